@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 
 	"github.com/langgenius/dify-plugin-daemon/cmd/commandline/plugin"
+	"github.com/langgenius/dify-plugin-daemon/pkg/plugin_packager/packager"
 	"github.com/spf13/cobra"
 )
 
@@ -104,7 +105,19 @@ If no parameters are provided, an interactive mode will be started.`,
 				maxSizeMB = 50
 			}
 			maxSizeBytes := maxSizeMB * 1024 * 1024
-			plugin.PackagePlugin(inputPath, outputPath, maxSizeBytes)
+
+			// vendor wheels of the requirements for offline installation
+			var wheelhouse *packager.WheelhouseOptions
+			if enabled, _ := cmd.Flags().GetBool("wheelhouse"); enabled {
+				pythonPath, _ := cmd.Flags().GetString("wheelhouse-python")
+				indexUrl, _ := cmd.Flags().GetString("wheelhouse-index-url")
+				wheelhouse = &packager.WheelhouseOptions{
+					PythonPath: pythonPath,
+					IndexUrl:   indexUrl,
+				}
+			}
+
+			plugin.PackagePlugin(inputPath, outputPath, maxSizeBytes, wheelhouse)
 		},
 	}
 
@@ -268,10 +281,15 @@ func init() {
   - extension: Extension plugin
   - agent-strategy: Agent strategy plugin`)
 	pluginInitCommand.Flags().StringVar(&language, "language", "", `Programming language. Available options:
-  - python: Python language`)
+  - python: Python language
+  - go: Go language, only tool plugins are supported
+  - nodejs: Node.js with TypeScript, only tool plugins are supported`)
 	pluginInitCommand.Flags().StringVar(&minDifyVersion, "min-dify-version", "", "Minimum Dify version required")
 	pluginInitCommand.Flags().BoolVar(&quick, "quick", false, "Skip interactive mode and create plugin directly")
 
 	pluginPackageCommand.Flags().StringP("output_path", "o", "", "output path")
 	pluginPackageCommand.Flags().Int64Var(&maxSizeMB, "max-size", 50, "Maximum uncompressed size in MB")
+	pluginPackageCommand.Flags().Bool("wheelhouse", false, "Vendor wheels of requirements.txt for all declared archs, the plugin is installed without internet access")
+	pluginPackageCommand.Flags().String("wheelhouse-python", "python3", "Python interpreter with pip used to download wheels")
+	pluginPackageCommand.Flags().String("wheelhouse-index-url", "", "Index to download wheels from, pip's default index if empty")
 }
@@ -0,0 +1,98 @@
+package plugin
+
+import (
+	_ "embed"
+	"fmt"
+	"path/filepath"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+)
+
+//go:embed templates/go/main.go.tmpl
+var GO_ENTRYPOINT_TEMPLATE []byte
+
+//go:embed templates/go/tools.go.tmpl
+var GO_TOOLS_TEMPLATE []byte
+
+//go:embed templates/go/go.mod.tmpl
+var GO_MOD_TEMPLATE []byte
+
+//go:embed templates/go/tool_provider.yaml
+var GO_TOOL_PROVIDER_TEMPLATE []byte
+
+//go:embed templates/go/tool.yaml
+var GO_TOOL_TEMPLATE []byte
+
+//go:embed templates/go/GUIDE.md
+var GO_GUIDE []byte
+
+//go:embed templates/go/.difyignore
+var GO_DIFYIGNORE []byte
+
+//go:embed templates/go/.gitignore
+var GO_GITIGNORE []byte
+
+// categories supported by the go template
+var goCategories = []string{"tool"}
+
+func createGoEnvironment(
+	root string, manifest *plugin_entities.PluginDeclaration, category string,
+) error {
+	if category != "tool" {
+		return fmt.Errorf("go template only supports categories: %v", goCategories)
+	}
+
+	guide, err := renderTemplate(GO_GUIDE, manifest, []string{})
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(root, "GUIDE.md"), guide); err != nil {
+		return err
+	}
+
+	goMod, err := renderTemplate(GO_MOD_TEMPLATE, manifest, []string{})
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(root, "go.mod"), goMod); err != nil {
+		return err
+	}
+
+	if err := writeFile(filepath.Join(root, "main.go"), string(GO_ENTRYPOINT_TEMPLATE)); err != nil {
+		return err
+	}
+
+	tools, err := renderTemplate(GO_TOOLS_TEMPLATE, manifest, []string{})
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(root, "tools.go"), tools); err != nil {
+		return err
+	}
+
+	if err := writeFile(filepath.Join(root, ".difyignore"), string(GO_DIFYIGNORE)); err != nil {
+		return err
+	}
+
+	if err := writeFile(filepath.Join(root, ".gitignore"), string(GO_GITIGNORE)); err != nil {
+		return err
+	}
+
+	toolProvider, err := renderTemplate(GO_TOOL_PROVIDER_TEMPLATE, manifest, []string{})
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(root, "provider", fmt.Sprintf("%s.yaml", manifest.Name)), toolProvider); err != nil {
+		return err
+	}
+
+	tool, err := renderTemplate(GO_TOOL_TEMPLATE, manifest, []string{})
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(root, "tools", fmt.Sprintf("%s.yaml", manifest.Name)), tool); err != nil {
+		return err
+	}
+
+	return nil
+}
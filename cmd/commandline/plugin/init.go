@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	_ "embed"
@@ -129,7 +130,8 @@ func InitPluginWithFlags(
 	if languageStr != "" {
 		validLanguages := []string{
 			string(constants.Python),
-			// Add more languages here if supported
+			string(constants.Go),
+			string(constants.NodeJS),
 		}
 		valid := false
 		for _, lang := range validLanguages {
@@ -172,6 +174,15 @@ func InitPluginWithFlags(
 		}
 	}
 
+	if languageStr == string(constants.Go) && categoryStr != "" && !slices.Contains(goCategories, categoryStr) {
+		log.Error("Go template only supports categories: %v", goCategories)
+		return
+	}
+	if languageStr == string(constants.NodeJS) && categoryStr != "" && !slices.Contains(nodejsCategories, categoryStr) {
+		log.Error("Node.js template only supports categories: %v", nodejsCategories)
+		return
+	}
+
 	m := newModel()
 
 	// Set profile information
@@ -471,6 +482,16 @@ func (m model) createPlugin() {
 		manifest.Meta.Runner.Entrypoint = "main"
 		manifest.Meta.Runner.Language = constants.Python
 		manifest.Meta.Runner.Version = "3.12"
+	case constants.Go:
+		// entrypoint is the package to build
+		manifest.Meta.Runner.Entrypoint = "."
+		manifest.Meta.Runner.Language = constants.Go
+		manifest.Meta.Runner.Version = "1.23"
+	case constants.NodeJS:
+		// entrypoint is the file built by `npm run build`
+		manifest.Meta.Runner.Entrypoint = "dist/main.js"
+		manifest.Meta.Runner.Language = constants.NodeJS
+		manifest.Meta.Runner.Version = "20"
 	default:
 		log.Error("unsupported language: %s", m.subMenus[SUB_MENU_KEY_LANGUAGE].(language).Language())
 		return
@@ -588,15 +609,38 @@ func (m model) createPlugin() {
 		return
 	}
 
-	err = createPythonEnvironment(
-		pluginDir,
-		manifest.Meta.Runner.Entrypoint,
-		manifest,
-		m.subMenus[SUB_MENU_KEY_CATEGORY].(category).Category(),
-	)
-	if err != nil {
-		log.Error("failed to create python environment: %s", err)
-		return
+	switch manifest.Meta.Runner.Language {
+	case constants.Go:
+		err = createGoEnvironment(
+			pluginDir,
+			manifest,
+			m.subMenus[SUB_MENU_KEY_CATEGORY].(category).Category(),
+		)
+		if err != nil {
+			log.Error("failed to create go environment: %s", err)
+			return
+		}
+	case constants.NodeJS:
+		err = createNodeJSEnvironment(
+			pluginDir,
+			manifest,
+			m.subMenus[SUB_MENU_KEY_CATEGORY].(category).Category(),
+		)
+		if err != nil {
+			log.Error("failed to create nodejs environment: %s", err)
+			return
+		}
+	default:
+		err = createPythonEnvironment(
+			pluginDir,
+			manifest.Meta.Runner.Entrypoint,
+			manifest,
+			m.subMenus[SUB_MENU_KEY_CATEGORY].(category).Category(),
+		)
+		if err != nil {
+			log.Error("failed to create python environment: %s", err)
+			return
+		}
 	}
 
 	success = true
@@ -2,8 +2,10 @@ package plugin
 
 import (
 	"os"
+	"os/exec"
 	"testing"
 
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/constants"
 	"github.com/langgenius/dify-plugin-daemon/pkg/plugin_packager/decoder"
 	"github.com/stretchr/testify/assert"
 )
@@ -353,3 +355,101 @@ func TestInitPluginWithFlagsValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestInitGoPlugin(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldDir, err := os.Getwd()
+	assert.NoError(t, err)
+	defer os.Chdir(oldDir)
+	assert.NoError(t, os.Chdir(tempDir))
+
+	InitPluginWithFlags(
+		"test-author",
+		"go_plugin",
+		"",
+		"Test go plugin",
+		false, true, false, false, false, false, false, false, false, false, false, false,
+		0,
+		"tool",
+		"go",
+		"",
+		true,
+	)
+
+	for _, file := range []string{
+		"go_plugin/manifest.yaml",
+		"go_plugin/go.mod",
+		"go_plugin/main.go",
+		"go_plugin/tools.go",
+		"go_plugin/provider/go_plugin.yaml",
+		"go_plugin/tools/go_plugin.yaml",
+	} {
+		_, err := os.Stat(file)
+		assert.NoError(t, err, "Expected file %s to exist", file)
+	}
+
+	decoder, err := decoder.NewFSPluginDecoder("go_plugin")
+	assert.NoError(t, err)
+	defer decoder.Close()
+
+	manifest, err := decoder.Manifest()
+	assert.NoError(t, err)
+	assert.Equal(t, constants.Go, manifest.Meta.Runner.Language)
+	assert.Equal(t, ".", manifest.Meta.Runner.Entrypoint)
+
+	// make sure the template compiles
+	goPath, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+	cmd := exec.Command(goPath, "vet", ".")
+	cmd.Dir = "go_plugin"
+	output, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(output))
+}
+
+func TestInitNodeJSPlugin(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldDir, err := os.Getwd()
+	assert.NoError(t, err)
+	defer os.Chdir(oldDir)
+	assert.NoError(t, os.Chdir(tempDir))
+
+	InitPluginWithFlags(
+		"test-author",
+		"nodejs_plugin",
+		"",
+		"Test nodejs plugin",
+		false, true, false, false, false, false, false, false, false, false, false, false,
+		0,
+		"tool",
+		"nodejs",
+		"",
+		true,
+	)
+
+	for _, file := range []string{
+		"nodejs_plugin/manifest.yaml",
+		"nodejs_plugin/package.json",
+		"nodejs_plugin/tsconfig.json",
+		"nodejs_plugin/src/main.ts",
+		"nodejs_plugin/src/messages.ts",
+		"nodejs_plugin/src/tools.ts",
+		"nodejs_plugin/provider/nodejs_plugin.yaml",
+		"nodejs_plugin/tools/nodejs_plugin.yaml",
+	} {
+		_, err := os.Stat(file)
+		assert.NoError(t, err, "Expected file %s to exist", file)
+	}
+
+	decoder, err := decoder.NewFSPluginDecoder("nodejs_plugin")
+	assert.NoError(t, err)
+	defer decoder.Close()
+
+	manifest, err := decoder.Manifest()
+	assert.NoError(t, err)
+	assert.Equal(t, constants.NodeJS, manifest.Meta.Runner.Language)
+	assert.Equal(t, "dist/main.js", manifest.Meta.Runner.Entrypoint)
+}
@@ -9,7 +9,8 @@ import (
 
 var languages = []constants.Language{
 	constants.Python,
-	constants.Go + " (not supported yet)",
+	constants.Go,
+	constants.NodeJS,
 }
 
 type language struct {
@@ -29,7 +30,8 @@ func (l language) Language() constants.Language {
 
 func (l language) View() string {
 	s := `Select the language you want to use for plugin development, and press ` + GREEN + `Enter` + RESET + ` to continue, 
-BTW, you need Python 3.12+ to develop the Plugin if you choose Python.
+BTW, you need Python 3.12+ to develop the Plugin if you choose Python, Go 1.23+ if you choose Go,
+or Node.js 20+ if you choose Node.js, Go and Node.js templates only support tool plugins for now.
 `
 	for i, language := range languages {
 		if i == l.cursor {
@@ -58,11 +60,6 @@ func (l language) Update(msg tea.Msg) (subMenu, subMenuEvent, tea.Cmd) {
 				l.cursor = 0
 			}
 		case "enter":
-			if l.cursor != 0 {
-				l.cursor = 0
-				return l, SUB_MENU_EVENT_NONE, nil
-			}
-
 			return l, SUB_MENU_EVENT_NEXT, nil
 		}
 	}
@@ -0,0 +1,112 @@
+package plugin
+
+import (
+	_ "embed"
+	"fmt"
+	"path/filepath"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+)
+
+//go:embed templates/nodejs/src/main.ts
+var NODEJS_ENTRYPOINT_TEMPLATE []byte
+
+//go:embed templates/nodejs/src/messages.ts
+var NODEJS_MESSAGES_TEMPLATE []byte
+
+//go:embed templates/nodejs/src/tools.ts.tmpl
+var NODEJS_TOOLS_TEMPLATE []byte
+
+//go:embed templates/nodejs/package.json.tmpl
+var NODEJS_PACKAGE_JSON_TEMPLATE []byte
+
+//go:embed templates/nodejs/tsconfig.json
+var NODEJS_TSCONFIG_TEMPLATE []byte
+
+//go:embed templates/nodejs/tool_provider.yaml
+var NODEJS_TOOL_PROVIDER_TEMPLATE []byte
+
+//go:embed templates/nodejs/tool.yaml
+var NODEJS_TOOL_TEMPLATE []byte
+
+//go:embed templates/nodejs/GUIDE.md
+var NODEJS_GUIDE []byte
+
+//go:embed templates/nodejs/.difyignore
+var NODEJS_DIFYIGNORE []byte
+
+//go:embed templates/nodejs/.gitignore
+var NODEJS_GITIGNORE []byte
+
+// categories supported by the nodejs template
+var nodejsCategories = []string{"tool"}
+
+func createNodeJSEnvironment(
+	root string, manifest *plugin_entities.PluginDeclaration, category string,
+) error {
+	if category != "tool" {
+		return fmt.Errorf("nodejs template only supports categories: %v", nodejsCategories)
+	}
+
+	guide, err := renderTemplate(NODEJS_GUIDE, manifest, []string{})
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(root, "GUIDE.md"), guide); err != nil {
+		return err
+	}
+
+	packageJson, err := renderTemplate(NODEJS_PACKAGE_JSON_TEMPLATE, manifest, []string{})
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(root, "package.json"), packageJson); err != nil {
+		return err
+	}
+
+	if err := writeFile(filepath.Join(root, "tsconfig.json"), string(NODEJS_TSCONFIG_TEMPLATE)); err != nil {
+		return err
+	}
+
+	if err := writeFile(filepath.Join(root, "src", "main.ts"), string(NODEJS_ENTRYPOINT_TEMPLATE)); err != nil {
+		return err
+	}
+
+	if err := writeFile(filepath.Join(root, "src", "messages.ts"), string(NODEJS_MESSAGES_TEMPLATE)); err != nil {
+		return err
+	}
+
+	tools, err := renderTemplate(NODEJS_TOOLS_TEMPLATE, manifest, []string{})
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(root, "src", "tools.ts"), tools); err != nil {
+		return err
+	}
+
+	if err := writeFile(filepath.Join(root, ".difyignore"), string(NODEJS_DIFYIGNORE)); err != nil {
+		return err
+	}
+
+	if err := writeFile(filepath.Join(root, ".gitignore"), string(NODEJS_GITIGNORE)); err != nil {
+		return err
+	}
+
+	toolProvider, err := renderTemplate(NODEJS_TOOL_PROVIDER_TEMPLATE, manifest, []string{})
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(root, "provider", fmt.Sprintf("%s.yaml", manifest.Name)), toolProvider); err != nil {
+		return err
+	}
+
+	tool, err := renderTemplate(NODEJS_TOOL_TEMPLATE, manifest, []string{})
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(root, "tools", fmt.Sprintf("%s.yaml", manifest.Name)), tool); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -8,7 +8,13 @@ import (
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
 )
 
-func PackagePlugin(inputPath string, outputPath string, maxSizeBytes int64) {
+// PackagePlugin packages the plugin, wheels of its requirements are vendored if wheelhouse is set
+func PackagePlugin(
+	inputPath string,
+	outputPath string,
+	maxSizeBytes int64,
+	wheelhouse *packager.WheelhouseOptions,
+) {
 	decoder, err := decoder.NewFSPluginDecoder(inputPath)
 	if err != nil {
 		log.Error("failed to create plugin decoder , plugin path: %s, error: %v", inputPath, err)
@@ -17,6 +23,9 @@ func PackagePlugin(inputPath string, outputPath string, maxSizeBytes int64) {
 	}
 
 	packager := packager.NewPackager(decoder)
+	if wheelhouse != nil {
+		packager.WithWheelhouse(*wheelhouse)
+	}
 	zipFile, err := packager.Pack(maxSizeBytes)
 
 	if err != nil {
@@ -0,0 +1,95 @@
+// This file implements the stdio protocol between the Dify plugin daemon and the plugin.
+//
+// The daemon writes one JSON request per line to stdin, the plugin writes one JSON event per line to stdout:
+//   - heartbeat: {"event": "heartbeat"}, the first one marks the plugin as ready
+//   - session:   {"event": "session", "session_id": "...", "data": {"type": "stream" | "end" | "error", "data": ...}}
+//   - log:       {"event": "log", "data": {"level": "info", "message": "...", "timestamp": 0}}
+//
+// Requests of type `ping` are liveness probes of the daemon, they are answered by the request loop itself,
+// a plugin which stops answering them is restarted.
+//
+// Put your business logic in tools.ts, you do not need to touch this file in most cases.
+import * as readline from "node:readline";
+import { Parameters } from "./messages";
+import { invokeTool, validateCredentials } from "./tools";
+
+const HEARTBEAT_INTERVAL = 10 * 1000;
+
+interface Invocation {
+  type: string;
+  action: string;
+  provider: string;
+  tool: string;
+  tool_parameters: Parameters;
+  credentials: Parameters;
+}
+
+function writeEvent(event: Record<string, unknown>): void {
+  process.stdout.write(JSON.stringify(event) + "\n");
+}
+
+function writeSession(sessionId: string, type: string, data: unknown): void {
+  writeEvent({ event: "session", session_id: sessionId, data: { type, data } });
+}
+
+// log sends a log message to the daemon, never use console.log, stdout is used by the protocol
+export function log(message: string): void {
+  writeEvent({
+    event: "log",
+    data: { level: "info", message, timestamp: Date.now() / 1000 },
+  });
+}
+
+async function handle(sessionId: string, invocation: Invocation): Promise<void> {
+  try {
+    switch (invocation.action) {
+      case "invoke_tool":
+        await invokeTool(
+          invocation.tool,
+          invocation.tool_parameters ?? {},
+          invocation.credentials ?? {},
+          (chunk) => writeSession(sessionId, "stream", chunk),
+        );
+        break;
+      case "validate_tool_credentials":
+        await validateCredentials(invocation.credentials ?? {});
+        writeSession(sessionId, "stream", { result: true });
+        break;
+      default:
+        throw new Error(`action ${invocation.action} is not supported`);
+    }
+    writeSession(sessionId, "end", null);
+  } catch (e) {
+    writeSession(sessionId, "error", {
+      error_type: "PluginInvokeError",
+      message: e instanceof Error ? e.message : String(e),
+    });
+  }
+}
+
+function main(): void {
+  writeEvent({ event: "heartbeat" });
+  setInterval(() => writeEvent({ event: "heartbeat" }), HEARTBEAT_INTERVAL);
+
+  const lines = readline.createInterface({ input: process.stdin });
+  lines.on("line", (line) => {
+    let request: { session_id: string; event: string; data: Invocation };
+    try {
+      request = JSON.parse(line);
+    } catch {
+      return;
+    }
+    if (request.event !== "request") {
+      return;
+    }
+    if (request.data?.type === "ping") {
+      writeSession(request.session_id, "end", null);
+      return;
+    }
+    void handle(request.session_id, request.data);
+  });
+  // the daemon closes stdin to stop the plugin
+  lines.on("close", () => process.exit(0));
+}
+
+main();
@@ -0,0 +1,16 @@
+// chunks of a tool response sent back to the daemon
+export type Parameters = Record<string, unknown>;
+
+export interface ToolMessage {
+  type: string;
+  message: Record<string, unknown>;
+  meta?: Record<string, unknown>;
+}
+
+export function textMessage(text: string): ToolMessage {
+  return { type: "text", message: { text } };
+}
+
+export function jsonMessage(object: Record<string, unknown>): ToolMessage {
+  return { type: "json", message: { json_object: object } };
+}
@@ -23,6 +23,12 @@ func (c *ControlPanel) buildLocalPluginRuntime(
 		return nil, nil, errors.Join(err, fmt.Errorf("construct plugin runtime error"))
 	}
 
+	if c.pythonEnvironmentCache != nil {
+		runtime.SetPythonEnvironmentCache(c.pythonEnvironmentCache)
+	}
+
+	runtime.SetLogBuffer(c.localPluginLogBuffer(pluginUniqueIdentifier))
+
 	return runtime, decoder, nil
 }
 
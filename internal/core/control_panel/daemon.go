@@ -10,6 +10,7 @@ import (
 	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/lock"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/mapping"
 )
 
@@ -52,6 +53,19 @@ type ControlPanel struct {
 		LocalPluginFailsRecord,
 	]
 
+	// on demand launches in progress, concurrent dispatches to the same plugin share one launch
+	localPluginOnDemandLaunches mapping.Map[
+		plugin_entities.PluginUniqueIdentifier,
+		*localPluginOnDemandLaunch,
+	]
+
+	// idle local plugins being stopped in on demand mode
+	// dispatches treat them as not running and launch them again once they are removed
+	localPluginStopping mapping.Map[
+		plugin_entities.PluginUniqueIdentifier,
+		*localPluginStop,
+	]
+
 	// this map marks plugins which should be ignored by `WatchDog`
 	// once a plugin is added, the launch process will be prevented
 	localPluginWatchIgnoreList mapping.Map[
@@ -59,11 +73,70 @@ type ControlPanel struct {
 		bool,
 	]
 
+	// local plugins being drained after upgrades
+	// original plugin unique identifier -> the plugin replacing it
+	// new sessions to the original plugin are dispatched to the successor
+	localPluginSuccessors mapping.Map[
+		plugin_entities.PluginUniqueIdentifier,
+		plugin_entities.PluginUniqueIdentifier,
+	]
+
+	// local plugins quarantined by operators, they can't be launched until released
+	localPluginQuarantines mapping.Map[
+		plugin_entities.PluginUniqueIdentifier,
+		LocalPluginQuarantine,
+	]
+
+	// resolves quarantines applied to the whole cluster from persistent storage
+	localPluginQuarantineResolver func(plugin_entities.PluginUniqueIdentifier) (*LocalPluginQuarantine, error)
+
+	// replicas of local plugins set by operators, applied every time a plugin launches
+	localPluginReplicas mapping.Map[
+		plugin_entities.PluginUniqueIdentifier,
+		int32,
+	]
+
+	// logs of local plugins, kept across runtimes of the same plugin until it's uninstalled
+	localPluginLogBuffers mapping.Map[
+		plugin_entities.PluginUniqueIdentifier,
+		*local_runtime.PluginLogBuffer,
+	]
+
+	// python environments shared across local plugins, nil if disabled
+	pythonEnvironmentCache *local_runtime.PythonEnvironmentCache
+
 	// local plugin installation lock
 	// locks when a plugin is on its installation process, avoid the same plugin
 	// to be processed concurrently
 	localPluginInstallationLock *lock.GranularityLock
 
+	// install source of local plugins, e.g. marketplace, github, package
+	// it's used to decide the sandbox profile of a plugin
+	// only plugins being installed are recorded, others are resolved by `localPluginSourceResolver`
+	localPluginSources mapping.Map[
+		plugin_entities.PluginUniqueIdentifier,
+		string,
+	]
+
+	// resolves install source of a local plugin from persistent storage
+	localPluginSourceResolver func(plugin_entities.PluginUniqueIdentifier) (string, error)
+
+	// resolves ids of local plugins pinned by operators from persistent storage
+	// pinned plugins are always running in on demand mode
+	localPluginPinResolver func() ([]string, error)
+
+	// resolves environment variables defined by operators for a local plugin
+	// returns variables for all versions of the plugin and variables for the installed package
+	localPluginEnvironmentResolver func(plugin_entities.PluginUniqueIdentifier) (
+		map[string]string, map[string]string, error,
+	)
+
+	// avoid disk garbage collections running concurrently
+	diskGCLock sync.Mutex
+	// when each orphan was first found by disk garbage collections, keyed by `kind:path`
+	// kept in memory, the grace period restarts after the daemon restarts
+	diskGCOrphanedAt map[string]time.Time
+
 	// debugging plugin runtime
 	debuggingPluginRuntime mapping.Map[
 		plugin_entities.PluginUniqueIdentifier,
@@ -72,8 +145,8 @@ type ControlPanel struct {
 }
 
 type LocalPluginFailsRecord struct {
-	RetryCount  int32
-	LastTriedAt time.Time
+	RetryCount  int32     `json:"retry_count"`
+	LastTriedAt time.Time `json:"last_tried_at"`
 }
 
 // create a new control panel as the engine of the local plugin daemon
@@ -83,7 +156,7 @@ func NewControlPanel(
 	packageBucket *media_transport.PackageBucket,
 	installedBucket *media_transport.InstalledBucket,
 ) *ControlPanel {
-	return &ControlPanel{
+	controlPanel := &ControlPanel{
 		config:          config,
 		mediaBucket:     mediaBucket,
 		packageBucket:   packageBucket,
@@ -98,4 +171,19 @@ func NewControlPanel(
 		// local plugin installation lock
 		localPluginInstallationLock: lock.NewGranularityLock(),
 	}
+
+	if config.Platform == app.PLATFORM_LOCAL && config.PythonEnvCacheEnabled {
+		cache, err := local_runtime.NewPythonEnvironmentCache(
+			config.PythonEnvCachePath,
+			time.Duration(config.PythonEnvCacheGCGracePeriod)*time.Second,
+		)
+		if err != nil {
+			// fallback to private environments
+			log.Error("failed to init python environment cache: %s", err.Error())
+		} else {
+			controlPanel.pythonEnvironmentCache = cache
+		}
+	}
+
+	return controlPanel
 }
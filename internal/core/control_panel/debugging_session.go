@@ -0,0 +1,98 @@
+package controlpanel
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/core/debugging_runtime"
+	"github.com/langgenius/dify-plugin-daemon/internal/service/debugging_service"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+)
+
+// DebuggingSession describes a debugging plugin connected to this node
+type DebuggingSession struct {
+	PluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier `json:"plugin_unique_identifier"`
+	TenantID               string                                 `json:"tenant_id"`
+	// the debugging key it connected with and the user who created the key
+	KeyID            string    `json:"key_id"`
+	UserID           string    `json:"user_id"`
+	RemoteAddr       string    `json:"remote_addr"`
+	ConnectedAt      time.Time `json:"connected_at"`
+	LastActiveAt     time.Time `json:"last_active_at"`
+	InFlightSessions int       `json:"in_flight_sessions"`
+}
+
+// DebuggingSessions lists debugging plugins connected to this node, all tenants are listed if `tenantId` is empty
+func (c *ControlPanel) DebuggingSessions(tenantId string) []DebuggingSession {
+	sessions := []DebuggingSession{}
+
+	c.debuggingPluginRuntime.Range(func(
+		pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+		runtime *debugging_runtime.RemotePluginRuntime,
+	) bool {
+		if tenantId != "" && runtime.TenantId() != tenantId {
+			return true
+		}
+		sessions = append(sessions, DebuggingSession{
+			PluginUniqueIdentifier: pluginUniqueIdentifier,
+			TenantID:               runtime.TenantId(),
+			KeyID:                  runtime.KeyId(),
+			UserID:                 runtime.UserId(),
+			RemoteAddr:             runtime.RemoteAddr(),
+			ConnectedAt:            runtime.ConnectedAt(),
+			LastActiveAt:           runtime.LastActiveAt(),
+			InFlightSessions:       runtime.InFlightSessions(),
+		})
+		return true
+	})
+
+	slices.SortFunc(sessions, func(a, b DebuggingSession) int {
+		return strings.Compare(a.PluginUniqueIdentifier.String(), b.PluginUniqueIdentifier.String())
+	})
+
+	return sessions
+}
+
+// KickDebuggingRuntime disconnects a debugging plugin of a tenant from this node
+// the plugin may connect again with a valid key
+func (c *ControlPanel) KickDebuggingRuntime(
+	tenantId string,
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) error {
+	runtime, ok := c.debuggingPluginRuntime.Load(pluginUniqueIdentifier)
+	if !ok || runtime.TenantId() != tenantId {
+		return ErrDebuggingRuntimeNotFound
+	}
+
+	runtime.Stop()
+	return nil
+}
+
+// kickDebuggingRuntimesByKey disconnects debugging plugins connected with a key, returns how many were found
+func (c *ControlPanel) kickDebuggingRuntimesByKey(tenantId string, keyId string) int {
+	kicked := 0
+	c.debuggingPluginRuntime.Range(func(
+		pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+		runtime *debugging_runtime.RemotePluginRuntime,
+	) bool {
+		if runtime.TenantId() == tenantId && runtime.KeyId() == keyId {
+			runtime.Stop()
+			kicked++
+		}
+		return true
+	})
+	return kicked
+}
+
+func (c *ControlPanel) watchRevokedDebuggingKeys() {
+	revoked, cancel := debugging_service.SubscribeRevokedConnectionKeys()
+	defer cancel()
+
+	for key := range revoked {
+		if kicked := c.kickDebuggingRuntimesByKey(key.TenantId, key.KeyId); kicked > 0 {
+			log.Info("disconnected %d debugging plugins of tenant %s as key %s was revoked", kicked, key.TenantId, key.KeyId)
+		}
+	}
+}
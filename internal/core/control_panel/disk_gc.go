@@ -0,0 +1,370 @@
+package controlpanel
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+)
+
+const (
+	// the working path of a plugin, including its venv
+	DISK_GC_KIND_WORKING_PATH = "working_path"
+	// stderr logs of a plugin kept outside of its working path
+	DISK_GC_KIND_STDERR_LOG = "stderr_log"
+	// the package of a plugin cached in `PLUGIN_PACKAGE_CACHE_PATH`
+	DISK_GC_KIND_PACKAGE = "package"
+)
+
+const (
+	DISK_GC_ACTION_REMOVED = "removed"
+	// reported by dry runs instead of removing it
+	DISK_GC_ACTION_WOULD_REMOVE = "would_remove"
+	// it's younger than the grace period
+	DISK_GC_ACTION_KEPT   = "kept"
+	DISK_GC_ACTION_FAILED = "failed"
+)
+
+// a plugin may be installed right after installed plugins were listed
+// its working path is never removed under disk pressure until it has been orphaned for this long
+const diskGCMinAge = time.Minute
+
+// DiskUsage describes the filesystem the working path is on
+type DiskUsage struct {
+	Path        string  `json:"path"`
+	TotalBytes  uint64  `json:"total_bytes"`
+	UsedBytes   uint64  `json:"used_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// DiskGCOrphan is a directory or package which belongs to no installed plugin nor running runtime
+type DiskGCOrphan struct {
+	Kind       string    `json:"kind"`
+	Path       string    `json:"path"`
+	SizeBytes  int64     `json:"size_bytes"`
+	ModifiedAt time.Time `json:"modified_at"`
+	// when it was first found orphaned by this node, the grace period starts from it
+	OrphanedAt time.Time `json:"orphaned_at"`
+	Action     string    `json:"action"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// DiskGCReport is the result of a disk garbage collection on this node
+type DiskGCReport struct {
+	DryRun             bool       `json:"dry_run"`
+	GracePeriodSeconds int        `json:"grace_period_seconds"`
+	HighWaterMark      int        `json:"high_water_mark"`
+	Usage              *DiskUsage `json:"usage,omitempty"`
+	// orphans on the local disk are removed regardless of the grace period
+	HighWaterMarkExceeded bool `json:"high_water_mark_exceeded"`
+	// removed bytes, or bytes would be removed by a dry run
+	ReclaimedBytes int64          `json:"reclaimed_bytes"`
+	Orphans        []DiskGCOrphan `json:"orphans"`
+}
+
+// CollectDisk removes working paths, stderr logs and cached packages of plugins
+// which are neither installed nor running on this node, orphans found within the grace period are kept
+// unless disk usage of the working path exceeds the high-water mark
+// the grace period starts when an orphan is first found, its modification time says nothing
+// about when the plugin was uninstalled
+// nothing is removed by a dry run, the report shows what would be removed
+func (c *ControlPanel) CollectDisk(dryRun bool) (DiskGCReport, error) {
+	c.diskGCLock.Lock()
+	defer c.diskGCLock.Unlock()
+
+	report := DiskGCReport{
+		DryRun:             dryRun,
+		GracePeriodSeconds: c.config.PluginDiskGCGracePeriod,
+		HighWaterMark:      c.config.PluginDiskGCHighWaterMark,
+		Orphans:            []DiskGCOrphan{},
+	}
+
+	// never remove anything if installed plugins are unknown
+	installed, err := c.installedBucket.List()
+	if err != nil {
+		return report, err
+	}
+
+	// identifiers and paths in use, never removed
+	identifiers := map[string]bool{}
+	inUse := map[string]bool{}
+	for _, identifier := range installed {
+		identifiers[identifier.String()] = true
+	}
+	c.localPluginRuntimes.Range(func(
+		key plugin_entities.PluginUniqueIdentifier,
+		value *local_runtime.LocalPluginRuntime,
+	) bool {
+		identifiers[key.String()] = true
+		inUse[absolutePath(value.State.WorkingPath)] = true
+		return true
+	})
+	// directories of the same layout, `author/name-version@checksum`
+	for identifier := range identifiers {
+		name := filepath.FromSlash(strings.ReplaceAll(identifier, ":", "-"))
+		inUse[absolutePath(filepath.Join(c.config.PluginWorkingPath, name))] = true
+		if c.config.PluginStderrLogPath != "" {
+			inUse[absolutePath(filepath.Join(c.config.PluginStderrLogPath, name))] = true
+		}
+	}
+
+	// other directories may be configured inside the working path
+	protected := []string{
+		absolutePath(c.config.PluginStderrLogPath),
+		absolutePath(c.config.PythonEnvCachePath),
+		absolutePath(c.config.PythonInterpreterCachePath),
+		absolutePath(c.config.PluginLogPath),
+	}
+
+	if c.config.PluginDiskGCHighWaterMark > 0 {
+		usage, err := diskUsage(c.config.PluginWorkingPath)
+		if err != nil {
+			log.Warn("failed to get disk usage of %s: %s", c.config.PluginWorkingPath, err.Error())
+		} else {
+			report.Usage = usage
+			report.HighWaterMarkExceeded = usage.UsedPercent >= float64(c.config.PluginDiskGCHighWaterMark)
+		}
+	}
+
+	gracePeriod := time.Duration(c.config.PluginDiskGCGracePeriod) * time.Second
+
+	// orphans are collected before any removal, paths which are not orphans anymore are forgotten
+	orphans := []DiskGCOrphan{}
+
+	// directories on the local disk, laid out as `author/name-version@checksum`
+	roots := map[string]string{DISK_GC_KIND_WORKING_PATH: c.config.PluginWorkingPath}
+	if c.config.PluginStderrLogPath != "" {
+		roots[DISK_GC_KIND_STDERR_LOG] = c.config.PluginStderrLogPath
+	}
+	for _, kind := range []string{DISK_GC_KIND_WORKING_PATH, DISK_GC_KIND_STDERR_LOG} {
+		root, ok := roots[kind]
+		if !ok {
+			continue
+		}
+		orphans = append(orphans, c.findOrphanDirectories(kind, root, inUse, protected)...)
+	}
+	orphans = append(orphans, c.findOrphanPackages(identifiers)...)
+	c.markOrphanedAt(orphans)
+
+	for _, orphan := range orphans {
+		age := time.Since(orphan.OrphanedAt)
+		switch orphan.Kind {
+		case DISK_GC_KIND_WORKING_PATH, DISK_GC_KIND_STDERR_LOG:
+			if age >= gracePeriod || (report.HighWaterMarkExceeded && age >= diskGCMinAge) {
+				c.removeOrphan(&orphan, dryRun, func() error {
+					return os.RemoveAll(orphan.Path)
+				})
+				// remove the author directory once it's empty
+				if orphan.Action == DISK_GC_ACTION_REMOVED {
+					os.Remove(filepath.Dir(orphan.Path))
+				}
+			} else {
+				orphan.Action = DISK_GC_ACTION_KEPT
+			}
+		case DISK_GC_KIND_PACKAGE:
+			// packages may be stored in a remote storage, the high-water mark doesn't apply
+			if age >= gracePeriod {
+				c.removeOrphan(&orphan, dryRun, func() error {
+					return c.packageBucket.Delete(orphan.Path)
+				})
+			} else {
+				orphan.Action = DISK_GC_ACTION_KEPT
+			}
+		}
+		report.add(orphan)
+	}
+
+	return report, nil
+}
+
+// markOrphanedAt sets when each orphan was first found, orphans found for the first time start from now
+// entries of paths which are not orphans anymore, e.g. removed or reinstalled, are dropped
+// the caller must hold `diskGCLock`
+func (c *ControlPanel) markOrphanedAt(orphans []DiskGCOrphan) {
+	now := time.Now()
+	orphanedAt := make(map[string]time.Time, len(orphans))
+	for i := range orphans {
+		key := orphans[i].Kind + ":" + orphans[i].Path
+		at, ok := c.diskGCOrphanedAt[key]
+		if !ok {
+			at = now
+		}
+		orphanedAt[key] = at
+		orphans[i].OrphanedAt = at
+	}
+	c.diskGCOrphanedAt = orphanedAt
+}
+
+func (c *ControlPanel) collectDiskPeriodically() {
+	for range time.NewTicker(time.Duration(c.config.PluginDiskGCInterval) * time.Second).C {
+		report, err := c.CollectDisk(false)
+		if err != nil {
+			log.Error("disk garbage collection failed: %s", err.Error())
+			continue
+		}
+
+		removed := 0
+		for _, orphan := range report.Orphans {
+			switch orphan.Action {
+			case DISK_GC_ACTION_REMOVED:
+				removed++
+			case DISK_GC_ACTION_FAILED:
+				log.Error("failed to remove %s %s: %s", orphan.Kind, orphan.Path, orphan.Error)
+			}
+		}
+		if removed > 0 {
+			log.Info("disk garbage collection removed %d orphans, %d bytes reclaimed", removed, report.ReclaimedBytes)
+		}
+	}
+}
+
+func (r *DiskGCReport) add(orphan DiskGCOrphan) {
+	if orphan.Action == DISK_GC_ACTION_REMOVED || orphan.Action == DISK_GC_ACTION_WOULD_REMOVE {
+		r.ReclaimedBytes += orphan.SizeBytes
+	}
+	r.Orphans = append(r.Orphans, orphan)
+}
+
+func (c *ControlPanel) removeOrphan(orphan *DiskGCOrphan, dryRun bool, remove func() error) {
+	if dryRun {
+		orphan.Action = DISK_GC_ACTION_WOULD_REMOVE
+		return
+	}
+
+	if err := remove(); err != nil {
+		orphan.Action = DISK_GC_ACTION_FAILED
+		orphan.Error = err.Error()
+		return
+	}
+
+	orphan.Action = DISK_GC_ACTION_REMOVED
+}
+
+// findOrphanDirectories lists directories of plugins under `root` which are not in use
+// hidden entries and entries containing protected paths are skipped
+func (c *ControlPanel) findOrphanDirectories(
+	kind string,
+	root string,
+	inUse map[string]bool,
+	protected []string,
+) []DiskGCOrphan {
+	orphans := []DiskGCOrphan{}
+
+	isProtected := func(path string) bool {
+		return slices.ContainsFunc(protected, func(p string) bool {
+			return p != "" && (p == path || strings.HasPrefix(p, path+string(filepath.Separator)))
+		})
+	}
+
+	authors, err := os.ReadDir(root)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Error("failed to list %s: %s", root, err.Error())
+		}
+		return orphans
+	}
+
+	for _, author := range authors {
+		authorPath := filepath.Join(root, author.Name())
+		if !author.IsDir() || strings.HasPrefix(author.Name(), ".") || isProtected(absolutePath(authorPath)) {
+			continue
+		}
+
+		entries, err := os.ReadDir(authorPath)
+		if err != nil {
+			log.Error("failed to list %s: %s", authorPath, err.Error())
+			continue
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+
+			path := filepath.Join(authorPath, entry.Name())
+			if inUse[absolutePath(path)] || isProtected(absolutePath(path)) {
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+
+			orphans = append(orphans, DiskGCOrphan{
+				Kind:       kind,
+				Path:       path,
+				SizeBytes:  directorySize(path),
+				ModifiedAt: info.ModTime(),
+			})
+		}
+	}
+
+	return orphans
+}
+
+// findOrphanPackages lists cached packages which belong to no plugin in `identifiers`
+func (c *ControlPanel) findOrphanPackages(identifiers map[string]bool) []DiskGCOrphan {
+	orphans := []DiskGCOrphan{}
+
+	names, err := c.packageBucket.List()
+	if err != nil {
+		log.Error("failed to list plugin packages: %s", err.Error())
+		return orphans
+	}
+
+	for _, name := range names {
+		if identifiers[name] {
+			continue
+		}
+
+		state, err := c.packageBucket.State(name)
+		if err != nil {
+			log.Error("failed to get state of plugin package %s: %s", name, err.Error())
+			continue
+		}
+
+		orphans = append(orphans, DiskGCOrphan{
+			Kind:       DISK_GC_KIND_PACKAGE,
+			Path:       name,
+			SizeBytes:  state.Size,
+			ModifiedAt: state.LastModified,
+		})
+	}
+
+	return orphans
+}
+
+// absolutePath returns the cleaned absolute path, empty paths stay empty
+func absolutePath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// directorySize returns the total size of regular files in a directory, unreadable files are skipped
+func directorySize(path string) int64 {
+	size := int64(0)
+	filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.Type().IsRegular() {
+			if info, err := entry.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
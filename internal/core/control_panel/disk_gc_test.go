@@ -0,0 +1,120 @@
+package controlpanel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/langgenius/dify-cloud-kit/oss"
+	"github.com/langgenius/dify-cloud-kit/oss/local"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager/media_transport"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectDisk(t *testing.T) {
+	root := t.TempDir()
+	storage, err := local.NewLocalStorage(oss.OSSArgs{Local: &oss.Local{Path: filepath.Join(root, "storage")}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	const checksum = "1234567890abcdef1234567890abcdef1234567890abcdef"
+	installed, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/a:1.0.0@" + checksum)
+	if !assert.NoError(t, err) {
+		return
+	}
+	running, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/b:1.0.0@" + checksum)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	config := &app.Config{
+		PluginWorkingPath:          filepath.Join(root, "cwd"),
+		PythonInterpreterCachePath: filepath.Join(root, "cwd", "interpreters"),
+		PluginLogPath:              filepath.Join(root, "cwd", "plugin_logs"),
+		PluginDiskGCGracePeriod:    3600,
+		PluginDiskGCHighWaterMark:  0,
+	}
+	controlPanel := &ControlPanel{
+		config:          config,
+		installedBucket: media_transport.NewInstalledBucket(storage, "plugin"),
+		packageBucket:   media_transport.NewPackageBucket(storage, "plugin_packages"),
+	}
+	assert.NoError(t, controlPanel.installedBucket.Save(installed, []byte("package")))
+	assert.NoError(t, controlPanel.packageBucket.Save(installed.String(), []byte("package")))
+	assert.NoError(t, controlPanel.packageBucket.Save("langgenius/c:1.0.0@"+checksum, []byte("package")))
+
+	runtime := &local_runtime.LocalPluginRuntime{}
+	runtime.State.WorkingPath = filepath.Join(config.PluginWorkingPath, "langgenius", "b-1.0.0@"+checksum)
+	controlPanel.localPluginRuntimes.Store(running, runtime)
+
+	mkdir := func(path string, modifiedAt time.Time) string {
+		assert.NoError(t, os.MkdirAll(path, 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(path, "main.py"), []byte("print(1)"), 0644))
+		assert.NoError(t, os.Chtimes(path, modifiedAt, modifiedAt))
+		return path
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	mkdir(filepath.Join(config.PluginWorkingPath, "langgenius", "a-1.0.0@"+checksum), old)
+	mkdir(filepath.Join(config.PluginWorkingPath, "langgenius", "b-1.0.0@"+checksum), old)
+	stale := mkdir(filepath.Join(config.PluginWorkingPath, "langgenius", "c-1.0.0@"+checksum), old)
+	fresh := mkdir(filepath.Join(config.PluginWorkingPath, "langgenius", "d-1.0.0@"+checksum), old)
+	// directories of the daemon laid out like plugins inside the working path
+	interpreter := mkdir(filepath.Join(config.PythonInterpreterCachePath, "cpython-3.11-linux"), old)
+	pluginLogs := mkdir(filepath.Join(config.PluginLogPath, "langgenius"), old)
+
+	collect := func(dryRun bool) (DiskGCReport, map[string]string) {
+		report, err := controlPanel.CollectDisk(dryRun)
+		assert.NoError(t, err)
+		actions := map[string]string{}
+		for _, orphan := range report.Orphans {
+			actions[orphan.Path] = orphan.Action
+		}
+		return report, actions
+	}
+
+	// orphans are just found, no matter how old they are
+	_, actions := collect(true)
+	assert.Equal(t, map[string]string{
+		stale:                            DISK_GC_ACTION_KEPT,
+		fresh:                            DISK_GC_ACTION_KEPT,
+		"langgenius/c:1.0.0@" + checksum: DISK_GC_ACTION_KEPT,
+	}, actions)
+
+	// the grace period passed since they were found
+	for _, key := range []string{
+		DISK_GC_KIND_WORKING_PATH + ":" + stale,
+		DISK_GC_KIND_PACKAGE + ":langgenius/c:1.0.0@" + checksum,
+	} {
+		assert.Contains(t, controlPanel.diskGCOrphanedAt, key)
+		controlPanel.diskGCOrphanedAt[key] = old
+	}
+
+	// a dry run removes nothing
+	report, actions := collect(true)
+	assert.Equal(t, map[string]string{
+		stale:                            DISK_GC_ACTION_WOULD_REMOVE,
+		fresh:                            DISK_GC_ACTION_KEPT,
+		"langgenius/c:1.0.0@" + checksum: DISK_GC_ACTION_WOULD_REMOVE,
+	}, actions)
+	assert.Equal(t, int64(len("print(1)")+len("package")), report.ReclaimedBytes)
+	assert.DirExists(t, stale)
+
+	report, _ = collect(false)
+	assert.Len(t, report.Orphans, 3)
+	assert.NoDirExists(t, stale)
+	assert.DirExists(t, fresh)
+	assert.DirExists(t, runtime.State.WorkingPath)
+	assert.DirExists(t, filepath.Join(config.PluginWorkingPath, "langgenius", "a-1.0.0@"+checksum))
+	assert.DirExists(t, interpreter)
+	assert.DirExists(t, pluginLogs)
+
+	// removed orphans are forgotten
+	_, actions = collect(true)
+	assert.Equal(t, map[string]string{fresh: DISK_GC_ACTION_KEPT}, actions)
+	assert.Len(t, controlPanel.diskGCOrphanedAt, 1)
+}
@@ -0,0 +1,26 @@
+package controlpanel
+
+import "syscall"
+
+// diskUsage returns usage of the filesystem the path is on
+func diskUsage(path string) (*DiskUsage, error) {
+	stat := syscall.Statfs_t{}
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return nil, err
+	}
+
+	total := stat.Blocks * uint64(stat.Bsize)
+	// blocks reserved for root are not available to the daemon, consider them used
+	available := stat.Bavail * uint64(stat.Bsize)
+	if total == 0 {
+		return &DiskUsage{Path: path}, nil
+	}
+
+	used := total - available
+	return &DiskUsage{
+		Path:        path,
+		TotalBytes:  total,
+		UsedBytes:   used,
+		UsedPercent: float64(used) * 100 / float64(total),
+	}, nil
+}
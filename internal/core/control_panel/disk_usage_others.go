@@ -0,0 +1,10 @@
+//go:build !linux
+
+package controlpanel
+
+import "errors"
+
+// diskUsage is only supported on linux, the high-water mark is ignored on other platforms
+func diskUsage(path string) (*DiskUsage, error) {
+	return nil, errors.New("disk usage is only supported on linux")
+}
@@ -0,0 +1,60 @@
+package controlpanel
+
+import (
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+)
+
+// SetLocalPluginEnvironmentResolver sets the resolver used to load environment variables
+// defined by operators, it's called every time a local plugin launches or reloads its environment
+func (c *ControlPanel) SetLocalPluginEnvironmentResolver(
+	resolver func(plugin_entities.PluginUniqueIdentifier) (map[string]string, map[string]string, error),
+) {
+	c.localPluginEnvironmentResolver = resolver
+}
+
+func (c *ControlPanel) applyLocalPluginEnvironment(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	runtime *local_runtime.LocalPluginRuntime,
+) {
+	if c.localPluginEnvironmentResolver == nil {
+		return
+	}
+
+	plugin, installation, err := c.localPluginEnvironmentResolver(pluginUniqueIdentifier)
+	if err != nil {
+		// launching a plugin without its variables is better than not launching it
+		log.Warn("failed to resolve environment of plugin %s: %s", pluginUniqueIdentifier, err.Error())
+		return
+	}
+
+	runtime.SetExtraEnvironment(plugin, installation)
+}
+
+// ReloadLocalPluginEnvironment reloads environment variables of all running versions of a plugin
+// NOTE: running instances are not affected, only new instances use the new variables
+func (c *ControlPanel) ReloadLocalPluginEnvironment(pluginID string) {
+	c.localPluginRuntimes.Range(func(
+		pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+		runtime *local_runtime.LocalPluginRuntime,
+	) bool {
+		if pluginUniqueIdentifier.PluginID() == pluginID {
+			c.applyLocalPluginEnvironment(pluginUniqueIdentifier, runtime)
+		}
+		return true
+	})
+}
+
+// LocalPluginInstanceEnvironments returns the effective environment of all instances of a local plugin
+// values are redacted
+func (c *ControlPanel) LocalPluginInstanceEnvironments(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) ([]local_runtime.InstanceEnvironment, error) {
+	runtime, ok := c.localPluginRuntimes.Load(pluginUniqueIdentifier)
+	if !ok {
+		return nil, ErrPluginRuntimeNotFound
+	}
+
+	return runtime.InstanceEnvironments(), nil
+}
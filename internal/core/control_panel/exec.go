@@ -7,4 +7,15 @@ var (
 	ErrLocalPluginRuntimeNotFound = errors.New("local plugin runtime not found")
 
 	ErrPluginRuntimeNotFound = errors.New("plugin runtime not found")
+	ErrPluginLogsNotFound    = errors.New("no logs of the plugin on this node")
+
+	ErrLocalPluginQuarantined    = errors.New("local plugin is quarantined")
+	ErrLocalPluginNotQuarantined = errors.New("local plugin is not quarantined")
+
+	ErrLocalPluginNotInstalled          = errors.New("local plugin is not installed")
+	ErrLocalPluginAutoLaunchDisabled    = errors.New("local plugin is stopped by operators")
+	ErrLocalPluginLaunchBackoff         = errors.New("local plugin failed to launch recently, retry later")
+	ErrLocalPluginOnDemandLaunchTimeout = errors.New("timed out waiting for local plugin to launch")
+
+	ErrDebuggingRuntimeNotFound = errors.New("debugging runtime not found")
 )
@@ -0,0 +1,139 @@
+package controlpanel
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/core/debugging_runtime"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+)
+
+const (
+	// the plugin has no runtime on this node as it failed to launch, see `LaunchFailures`
+	RUNTIME_INVENTORY_STATUS_LAUNCH_FAILED = "launch_failed"
+	// the plugin has no runtime on this node as it's quarantined, see `Quarantine`
+	RUNTIME_INVENTORY_STATUS_QUARANTINED = "quarantined"
+)
+
+// RuntimeInventory describes a plugin runtime known by this node
+type RuntimeInventory struct {
+	PluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier `json:"plugin_unique_identifier"`
+	RuntimeType            plugin_entities.PluginRuntimeType      `json:"runtime_type"`
+	Status                 string                                 `json:"status"`
+	Instances              []local_runtime.InstanceInventory      `json:"instances"`
+	Replicas               int32                                  `json:"replicas"`
+	InFlightSessions       int                                    `json:"in_flight_sessions"`
+	Restarts               int                                    `json:"restarts"`
+	LastError              *local_runtime.RuntimeError            `json:"last_error"`
+	LastActiveAt           *time.Time                             `json:"last_active_at,omitempty"`
+	WorkingPath            string                                 `json:"working_path"`
+	LaunchFailures         *LocalPluginFailsRecord                `json:"launch_failures"`
+	Quarantine             *LocalPluginQuarantine                 `json:"quarantine,omitempty"`
+	// the plugin replacing it, set while the runtime is draining after an upgrade
+	Successor string `json:"successor,omitempty"`
+	// tenant a debugging plugin belongs to
+	TenantID string `json:"tenant_id,omitempty"`
+	// function a serverless plugin is deployed as
+	FunctionName string `json:"function_name,omitempty"`
+	FunctionURL  string `json:"function_url,omitempty"`
+}
+
+// RuntimeInventory lists local and debugging runtimes on this node
+// local plugins which failed to launch or are quarantined are listed as well
+func (c *ControlPanel) RuntimeInventory() []RuntimeInventory {
+	inventories := []RuntimeInventory{}
+
+	c.localPluginRuntimes.Range(func(
+		pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+		runtime *local_runtime.LocalPluginRuntime,
+	) bool {
+		inventory := RuntimeInventory{
+			PluginUniqueIdentifier: pluginUniqueIdentifier,
+			RuntimeType:            runtime.Type(),
+			Status:                 runtime.State.Status,
+			Instances:              runtime.Instances(),
+			Replicas:               runtime.Replicas(),
+			InFlightSessions:       runtime.InFlightSessions(),
+			Restarts:               runtime.State.Restarts,
+			LastError:              runtime.LastError(),
+			WorkingPath:            runtime.State.WorkingPath,
+		}
+		if record, ok := c.localPluginFailsRecord.Load(pluginUniqueIdentifier); ok {
+			inventory.LaunchFailures = &record
+		}
+		if successor, ok := c.localPluginSuccessors.Load(pluginUniqueIdentifier); ok {
+			inventory.Successor = successor.String()
+		}
+		// a runtime being stopped after it was quarantined
+		if quarantine, ok := c.localPluginQuarantines.Load(pluginUniqueIdentifier); ok {
+			inventory.Quarantine = &quarantine
+		}
+		inventories = append(inventories, inventory)
+		return true
+	})
+
+	c.localPluginQuarantines.Range(func(
+		pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+		quarantine LocalPluginQuarantine,
+	) bool {
+		if c.localPluginRuntimes.Exists(pluginUniqueIdentifier) {
+			return true
+		}
+		inventories = append(inventories, RuntimeInventory{
+			PluginUniqueIdentifier: pluginUniqueIdentifier,
+			RuntimeType:            plugin_entities.PLUGIN_RUNTIME_TYPE_LOCAL,
+			Status:                 RUNTIME_INVENTORY_STATUS_QUARANTINED,
+			Instances:              []local_runtime.InstanceInventory{},
+			Quarantine:             &quarantine,
+		})
+		return true
+	})
+
+	c.localPluginFailsRecord.Range(func(
+		pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+		record LocalPluginFailsRecord,
+	) bool {
+		if c.localPluginRuntimes.Exists(pluginUniqueIdentifier) ||
+			c.localPluginQuarantines.Exists(pluginUniqueIdentifier) {
+			return true
+		}
+		inventories = append(inventories, RuntimeInventory{
+			PluginUniqueIdentifier: pluginUniqueIdentifier,
+			RuntimeType:            plugin_entities.PLUGIN_RUNTIME_TYPE_LOCAL,
+			Status:                 RUNTIME_INVENTORY_STATUS_LAUNCH_FAILED,
+			Instances:              []local_runtime.InstanceInventory{},
+			LaunchFailures:         &record,
+		})
+		return true
+	})
+
+	c.debuggingPluginRuntime.Range(func(
+		pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+		runtime *debugging_runtime.RemotePluginRuntime,
+	) bool {
+		// debugging runtimes are active as long as they are connected
+		status := plugin_entities.PLUGIN_RUNTIME_STATUS_ACTIVE
+		if runtime.Stopped() {
+			status = plugin_entities.PLUGIN_RUNTIME_STATUS_STOPPED
+		}
+		lastActiveAt := runtime.LastActiveAt()
+		inventories = append(inventories, RuntimeInventory{
+			PluginUniqueIdentifier: pluginUniqueIdentifier,
+			RuntimeType:            runtime.Type(),
+			Status:                 status,
+			Instances:              []local_runtime.InstanceInventory{},
+			InFlightSessions:       runtime.InFlightSessions(),
+			LastActiveAt:           &lastActiveAt,
+			TenantID:               runtime.TenantId(),
+		})
+		return true
+	})
+
+	slices.SortFunc(inventories, func(a, b RuntimeInventory) int {
+		return strings.Compare(a.PluginUniqueIdentifier.String(), b.PluginUniqueIdentifier.String())
+	})
+
+	return inventories
+}
@@ -0,0 +1,26 @@
+package controlpanel
+
+import (
+	"testing"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntimeInventoryOfFailedPlugins(t *testing.T) {
+	identifier, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/a:1.0.0@1234567890abcdef1234567890abcdef1234567890abcdef")
+	assert.NoError(t, err)
+
+	controlPanel := &ControlPanel{}
+	controlPanel.localPluginFailsRecord.Store(identifier, LocalPluginFailsRecord{RetryCount: 3})
+
+	// a plugin without runtime is listed with its launch failure record
+	inventories := controlPanel.RuntimeInventory()
+	if !assert.Len(t, inventories, 1) {
+		return
+	}
+	assert.Equal(t, identifier, inventories[0].PluginUniqueIdentifier)
+	assert.Equal(t, plugin_entities.PLUGIN_RUNTIME_TYPE_LOCAL, inventories[0].RuntimeType)
+	assert.Equal(t, RUNTIME_INVENTORY_STATUS_LAUNCH_FAILED, inventories[0].Status)
+	assert.Equal(t, int32(3), inventories[0].LaunchFailures.RetryCount)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
 	routinepkg "github.com/langgenius/dify-plugin-daemon/pkg/routine"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/routine"
 )
 
@@ -26,9 +27,16 @@ func (c *ControlPanel) LaunchLocalPlugin(
 
 	// check if the plugin is already installed
 	if _, exists := c.localPluginRuntimes.Load(pluginUniqueIdentifier); exists {
+		c.localPluginInstallationLock.Unlock(pluginUniqueIdentifier.String())
 		return nil, nil, ErrorPluginAlreadyLaunched
 	}
 
+	// quarantined plugins must be released before launching
+	if quarantine, ok := c.LocalPluginQuarantine(pluginUniqueIdentifier); ok {
+		c.localPluginInstallationLock.Unlock(pluginUniqueIdentifier.String())
+		return nil, nil, errors.Join(ErrLocalPluginQuarantined, fmt.Errorf("reason: %s", quarantine.Reason))
+	}
+
 	// acquire semaphore, this semaphore will be released
 	c.localPluginLaunchingSemaphore <- true
 
@@ -58,6 +66,12 @@ func (c *ControlPanel) LaunchLocalPlugin(
 		return nil, nil, err
 	}
 
+	// apply sandbox profile according to the install source and verification level
+	runtime.SetSandboxProfile(c.resolveSandboxProfile(pluginUniqueIdentifier, runtime))
+
+	// apply environment variables defined by operators
+	c.applyLocalPluginEnvironment(pluginUniqueIdentifier, runtime)
+
 	// init environment
 	// whatever it's a user request to launch a plugin or a new plugin was found
 	// by watch dog, initialize environment is a must
@@ -102,6 +116,11 @@ func (c *ControlPanel) LaunchLocalPlugin(
 				notifier.OnLocalRuntimeScaleDown(runtime, i)
 			})
 		},
+		OnInstanceCrashedImpl: func(pi *local_runtime.PluginInstance, err error) {
+			c.WalkNotifiers(func(notifier ControlPanelNotifier) {
+				notifier.OnLocalRuntimeInstanceCrashed(runtime, pi, err)
+			})
+		},
 		// only first instance failed will trigger this
 		OnInstanceLaunchFailedImpl: func(pi *local_runtime.PluginInstance, err error) {
 			once.Do(func() {
@@ -125,7 +144,10 @@ func (c *ControlPanel) LaunchLocalPlugin(
 			// Even if the runtime is not ready, deleting it still makes sense
 			// once a plugin is stopping schedule, all new requests to it need to be rejected
 			// so just remove it from map
-			c.localPluginRuntimes.Delete(pluginUniqueIdentifier)
+			// a restarted runtime may have been stored already, keep it
+			c.localPluginRuntimes.CompareAndDelete(pluginUniqueIdentifier, runtime)
+			// dispatches waiting for it to be stopped for being idle can launch a new one
+			c.releaseLocalPluginStop(pluginUniqueIdentifier, runtime)
 			// notify the plugin is stopping
 			c.WalkNotifiers(func(notifier ControlPanelNotifier) {
 				notifier.OnLocalRuntimeStop(runtime)
@@ -135,7 +157,13 @@ func (c *ControlPanel) LaunchLocalPlugin(
 	runtime.AddNotifier(lifetime)
 
 	// scale up, ensure at least one instance is running
+	// replicas set by operators are kept across restarts of the runtime
 	runtime.ScaleUp()
+	if replicas, ok := c.localPluginReplicas.Load(pluginUniqueIdentifier); ok {
+		if err := runtime.SetReplicas(replicas); err != nil {
+			log.Warn("failed to set replicas of plugin %s: %s", pluginUniqueIdentifier, err.Error())
+		}
+	}
 
 	// start schedule
 	// NOTE: it's a async method, releasing semaphore here is not a good idea
@@ -51,3 +51,12 @@ func (l *StandardLogger) OnLocalRuntimeScaleDown(runtime *local_runtime.LocalPlu
 	identity, _ := runtime.Identity()
 	log.Info("local runtime scale down: %s, instance nums: %d", identity, instanceNums)
 }
+
+func (l *StandardLogger) OnLocalRuntimeInstanceCrashed(
+	runtime *local_runtime.LocalPluginRuntime,
+	instance *local_runtime.PluginInstance,
+	err error,
+) {
+	identity, _ := runtime.Identity()
+	log.Error("local runtime instance crashed: %s, instance: %s, error: %s", identity, instance.ID(), err)
+}
@@ -0,0 +1,194 @@
+package controlpanel
+
+import (
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	routinepkg "github.com/langgenius/dify-plugin-daemon/pkg/routine"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/routine"
+)
+
+// SetLocalPluginPinResolver sets the resolver used to find plugins pinned by operators
+// it returns plugin ids like `author/name`, all versions of a pinned plugin are pinned
+func (c *ControlPanel) SetLocalPluginPinResolver(resolver func() ([]string, error)) {
+	c.localPluginPinResolver = resolver
+}
+
+// LocalPluginOnDemand returns true if local plugins are launched by their first dispatch
+// instead of being launched by `WatchDog` once they are installed
+func (c *ControlPanel) LocalPluginOnDemand() bool {
+	return c.config.PluginLocalLaunchMode == app.LOCAL_LAUNCH_MODE_ON_DEMAND
+}
+
+// pinnedLocalPlugins returns ids of plugins which are always running in on demand mode
+// both pinned by `PLUGIN_LOCAL_PINNED_PLUGINS` and by operators
+func (c *ControlPanel) pinnedLocalPlugins() map[string]bool {
+	pinned := map[string]bool{}
+	for _, pluginID := range c.config.PluginLocalPinnedPlugins {
+		pinned[pluginID] = true
+	}
+
+	if c.localPluginPinResolver != nil {
+		pluginIDs, err := c.localPluginPinResolver()
+		if err != nil {
+			log.Warn("failed to resolve pinned plugins: %s", err.Error())
+		}
+		for _, pluginID := range pluginIDs {
+			pinned[pluginID] = true
+		}
+	}
+
+	return pinned
+}
+
+// localPluginOnDemandLaunch is a launch shared by concurrent dispatches to the same plugin
+type localPluginOnDemandLaunch struct {
+	// closed once the launch finished, `err` is set before
+	done chan struct{}
+	err  error
+}
+
+// localPluginStop is an idle local plugin runtime being stopped
+type localPluginStop struct {
+	runtime *local_runtime.LocalPluginRuntime
+	// closed once the runtime is removed or the stop is aborted
+	stopped chan struct{}
+}
+
+// IsLocalPluginStopping returns true if the plugin is stopped for being idle and it's not removed yet
+func (c *ControlPanel) IsLocalPluginStopping(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) bool {
+	return c.localPluginStopping.Exists(pluginUniqueIdentifier)
+}
+
+// releaseLocalPluginStop wakes up launches waiting for the stop of `runtime`
+func (c *ControlPanel) releaseLocalPluginStop(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	runtime *local_runtime.LocalPluginRuntime,
+) {
+	stop, ok := c.localPluginStopping.Load(pluginUniqueIdentifier)
+	if ok && stop.runtime == runtime && c.localPluginStopping.CompareAndDelete(pluginUniqueIdentifier, stop) {
+		close(stop.stopped)
+	}
+}
+
+// LaunchLocalPluginOnDemand launches an installed local plugin which is not running on this node
+// and waits until it's ready, concurrent calls for the same plugin wait for the same launch
+// a plugin which failed to launch is not launched again until its retry wait time is passed
+func (c *ControlPanel) LaunchLocalPluginOnDemand(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) error {
+	if c.localPluginRuntimes.Exists(pluginUniqueIdentifier) && !c.IsLocalPluginStopping(pluginUniqueIdentifier) {
+		return nil
+	}
+
+	// stopped by operators or being removed
+	if c.localPluginWatchIgnoreList.Exists(pluginUniqueIdentifier) {
+		return ErrLocalPluginAutoLaunchDisabled
+	}
+
+	if exists, err := c.installedBucket.Exists(pluginUniqueIdentifier); err != nil {
+		return err
+	} else if !exists {
+		return ErrLocalPluginNotInstalled
+	}
+
+	retry, failed := c.localPluginFailsRecord.Load(pluginUniqueIdentifier)
+	if failed && time.Since(retry.LastTriedAt) < c.calculateWaitTime(retry.RetryCount) {
+		return ErrLocalPluginLaunchBackoff
+	}
+
+	launch, launching := c.localPluginOnDemandLaunches.LoadOrStore(
+		pluginUniqueIdentifier,
+		&localPluginOnDemandLaunch{done: make(chan struct{})},
+	)
+	if !launching {
+		routine.Submit(routinepkg.Labels{
+			routinepkg.RoutineLabelKeyModule: "controlpanel",
+			routinepkg.RoutineLabelKeyMethod: "LaunchLocalPluginOnDemand",
+		}, func() {
+			launch.err = c.launchLocalPluginOnDemand(pluginUniqueIdentifier)
+			// failures are recorded before the launch is released, later dispatches back off
+			c.localPluginOnDemandLaunches.Delete(pluginUniqueIdentifier)
+			close(launch.done)
+		})
+	}
+
+	// the launch keeps going after the timeout, later dispatches use it once it's ready
+	select {
+	case <-launch.done:
+		return launch.err
+	case <-time.After(time.Duration(c.config.PluginLocalOnDemandLaunchTimeout) * time.Second):
+		return ErrLocalPluginOnDemandLaunchTimeout
+	}
+}
+
+// launchLocalPluginOnDemand launches the plugin and records the failure, only one runs for a plugin at a time
+func (c *ControlPanel) launchLocalPluginOnDemand(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) error {
+	// the previous launch may have failed after the caller checked the record
+	retry, failed := c.localPluginFailsRecord.Load(pluginUniqueIdentifier)
+	if failed && time.Since(retry.LastTriedAt) < c.calculateWaitTime(retry.RetryCount) {
+		return ErrLocalPluginLaunchBackoff
+	}
+
+	// an idle runtime being stopped is still stored, wait until it's removed
+	if stop, ok := c.localPluginStopping.Load(pluginUniqueIdentifier); ok {
+		<-stop.stopped
+	}
+
+	err := c.launchLocalPluginAndWait(pluginUniqueIdentifier)
+	if err != nil {
+		// read it again, `WatchDog` or operators may have changed it during the launch
+		retry, _ := c.localPluginFailsRecord.Load(pluginUniqueIdentifier)
+		c.localPluginFailsRecord.Store(pluginUniqueIdentifier, LocalPluginFailsRecord{
+			RetryCount:  retry.RetryCount + 1,
+			LastTriedAt: time.Now(),
+		})
+	}
+
+	return err
+}
+
+// stopIdleLocalPlugins gracefully shuts down local plugins which had no sessions for the idle timeout
+// it only works in on demand mode, pinned plugins are never stopped
+func (c *ControlPanel) stopIdleLocalPlugins() {
+	if !c.LocalPluginOnDemand() {
+		return
+	}
+
+	idleTimeout := time.Duration(c.config.PluginLocalIdleTimeout) * time.Second
+	pinned := c.pinnedLocalPlugins()
+
+	c.localPluginRuntimes.Range(func(
+		key plugin_entities.PluginUniqueIdentifier,
+		value *local_runtime.LocalPluginRuntime,
+	) bool {
+		if pinned[key.PluginID()] || c.IsLocalPluginDraining(key) || c.IsLocalPluginStopping(key) {
+			return true
+		}
+
+		if idle := value.IdleDuration(); idle >= idleTimeout {
+			// from now on, dispatches launch a new runtime instead of using this one
+			c.localPluginStopping.Store(key, &localPluginStop{runtime: value, stopped: make(chan struct{})})
+			// a session may have started before it's marked
+			if value.IdleDuration() < idleTimeout {
+				c.releaseLocalPluginStop(key, value)
+				return true
+			}
+
+			log.Info("stop idle local plugin %s, no sessions for %s", key, idle.Truncate(time.Second))
+			if _, err := c.ShutdownLocalPluginGracefully(key); err != nil {
+				log.Error("shutdown idle local plugin %s failed: %s", key, err.Error())
+				c.releaseLocalPluginStop(key, value)
+			}
+		}
+
+		return true
+	})
+}
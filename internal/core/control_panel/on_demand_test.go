@@ -0,0 +1,194 @@
+package controlpanel
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/langgenius/dify-cloud-kit/oss"
+	"github.com/langgenius/dify-cloud-kit/oss/local"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager/media_transport"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/lock"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/routine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinnedLocalPlugins(t *testing.T) {
+	controlPanel := &ControlPanel{config: &app.Config{
+		PluginLocalLaunchMode:    app.LOCAL_LAUNCH_MODE_ON_DEMAND,
+		PluginLocalPinnedPlugins: []string{"langgenius/openai"},
+	}}
+	assert.True(t, controlPanel.LocalPluginOnDemand())
+	assert.Equal(t, map[string]bool{"langgenius/openai": true}, controlPanel.pinnedLocalPlugins())
+
+	controlPanel.SetLocalPluginPinResolver(func() ([]string, error) {
+		return []string{"langgenius/anthropic"}, nil
+	})
+	assert.Equal(t, map[string]bool{
+		"langgenius/openai":    true,
+		"langgenius/anthropic": true,
+	}, controlPanel.pinnedLocalPlugins())
+
+	// a broken storage keeps plugins pinned by config
+	controlPanel.SetLocalPluginPinResolver(func() ([]string, error) {
+		return nil, errors.New("database is down")
+	})
+	assert.Equal(t, map[string]bool{"langgenius/openai": true}, controlPanel.pinnedLocalPlugins())
+}
+
+func TestLaunchLocalPluginOnDemand(t *testing.T) {
+	running, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/a:1.0.0@1234567890abcdef1234567890abcdef1234567890abcdef")
+	if !assert.NoError(t, err) {
+		return
+	}
+	stopped, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/b:1.0.0@1234567890abcdef1234567890abcdef1234567890abcdef")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	controlPanel := &ControlPanel{config: &app.Config{
+		PluginLocalLaunchMode: app.LOCAL_LAUNCH_MODE_ON_DEMAND,
+	}}
+	controlPanel.localPluginRuntimes.Store(running, &local_runtime.LocalPluginRuntime{})
+	controlPanel.DisableLocalPluginAutoLaunch(stopped)
+
+	// dispatched to the running one directly
+	assert.NoError(t, controlPanel.LaunchLocalPluginOnDemand(running))
+	// plugins stopped by operators are not launched by dispatches
+	assert.ErrorIs(t, controlPanel.LaunchLocalPluginOnDemand(stopped), ErrLocalPluginAutoLaunchDisabled)
+}
+
+// launchCounter counts launches of local runtimes
+type launchCounter struct {
+	StandardLogger
+	starting atomic.Int32
+}
+
+func (l *launchCounter) OnLocalRuntimeStarting(plugin_entities.PluginUniqueIdentifier) {
+	l.starting.Add(1)
+}
+
+func TestLaunchFailingLocalPluginOnDemandConcurrently(t *testing.T) {
+	routine.InitPool(1024)
+
+	identifier, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/a:1.0.0@1234567890abcdef1234567890abcdef1234567890abcdef")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	storage, err := local.NewLocalStorage(oss.OSSArgs{Local: &oss.Local{Path: filepath.Join(t.TempDir(), "storage")}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// installed, but its package is missing, every launch fails
+	controlPanel := &ControlPanel{
+		config: &app.Config{
+			PluginLocalLaunchMode:            app.LOCAL_LAUNCH_MODE_ON_DEMAND,
+			PluginLocalOnDemandLaunchTimeout: 10,
+		},
+		installedBucket:               media_transport.NewInstalledBucket(storage, "plugin"),
+		packageBucket:                 media_transport.NewPackageBucket(storage, "plugin_packages"),
+		localPluginInstallationLock:   lock.NewGranularityLock(),
+		localPluginLaunchingSemaphore: make(chan bool, 1),
+		controlPanelNotifierLock:      &sync.RWMutex{},
+	}
+	assert.NoError(t, controlPanel.installedBucket.Save(identifier, []byte("package")))
+	counter := &launchCounter{}
+	controlPanel.AddNotifier(counter)
+	// the next failure makes it back off
+	controlPanel.localPluginFailsRecord.Store(identifier, LocalPluginFailsRecord{
+		RetryCount:  2,
+		LastTriedAt: time.Now().Add(-time.Hour),
+	})
+
+	// hold the semaphore, so that all dispatches arrive while the launch is in progress
+	controlPanel.localPluginLaunchingSemaphore <- true
+
+	errs := make(chan error, 8)
+	for range 8 {
+		go func() {
+			errs <- controlPanel.LaunchLocalPluginOnDemand(identifier)
+		}()
+	}
+	time.Sleep(200 * time.Millisecond)
+	<-controlPanel.localPluginLaunchingSemaphore
+
+	for range 8 {
+		assert.Error(t, <-errs)
+	}
+
+	// dispatches share one launch, the failure is counted once
+	assert.Equal(t, int32(1), counter.starting.Load())
+	record, ok := controlPanel.localPluginFailsRecord.Load(identifier)
+	assert.True(t, ok)
+	assert.Equal(t, int32(3), record.RetryCount)
+
+	// later dispatches back off
+	assert.ErrorIs(t, controlPanel.LaunchLocalPluginOnDemand(identifier), ErrLocalPluginLaunchBackoff)
+	assert.Equal(t, int32(1), counter.starting.Load())
+}
+
+func TestLaunchStoppingLocalPluginOnDemand(t *testing.T) {
+	routine.InitPool(1024)
+
+	identifier, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/a:1.0.0@1234567890abcdef1234567890abcdef1234567890abcdef")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	storage, err := local.NewLocalStorage(oss.OSSArgs{Local: &oss.Local{Path: filepath.Join(t.TempDir(), "storage")}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// installed, but its package is missing, the new launch fails after the old runtime is removed
+	controlPanel := &ControlPanel{
+		config: &app.Config{
+			PluginLocalLaunchMode:            app.LOCAL_LAUNCH_MODE_ON_DEMAND,
+			PluginLocalOnDemandLaunchTimeout: 10,
+		},
+		installedBucket:               media_transport.NewInstalledBucket(storage, "plugin"),
+		packageBucket:                 media_transport.NewPackageBucket(storage, "plugin_packages"),
+		localPluginInstallationLock:   lock.NewGranularityLock(),
+		localPluginLaunchingSemaphore: make(chan bool, 1),
+		controlPanelNotifierLock:      &sync.RWMutex{},
+	}
+	assert.NoError(t, controlPanel.installedBucket.Save(identifier, []byte("package")))
+	counter := &launchCounter{}
+	controlPanel.AddNotifier(counter)
+
+	// the runtime is stopped for being idle
+	runtime := &local_runtime.LocalPluginRuntime{}
+	controlPanel.localPluginRuntimes.Store(identifier, runtime)
+	controlPanel.localPluginStopping.Store(identifier, &localPluginStop{runtime: runtime, stopped: make(chan struct{})})
+
+	_, err = controlPanel.GetPluginRuntime(identifier)
+	assert.ErrorIs(t, err, ErrPluginRuntimeNotFound)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- controlPanel.LaunchLocalPluginOnDemand(identifier)
+	}()
+
+	// the launch waits until the stopping runtime is removed
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(0), counter.starting.Load())
+
+	// a stop of another runtime does not release it
+	controlPanel.releaseLocalPluginStop(identifier, &local_runtime.LocalPluginRuntime{})
+	assert.True(t, controlPanel.IsLocalPluginStopping(identifier))
+
+	controlPanel.localPluginRuntimes.CompareAndDelete(identifier, runtime)
+	controlPanel.releaseLocalPluginStop(identifier, runtime)
+	assert.False(t, controlPanel.IsLocalPluginStopping(identifier))
+
+	assert.Error(t, <-errs)
+	assert.Equal(t, int32(1), counter.starting.Load())
+}
@@ -0,0 +1,242 @@
+package controlpanel
+
+import (
+	"errors"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	routinepkg "github.com/langgenius/dify-plugin-daemon/pkg/routine"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/routine"
+)
+
+// LocalPluginQuarantine prevents a local plugin from being launched until it's released
+type LocalPluginQuarantine struct {
+	Reason        string    `json:"reason"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+// SetLocalPluginQuarantineResolver sets the resolver used to find quarantines applied to the whole cluster
+// it's called before a local plugin launches, returns nil if the plugin is not quarantined
+func (c *ControlPanel) SetLocalPluginQuarantineResolver(
+	resolver func(plugin_entities.PluginUniqueIdentifier) (*LocalPluginQuarantine, error),
+) {
+	c.localPluginQuarantineResolver = resolver
+}
+
+// LocalPluginQuarantine returns the quarantine of a local plugin, false if it's not quarantined
+func (c *ControlPanel) LocalPluginQuarantine(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) (LocalPluginQuarantine, bool) {
+	if quarantine, ok := c.localPluginQuarantines.Load(pluginUniqueIdentifier); ok {
+		return quarantine, true
+	}
+
+	if c.localPluginQuarantineResolver == nil {
+		return LocalPluginQuarantine{}, false
+	}
+
+	quarantine, err := c.localPluginQuarantineResolver(pluginUniqueIdentifier)
+	if err != nil {
+		// a broken storage should not stop all plugins from launching
+		log.Warn("failed to resolve quarantine of plugin %s: %s", pluginUniqueIdentifier, err.Error())
+		return LocalPluginQuarantine{}, false
+	}
+	if quarantine == nil {
+		return LocalPluginQuarantine{}, false
+	}
+
+	// keep it in memory, it's listed in the inventory and not resolved again
+	c.localPluginQuarantines.Store(pluginUniqueIdentifier, *quarantine)
+	return *quarantine, true
+}
+
+// RestartLocalPlugin gracefully shuts down a local plugin and launches it again
+// `WatchDog` is not allowed to launch it in the meantime
+//
+// Returns a channel that notifies if the plugin launched again (both success and failed)
+func (c *ControlPanel) RestartLocalPlugin(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) (<-chan error, error) {
+	if !c.localPluginRuntimes.Exists(pluginUniqueIdentifier) {
+		return nil, ErrLocalPluginRuntimeNotFound
+	}
+
+	if _, ok := c.LocalPluginQuarantine(pluginUniqueIdentifier); ok {
+		return nil, ErrLocalPluginQuarantined
+	}
+
+	c.DisableLocalPluginAutoLaunch(pluginUniqueIdentifier)
+
+	ch := make(chan error, 1)
+
+	routine.Submit(routinepkg.Labels{
+		routinepkg.RoutineLabelKeyModule: "controlpanel",
+		routinepkg.RoutineLabelKeyMethod: "RestartLocalPlugin",
+	}, func() {
+		c.shutdownLocalPlugin(pluginUniqueIdentifier)
+		c.EnableLocalPluginAutoLaunch(pluginUniqueIdentifier)
+		ch <- c.launchLocalPluginAndWait(pluginUniqueIdentifier)
+		close(ch)
+	})
+
+	return ch, nil
+}
+
+// StopLocalPlugin gracefully shuts down a local plugin, it's not launched by `WatchDog` again
+// until `StartLocalPlugin` is called, a plugin which is not running is only prevented from launching
+//
+// Returns a channel that will be closed once the plugin is shutdown
+func (c *ControlPanel) StopLocalPlugin(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) (<-chan error, error) {
+	c.DisableLocalPluginAutoLaunch(pluginUniqueIdentifier)
+
+	ch := make(chan error, 1)
+
+	routine.Submit(routinepkg.Labels{
+		routinepkg.RoutineLabelKeyModule: "controlpanel",
+		routinepkg.RoutineLabelKeyMethod: "StopLocalPlugin",
+	}, func() {
+		c.shutdownLocalPlugin(pluginUniqueIdentifier)
+		close(ch)
+	})
+
+	return ch, nil
+}
+
+// StartLocalPlugin launches a local plugin stopped by `StopLocalPlugin` and enables its auto launch
+// previous launch failures are forgotten, `WatchDog` retries it from scratch
+//
+// Returns a channel that notifies if the plugin launched (both success and failed)
+func (c *ControlPanel) StartLocalPlugin(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) (<-chan error, error) {
+	if _, ok := c.LocalPluginQuarantine(pluginUniqueIdentifier); ok {
+		return nil, ErrLocalPluginQuarantined
+	}
+
+	c.EnableLocalPluginAutoLaunch(pluginUniqueIdentifier)
+	c.localPluginFailsRecord.Delete(pluginUniqueIdentifier)
+
+	ch := make(chan error, 1)
+
+	routine.Submit(routinepkg.Labels{
+		routinepkg.RoutineLabelKeyModule: "controlpanel",
+		routinepkg.RoutineLabelKeyMethod: "StartLocalPlugin",
+	}, func() {
+		ch <- c.launchLocalPluginAndWait(pluginUniqueIdentifier)
+		close(ch)
+	})
+
+	return ch, nil
+}
+
+// ScaleLocalPlugin sets replicas of a running local plugin, they are kept once the plugin restarts
+// it's not allowed if replicas are managed by the autoscaler
+func (c *ControlPanel) ScaleLocalPlugin(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	replicas int32,
+) error {
+	runtime, ok := c.localPluginRuntimes.Load(pluginUniqueIdentifier)
+	if !ok {
+		return ErrLocalPluginRuntimeNotFound
+	}
+
+	if err := runtime.SetReplicas(replicas); err != nil {
+		return err
+	}
+
+	c.localPluginReplicas.Store(pluginUniqueIdentifier, replicas)
+	return nil
+}
+
+// QuarantineLocalPlugin stops a local plugin and prevents it from being launched
+// whatever by `WatchDog`, `StartLocalPlugin` or an installation, until `ReleaseLocalPlugin` is called
+//
+// Returns a channel that will be closed once the plugin is shutdown
+func (c *ControlPanel) QuarantineLocalPlugin(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	reason string,
+) (<-chan error, error) {
+	c.localPluginQuarantines.Store(pluginUniqueIdentifier, LocalPluginQuarantine{
+		Reason:        reason,
+		QuarantinedAt: time.Now(),
+	})
+
+	return c.StopLocalPlugin(pluginUniqueIdentifier)
+}
+
+// ReleaseLocalPlugin lifts the quarantine of a local plugin and launches it
+// NOTE: quarantines applied to the whole cluster must be removed from the persistent storage first,
+// otherwise the plugin is quarantined again once it's resolved
+//
+// Returns a channel that notifies if the plugin launched (both success and failed)
+func (c *ControlPanel) ReleaseLocalPlugin(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) (<-chan error, error) {
+	if !c.localPluginQuarantines.Exists(pluginUniqueIdentifier) {
+		return nil, ErrLocalPluginNotQuarantined
+	}
+	c.localPluginQuarantines.Delete(pluginUniqueIdentifier)
+
+	return c.StartLocalPlugin(pluginUniqueIdentifier)
+}
+
+// shutdownLocalPlugin gracefully shuts down a local plugin and waits until it's removed
+// a plugin being launched is shutdown once it's ready
+func (c *ControlPanel) shutdownLocalPlugin(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) {
+	// the installation lock is held until the launch finished
+	c.localPluginInstallationLock.Lock(pluginUniqueIdentifier.String())
+	c.localPluginInstallationLock.Unlock(pluginUniqueIdentifier.String())
+
+	runtime, ok := c.localPluginRuntimes.Load(pluginUniqueIdentifier)
+	if !ok {
+		return
+	}
+
+	runtime.GracefulStop(false)
+	c.waitForLocalPluginRuntimeRemoved(pluginUniqueIdentifier, runtime)
+}
+
+// the runtime is removed once its schedule loop exits, which is a while after the instances stopped
+// launching the plugin again before that is rejected as it's considered running
+func (c *ControlPanel) waitForLocalPluginRuntimeRemoved(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	runtime *local_runtime.LocalPluginRuntime,
+) {
+	ticker := time.NewTicker(time.Second * 1)
+	defer ticker.Stop()
+
+	for {
+		current, ok := c.localPluginRuntimes.Load(pluginUniqueIdentifier)
+		if !ok || current != runtime {
+			return
+		}
+		<-ticker.C
+	}
+}
+
+// launchLocalPluginAndWait launches a local plugin and waits until it's ready or failed
+// a plugin launched by others in the meantime is considered a success
+func (c *ControlPanel) launchLocalPluginAndWait(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) error {
+	_, ch, err := c.LaunchLocalPlugin(pluginUniqueIdentifier)
+	if errors.Is(err, ErrorPluginAlreadyLaunched) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := <-ch; err != nil {
+		return err
+	}
+
+	c.localPluginFailsRecord.Delete(pluginUniqueIdentifier)
+	return nil
+}
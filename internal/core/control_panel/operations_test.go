@@ -0,0 +1,92 @@
+package controlpanel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/lock"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/routine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantineLocalPlugin(t *testing.T) {
+	routine.InitPool(1024)
+
+	identifier, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/a:1.0.0@1234567890abcdef1234567890abcdef1234567890abcdef")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	controlPanel := &ControlPanel{localPluginInstallationLock: lock.NewGranularityLock()}
+
+	ch, err := controlPanel.QuarantineLocalPlugin(identifier, "leaking credentials")
+	if !assert.NoError(t, err) {
+		return
+	}
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "plugin was not stopped before the deadline")
+		return
+	}
+
+	// neither an operator nor `WatchDog` is able to launch it
+	_, _, err = controlPanel.LaunchLocalPlugin(identifier)
+	assert.ErrorIs(t, err, ErrLocalPluginQuarantined)
+	_, err = controlPanel.StartLocalPlugin(identifier)
+	assert.ErrorIs(t, err, ErrLocalPluginQuarantined)
+	_, ok := controlPanel.localPluginWatchIgnoreList.Load(identifier)
+	assert.True(t, ok)
+
+	inventories := controlPanel.RuntimeInventory()
+	if !assert.Len(t, inventories, 1) {
+		return
+	}
+	assert.Equal(t, RUNTIME_INVENTORY_STATUS_QUARANTINED, inventories[0].Status)
+	assert.Equal(t, "leaking credentials", inventories[0].Quarantine.Reason)
+}
+
+func TestLocalPluginQuarantineResolver(t *testing.T) {
+	quarantined, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/a:1.0.0@1234567890abcdef1234567890abcdef1234567890abcdef")
+	if !assert.NoError(t, err) {
+		return
+	}
+	released, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/b:1.0.0@1234567890abcdef1234567890abcdef1234567890abcdef")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resolved := 0
+	controlPanel := &ControlPanel{}
+	controlPanel.SetLocalPluginQuarantineResolver(func(
+		pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	) (*LocalPluginQuarantine, error) {
+		resolved++
+		if pluginUniqueIdentifier == quarantined {
+			return &LocalPluginQuarantine{Reason: "cluster"}, nil
+		}
+		return nil, nil
+	})
+
+	// quarantines resolved from the storage are kept in memory
+	for range 2 {
+		quarantine, ok := controlPanel.LocalPluginQuarantine(quarantined)
+		assert.True(t, ok)
+		assert.Equal(t, "cluster", quarantine.Reason)
+	}
+	_, ok := controlPanel.LocalPluginQuarantine(released)
+	assert.False(t, ok)
+	assert.Equal(t, 2, resolved)
+
+	_, err = controlPanel.ReleaseLocalPlugin(released)
+	assert.ErrorIs(t, err, ErrLocalPluginNotQuarantined)
+
+	// it's quarantined again as long as the storage keeps it
+	_, err = controlPanel.ReleaseLocalPlugin(quarantined)
+	assert.ErrorIs(t, err, ErrLocalPluginQuarantined)
+	assert.Equal(t, 3, resolved)
+
+	err = controlPanel.ScaleLocalPlugin(released, 2)
+	assert.ErrorIs(t, err, ErrLocalPluginRuntimeNotFound)
+}
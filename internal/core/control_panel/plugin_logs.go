@@ -0,0 +1,73 @@
+package controlpanel
+
+import (
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/stream"
+)
+
+// localPluginLogBuffer returns the log buffer of a local plugin, creates it if not exists
+func (c *ControlPanel) localPluginLogBuffer(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) *local_runtime.PluginLogBuffer {
+	if buffer, ok := c.localPluginLogBuffers.Load(pluginUniqueIdentifier); ok {
+		return buffer
+	}
+
+	buffer, _ := c.localPluginLogBuffers.LoadOrStore(
+		pluginUniqueIdentifier,
+		local_runtime.NewPluginLogBufferFromConfig(c.config, pluginUniqueIdentifier),
+	)
+	return buffer
+}
+
+// QueryLocalPluginLogs returns buffered logs of a local plugin on this node
+func (c *ControlPanel) QueryLocalPluginLogs(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	query local_runtime.PluginLogQuery,
+) ([]local_runtime.PluginLogEntry, error) {
+	buffer, ok := c.localPluginLogBuffers.Load(pluginUniqueIdentifier)
+	if !ok {
+		return nil, ErrPluginLogsNotFound
+	}
+
+	return buffer.Query(query), nil
+}
+
+// TailLocalPluginLogs streams buffered and new logs of a local plugin on this node
+// close the stream to stop tailing
+func (c *ControlPanel) TailLocalPluginLogs(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	query local_runtime.PluginLogQuery,
+) (*stream.Stream[local_runtime.PluginLogEntry], error) {
+	buffer, ok := c.localPluginLogBuffers.Load(pluginUniqueIdentifier)
+	if !ok {
+		return nil, ErrPluginLogsNotFound
+	}
+
+	return buffer.Tail(query), nil
+}
+
+// collectLocalPluginLogBuffers closes and drops log buffers of uninstalled plugins
+func (c *ControlPanel) collectLocalPluginLogBuffers() {
+	c.localPluginLogBuffers.Range(func(
+		key plugin_entities.PluginUniqueIdentifier,
+		value *local_runtime.PluginLogBuffer,
+	) bool {
+		// logs of a running plugin are still being written
+		if c.localPluginRuntimes.Exists(key) {
+			return true
+		}
+
+		if exists, err := c.installedBucket.Exists(key); err != nil {
+			log.Error("check if plugin %s is installed failed: %s", key.String(), err.Error())
+		} else if !exists {
+			if buffer, ok := c.localPluginLogBuffers.LoadAndDelete(key); ok {
+				buffer.Close()
+			}
+		}
+
+		return true
+	})
+}
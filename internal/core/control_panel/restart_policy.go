@@ -0,0 +1,31 @@
+package controlpanel
+
+import (
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+)
+
+// LocalPluginRestartPolicyStatus returns the restart policy state of a local plugin
+func (c *ControlPanel) LocalPluginRestartPolicyStatus(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) (local_runtime.RestartPolicyStatus, error) {
+	runtime, ok := c.localPluginRuntimes.Load(pluginUniqueIdentifier)
+	if !ok {
+		return local_runtime.RestartPolicyStatus{}, ErrPluginRuntimeNotFound
+	}
+
+	return runtime.RestartPolicyStatus(), nil
+}
+
+// ResetLocalPluginRestartPolicy clears failures of a local plugin, a crash-looping plugin starts respawning again
+func (c *ControlPanel) ResetLocalPluginRestartPolicy(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) error {
+	runtime, ok := c.localPluginRuntimes.Load(pluginUniqueIdentifier)
+	if !ok {
+		return ErrPluginRuntimeNotFound
+	}
+
+	runtime.ResetRestartPolicy()
+	return nil
+}
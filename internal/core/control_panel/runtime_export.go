@@ -19,10 +19,31 @@ func (c *ControlPanel) GetPluginRuntime(
 		}
 		return runtime, nil
 	} else {
+		// the plugin was upgraded and it's draining, dispatch to the new one
+		if successor, ok := c.localPluginSuccessors.Load(pluginUniqueIdentifier); ok {
+			if runtime, ok := c.localPluginRuntimes.Load(successor); ok {
+				return runtime, nil
+			}
+		}
+
+		// idle runtimes being stopped are treated as not running, dispatches launch a new one
 		runtime, ok := c.localPluginRuntimes.Load(pluginUniqueIdentifier)
-		if !ok {
+		if !ok || c.IsLocalPluginStopping(pluginUniqueIdentifier) {
 			return nil, ErrPluginRuntimeNotFound
 		}
 		return runtime, nil
 	}
 }
+
+// LocalPluginRuntimeState returns the runtime state of a local plugin running on this machine
+// including status, working path and patches applied to its sdk
+func (c *ControlPanel) LocalPluginRuntimeState(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) (plugin_entities.PluginRuntimeState, error) {
+	runtime, ok := c.localPluginRuntimes.Load(pluginUniqueIdentifier)
+	if !ok {
+		return plugin_entities.PluginRuntimeState{}, ErrPluginRuntimeNotFound
+	}
+
+	return runtime.RuntimeState(), nil
+}
@@ -0,0 +1,60 @@
+package controlpanel
+
+import (
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+)
+
+// SetLocalPluginSource records where a plugin is installed from
+// call it before `LaunchLocalPlugin` to make sure the right sandbox profile is applied
+func (c *ControlPanel) SetLocalPluginSource(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	source string,
+) {
+	c.localPluginSources.Store(pluginUniqueIdentifier, source)
+}
+
+// SetLocalPluginSourceResolver sets the resolver used to find install source of
+// plugins which were not recorded by `SetLocalPluginSource`, like plugins launched by `WatchDog`
+func (c *ControlPanel) SetLocalPluginSourceResolver(
+	resolver func(plugin_entities.PluginUniqueIdentifier) (string, error),
+) {
+	c.localPluginSourceResolver = resolver
+}
+
+func (c *ControlPanel) getLocalPluginSource(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) string {
+	if source, ok := c.localPluginSources.Load(pluginUniqueIdentifier); ok {
+		return source
+	}
+
+	if c.localPluginSourceResolver == nil {
+		return ""
+	}
+
+	source, err := c.localPluginSourceResolver(pluginUniqueIdentifier)
+	if err != nil {
+		log.Warn("failed to resolve install source of plugin %s: %s", pluginUniqueIdentifier, err.Error())
+		return ""
+	}
+
+	return source
+}
+
+// resolveSandboxProfile decides the sandbox profile of a local plugin runtime
+func (c *ControlPanel) resolveSandboxProfile(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	runtime *local_runtime.LocalPluginRuntime,
+) local_runtime.SandboxProfile {
+	if !c.config.PluginSandboxEnabled {
+		return local_runtime.SandboxProfileNone
+	}
+
+	return local_runtime.ResolveSandboxProfile(
+		c.config,
+		c.getLocalPluginSource(pluginUniqueIdentifier),
+		runtime.State.Verified,
+	)
+}
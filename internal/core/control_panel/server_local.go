@@ -1,6 +1,7 @@
 package controlpanel
 
 import (
+	"errors"
 	"sync"
 	"time"
 
@@ -35,6 +36,11 @@ func (c *ControlPanel) removeUnusedLocalPlugins() {
 			key plugin_entities.PluginUniqueIdentifier,
 			value *local_runtime.LocalPluginRuntime,
 		) bool {
+			// draining plugins are shutdown once their sessions are finished
+			if c.IsLocalPluginDraining(key) {
+				return true
+			}
+
 			// remove plugin runtime
 			if exists, err := c.installedBucket.Exists(key); err != nil {
 				log.Error("check if plugin %s is installed failed: %s", key.String(), err.Error())
@@ -47,6 +53,33 @@ func (c *ControlPanel) removeUnusedLocalPlugins() {
 
 			return true
 		})
+
+		// stop plugins without sessions for a while, they are launched again by the next dispatch
+		c.stopIdleLocalPlugins()
+
+		// remove python environments no longer used by installed plugins
+		c.collectPythonEnvironments()
+
+		// drop logs of uninstalled plugins
+		c.collectLocalPluginLogBuffers()
+	}
+}
+
+// collectPythonEnvironments drops references of uninstalled plugins from the shared
+// python environments and removes environments without references
+func (c *ControlPanel) collectPythonEnvironments() {
+	if c.pythonEnvironmentCache == nil {
+		return
+	}
+
+	removed, err := c.pythonEnvironmentCache.GC(c.installedBucket.Exists)
+	if err != nil {
+		log.Error("collect python environments failed: %s", err.Error())
+		return
+	}
+
+	for _, key := range removed {
+		log.Info("removed unused python environment %s", key)
 	}
 }
 
@@ -62,9 +95,19 @@ func (c *ControlPanel) handleNewLocalPlugins() {
 		return
 	}
 
+	// in on demand mode, only pinned plugins are launched ahead of dispatches
+	var pinned map[string]bool
+	if c.LocalPluginOnDemand() {
+		pinned = c.pinnedLocalPlugins()
+	}
+
 	var wg sync.WaitGroup
 
 	for _, uniquePluginIdentifier := range plugins {
+		if pinned != nil && !pinned[uniquePluginIdentifier.PluginID()] {
+			continue
+		}
+
 		// check if the plugin is in the ignore list
 		if _, ok := c.localPluginWatchIgnoreList.Load(uniquePluginIdentifier); ok {
 			// skip the plugin
@@ -76,6 +119,11 @@ func (c *ControlPanel) handleNewLocalPlugins() {
 			continue
 		}
 
+		// skip if the plugin is quarantined on this node
+		if c.localPluginQuarantines.Exists(uniquePluginIdentifier) {
+			continue
+		}
+
 		// get the retry count
 		retry, ok := c.localPluginFailsRecord.Load(uniquePluginIdentifier)
 		if !ok {
@@ -102,6 +150,10 @@ func (c *ControlPanel) handleNewLocalPlugins() {
 		}, func() {
 			defer wg.Done()
 			_, ch, err := c.LaunchLocalPlugin(uniquePluginIdentifier)
+			if errors.Is(err, ErrLocalPluginQuarantined) {
+				log.Info("skip launching local plugin %s: %s", uniquePluginIdentifier, err.Error())
+				return
+			}
 			if err != nil {
 				log.Error("launch local plugin failed: %s, retried in %d seconds", err.Error(), waitTime)
 				return
@@ -21,6 +21,12 @@ type ControlPanelNotifier interface {
 	OnLocalRuntimeScaleUp(runtime *local_runtime.LocalPluginRuntime, instanceNums int32)
 	// on local runtime scale down
 	OnLocalRuntimeScaleDown(runtime *local_runtime.LocalPluginRuntime, instanceNums int32)
+	// on a ready instance of local runtime exited unexpectedly
+	OnLocalRuntimeInstanceCrashed(
+		runtime *local_runtime.LocalPluginRuntime,
+		instance *local_runtime.PluginInstance,
+		err error,
+	)
 
 	// on remote runtime connected
 	OnDebuggingRuntimeConnected(runtime *debugging_runtime.RemotePluginRuntime)
@@ -0,0 +1,56 @@
+package controlpanel
+
+import (
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	routinepkg "github.com/langgenius/dify-plugin-daemon/pkg/routine"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/routine"
+)
+
+// DrainLocalPlugin retires a local plugin runtime replaced by `successor` without interrupting it
+// new sessions to the original plugin are dispatched to the successor once it's running on this node,
+// sessions already bound to the original runtime keep going until they are finished
+// or `PluginLocalUpgradeDrainTimeout` is reached
+//
+// `onProgress` is called with the number of in-flight sessions of the original runtime periodically
+// Returns a channel that will be closed once the original runtime is shutdown
+func (c *ControlPanel) DrainLocalPlugin(
+	original plugin_entities.PluginUniqueIdentifier,
+	successor plugin_entities.PluginUniqueIdentifier,
+	onProgress func(inFlightSessions int),
+) (<-chan error, error) {
+	runtime, exists := c.localPluginRuntimes.Load(original)
+	if !exists {
+		return nil, ErrLocalPluginRuntimeNotFound
+	}
+
+	// from now on, new dispatches to the original plugin go to the successor
+	c.localPluginSuccessors.Store(original, successor)
+
+	ch := make(chan error, 1)
+
+	routine.Submit(routinepkg.Labels{
+		routinepkg.RoutineLabelKeyModule: "controlpanel",
+		routinepkg.RoutineLabelKeyMethod: "DrainLocalPlugin",
+	}, func() {
+		runtime.Drain(time.Duration(c.config.PluginLocalUpgradeDrainTimeout)*time.Second, onProgress)
+
+		// the original runtime is gone, requests to it are rejected from now on
+		// as the installation has been switched to the successor, only stale ones are affected
+		c.localPluginSuccessors.Delete(original)
+
+		// trigger that the runtime has shutdown
+		close(ch)
+	})
+
+	return ch, nil
+}
+
+// IsLocalPluginDraining returns true if the plugin is being replaced by another one
+func (c *ControlPanel) IsLocalPluginDraining(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) bool {
+	_, ok := c.localPluginSuccessors.Load(pluginUniqueIdentifier)
+	return ok
+}
@@ -0,0 +1,38 @@
+package controlpanel
+
+import (
+	"testing"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPluginRuntimeOfDrainingPlugin(t *testing.T) {
+	original, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/a:1.0.0@1234567890abcdef1234567890abcdef1234567890abcdef")
+	assert.NoError(t, err)
+	successor, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/a:1.0.1@abcdef1234567890abcdef1234567890abcdef1234567890")
+	assert.NoError(t, err)
+
+	controlPanel := &ControlPanel{}
+	originalRuntime := &local_runtime.LocalPluginRuntime{}
+	successorRuntime := &local_runtime.LocalPluginRuntime{}
+	controlPanel.localPluginRuntimes.Store(original, originalRuntime)
+	controlPanel.localPluginSuccessors.Store(original, successor)
+
+	// the successor is not running on this node yet, keep using the original one
+	runtime, err := controlPanel.GetPluginRuntime(original)
+	assert.NoError(t, err)
+	assert.Same(t, originalRuntime, runtime)
+
+	controlPanel.localPluginRuntimes.Store(successor, successorRuntime)
+	runtime, err = controlPanel.GetPluginRuntime(original)
+	assert.NoError(t, err)
+	assert.Same(t, successorRuntime, runtime)
+
+	// the original runtime was shutdown after draining
+	controlPanel.localPluginSuccessors.Delete(original)
+	controlPanel.localPluginRuntimes.Delete(original)
+	_, err = controlPanel.GetPluginRuntime(original)
+	assert.ErrorIs(t, err, ErrPluginRuntimeNotFound)
+}
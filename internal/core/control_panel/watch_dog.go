@@ -26,6 +26,11 @@ func (c *ControlPanel) startLocalPluginWatchDog() {
 		// a plugin may be uninstalled by other nodes
 		// to ensure all uninstalled plugin running in all nodes are stopped
 		go c.removeUnusedLocalPlugins()
+
+		// reclaim disk space of uninstalled and upgraded plugins
+		if c.config.PluginDiskGCEnabled {
+			go c.collectDiskPeriodically()
+		}
 	}
 }
 
@@ -35,6 +40,9 @@ func (c *ControlPanel) startDebuggingServerWatchDog() {
 		// setup debugging server
 		c.setupDebuggingServer(c.config)
 
+		// disconnect plugins once their keys are revoked
+		go c.watchRevokedDebuggingKeys()
+
 		// launch debugging server
 		go func() {
 			err := c.startDebuggingServer()
@@ -1,14 +1,20 @@
 package debugging_runtime
 
 import (
-	"bytes"
 	"errors"
 
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/framing"
 	"github.com/panjf2000/gnet/v2"
 )
 
+// codec decodes json lines by default, the plugin may negotiate a framed protocol
+// with its first message, responses are encoded with the same protocol
 type codec struct {
-	buf bytes.Buffer
+	decoder *framing.Decoder
+}
+
+func newCodec(options framing.Options) *codec {
+	return &codec{decoder: framing.NewDecoder(options)}
 }
 
 func (w *codec) Decode(c gnet.Conn) ([][]byte, error) {
@@ -24,27 +30,14 @@ func (w *codec) Decode(c gnet.Conn) ([][]byte, error) {
 		return nil, errors.New("read less than size")
 	}
 
-	return w.getLines(buf), nil
+	return w.decode(buf)
 }
 
-func (w *codec) getLines(data []byte) [][]byte {
-	// write to buffer
-	w.buf.Write(data)
-
-	// read line by line, split by \n, remaining data will be kept in buffer
-	buf := make([]byte, w.buf.Len())
-	w.buf.Read(buf)
-	w.buf.Reset()
-
-	lines := bytes.Split(buf, []byte("\n"))
-
-	// if last line is not completed, keep it in buffer
-	if len(lines[len(lines)-1]) != 0 {
-		w.buf.Write(lines[len(lines)-1])
-		lines = lines[:len(lines)-1]
-	} else if len(lines) > 0 {
-		lines = lines[:len(lines)-1]
-	}
+func (w *codec) decode(data []byte) ([][]byte, error) {
+	return w.decoder.Feed(data)
+}
 
-	return lines
+// Encode encodes a json message with the negotiated protocol
+func (w *codec) Encode(message []byte) ([]byte, error) {
+	return w.decoder.Protocol().Encode(message)
 }
@@ -2,35 +2,62 @@ package debugging_runtime
 
 import (
 	"testing"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/framing"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestCodec(t *testing.T) {
-	codec := &codec{}
-	liens := codec.getLines([]byte("test\n"))
+	codec := newCodec(framing.Options{})
+	liens, _ := codec.decode([]byte("test\n"))
 	if len(liens) != 1 {
 		t.Error("getLines failed")
 	}
 
-	liens = codec.getLines([]byte("test\ntest"))
+	liens, _ = codec.decode([]byte("test\ntest"))
 	if len(liens) == 2 {
 		t.Error("getLines failed")
 	}
 
-	liens = codec.getLines([]byte("\n"))
+	liens, _ = codec.decode([]byte("\n"))
 	if len(liens) != 1 {
 		t.Error("getLines failed")
 	}
 }
 
 func TestCodec2(t *testing.T) {
-	codec := &codec{}
+	codec := newCodec(framing.Options{})
 
 	msg := "9c3df1b4-6daf-4cb4-bcaa-3f05a2dbc3a1\n{\"version\":\"1.0.0\",\"type\":\"plugin\",\"author\":\"Yeuoly\",\"name\":\"ci_test\",\"created_at\":\"2024-08-14T19:48:04.867581+08:00\",\"resource\":{\"memory\":1,\"storage\":1,\"permission\":null},\"plugins\":[\"test\"],\"execution\":{\"install\":\"echo 'hello'\",\"launch\":\"echo 'hello'\"},\"meta\":{\"version\":\"0.0.1\",\"arch\":[\"amd64\"],\"runner\":{\"language\":\"python\",\"version\":\"3.12\",\"entrypoint\":\"main\"}}}"
 
-	lines := codec.getLines([]byte(msg))
+	lines, _ := codec.decode([]byte(msg))
 	if len(lines) != 1 {
 		if string(lines[0]) != msg[:len(lines[0])] {
 			t.Error("getLines failed")
 		}
 	}
 }
+
+func TestCodecNegotiation(t *testing.T) {
+	codec := newCodec(framing.Options{Negotiable: true})
+
+	frame, err := framing.PROTOCOL_FRAMED_MSGPACK.Encode([]byte(`{"type":"handshake"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the negotiation message is consumed, the rest of the chunk is decoded as frames
+	data := append(framing.NegotiationMessage(framing.PROTOCOL_FRAMED_MSGPACK), frame[:3]...)
+	messages, err := codec.decode(data)
+	assert.NoError(t, err)
+	assert.Empty(t, messages)
+
+	messages, err = codec.decode(frame[3:])
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"type":"handshake"}`)}, messages)
+
+	// responses use the negotiated protocol
+	response, err := codec.Encode([]byte(`{"type":"handshake"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, frame, response)
+}
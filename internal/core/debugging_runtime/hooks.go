@@ -10,6 +10,7 @@ import (
 	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager/basic_runtime"
 	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager/media_transport"
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/framing"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/parser"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/stream"
@@ -33,6 +34,12 @@ type DifyServer struct {
 	// event loop count
 	numLoops int
 
+	// framing of messages, plugins may negotiate it with their first message
+	framingOptions framing.Options
+
+	// terminates tls in front of the engine, nil if the server accepts plaintext
+	tlsTerminator *tlsTerminator
+
 	plugins     map[int]*RemotePluginRuntime
 	pluginsLock *sync.RWMutex
 
@@ -45,18 +52,33 @@ type DifyServer struct {
 
 func (s *DifyServer) OnBoot(c gnet.Engine) (action gnet.Action) {
 	s.engine = c
+
+	if s.tlsTerminator != nil {
+		// the engine listens on a random loopback port, forward tls connections to it
+		addr, err := engineAddr(c)
+		if err != nil {
+			log.Error("failed to get address of remote installing server: %s", err.Error())
+			return gnet.Shutdown
+		}
+
+		s.tlsTerminator.SetUpstream(addr.String())
+		go s.tlsTerminator.Serve()
+	}
+
 	return gnet.None
 }
 
 func (s *DifyServer) OnOpen(c gnet.Conn) (out []byte, action gnet.Action) {
 	// new plugin connected
-	c.SetContext(&codec{})
+	codec := newCodec(s.framingOptions)
+	c.SetContext(codec)
 	runtime := &RemotePluginRuntime{
 		MediaTransport: basic_runtime.NewMediaTransport(
 			s.mediaManager,
 		),
 
 		conn:                      c,
+		codec:                     codec,
 		response:                  stream.NewStream[[]byte](512),
 		messageCallbacks:          make(map[string][]func([]byte)),
 		messageCallbacksLock:      &sync.RWMutex{},
@@ -116,6 +138,10 @@ func (s *DifyServer) OnClose(c gnet.Conn, err error) (action gnet.Action) {
 }
 
 func (s *DifyServer) OnShutdown(c gnet.Engine) {
+	if s.tlsTerminator != nil {
+		s.tlsTerminator.Close()
+	}
+
 	s.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
 		notifier.OnServerShutdown(SERVER_SHUTDOWN_REASON_EXIT)
 	})
@@ -185,6 +211,10 @@ func (s *DifyServer) onMessage(runtime *RemotePluginRuntime, message []byte) {
 				closeConn(append([]byte(err.Error()), '\n'))
 			} else {
 				runtime.tenantId = connectionInfo.TenantId
+				runtime.keyId = connectionInfo.KeyId
+				runtime.userId = connectionInfo.UserId
+				runtime.connectedAt = time.Now()
+				runtime.remoteAddr = s.clientAddr(runtime.conn)
 				runtime.handshake = true
 			}
 		case plugin_entities.REGISTER_EVENT_TYPE_ASSET_CHUNK:
@@ -241,6 +271,19 @@ func (s *DifyServer) onMessage(runtime *RemotePluginRuntime, message []byte) {
 	}
 }
 
+// clientAddr returns the address of the plugin, connections forwarded by the tls terminator are resolved
+func (s *DifyServer) clientAddr(c gnet.Conn) string {
+	if s.tlsTerminator != nil {
+		if peer, ok := s.tlsTerminator.Peer(c.RemoteAddr()); ok {
+			return peer.RemoteAddr
+		}
+	}
+	if c.RemoteAddr() == nil {
+		return ""
+	}
+	return c.RemoteAddr().String()
+}
+
 // AddNotifier adds a notifier to the runtime
 func (r *DifyServer) AddNotifier(notifier PluginRuntimeNotifier) {
 	r.notifierMutex.Lock()
@@ -62,7 +62,11 @@ func (r *RemotePluginRuntime) Write(
 	if r.conn == nil {
 		return errors.New("connection not established")
 	}
-	return r.conn.AsyncWrite(append(data, '\n'), func(c gnet.Conn, err error) error {
+	message, err := r.codec.Encode(data)
+	if err != nil {
+		return err
+	}
+	return r.conn.AsyncWrite(message, func(c gnet.Conn, err error) error {
 		return err
 	})
 }
@@ -47,8 +47,8 @@ func (r *RemotePluginRuntime) SpawnCore() error {
 			func(err string) {
 				log.Error("plugin %s: %s", r.Configuration().Identity(), err)
 			},
-			func(message string) {
-				log.Info("plugin %s: %s", r.Configuration().Identity(), message)
+			func(sessionId string, event plugin_entities.PluginLogEvent) {
+				log.Info("plugin %s: %s", r.Configuration().Identity(), event.Message)
 			},
 		)
 	})
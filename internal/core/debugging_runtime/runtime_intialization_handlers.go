@@ -38,6 +38,17 @@ func (d *DifyServer) handleHandleShake(
 		return nil, fmt.Errorf("failed to get connection info: %v", err)
 	}
 
+	// plaintext connections made to the engine directly are rejected if tls is enabled
+	if d.tlsTerminator != nil {
+		peer, ok := d.tlsTerminator.Peer(runtime.conn.RemoteAddr())
+		if !ok {
+			return nil, ErrTLSRequired
+		}
+		if d.tlsTerminator.mutual && peer.TenantID != info.TenantId {
+			return nil, ErrTLSTenantNotMatch
+		}
+	}
+
 	return info, nil
 }
 
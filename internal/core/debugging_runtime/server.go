@@ -3,6 +3,7 @@ package debugging_runtime
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"sync"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager/media_transport"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/framing"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
 	"github.com/panjf2000/gnet/v2"
 
 	gnet_errors "github.com/panjf2000/gnet/v2/pkg/errors"
@@ -17,6 +20,8 @@ import (
 
 type RemotePluginServer struct {
 	server *DifyServer
+
+	config *app.Config
 }
 
 type RemotePluginServerInterface interface {
@@ -36,13 +41,43 @@ func (r *RemotePluginServer) Stop() error {
 
 // Launch starts the server
 func (r *RemotePluginServer) Launch() error {
+	addr := r.server.addr
+	if r.config.PluginRemoteInstallingTLSEnabled {
+		tlsConfig, err := newTLSConfig(r.config)
+		if err != nil {
+			return err
+		}
+
+		terminator, err := newTLSTerminator(fmt.Sprintf(
+			"%s:%d",
+			r.config.PluginRemoteInstallingHost,
+			r.config.PluginRemoteInstallingPort,
+		), tlsConfig)
+		if err != nil {
+			return err
+		}
+
+		// the engine is only reachable from this host, the terminator forwards connections to it
+		r.server.tlsTerminator = terminator
+		addr = "tcp://127.0.0.1:0"
+	} else if !isLoopbackHost(r.config.PluginRemoteInstallingHost) {
+		log.Warn(
+			"remote installing server accepts plaintext connections on %s, debugging keys and plugin traffic are not encrypted, "+
+				"set PLUGIN_REMOTE_INSTALLING_TLS_ENABLED to enable tls",
+			r.config.PluginRemoteInstallingHost,
+		)
+	}
+
 	err := gnet.Run(
-		r.server, r.server.addr, gnet.WithMulticore(r.server.multicore),
+		r.server, addr, gnet.WithMulticore(r.server.multicore),
 		gnet.WithNumEventLoop(r.server.numLoops),
 	)
 
 	if err != nil {
 		r.Stop()
+		if r.server.tlsTerminator != nil {
+			r.server.tlsTerminator.Close()
+		}
 	}
 
 	// collect shutdown signal
@@ -78,6 +113,10 @@ func NewDebuggingPluginServer(
 		port:         config.PluginRemoteInstallingPort,
 		multicore:    multicore,
 		numLoops:     config.PluginRemoteInstallServerEventLoopNums,
+		framingOptions: framing.Options{
+			MaxFrameSize: config.PluginRuntimeMaxFrameSize,
+			Negotiable:   config.PluginRuntimeFramingEnabled,
+		},
 
 		plugins:     make(map[int]*RemotePluginRuntime),
 		pluginsLock: &sync.RWMutex{},
@@ -90,11 +129,41 @@ func NewDebuggingPluginServer(
 
 	manager := &RemotePluginServer{
 		server: s,
+		config: config,
 	}
 
 	return manager
 }
 
+// isLoopbackHost reports whether the server is only reachable from this host
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// engineAddr returns the address the engine listens on
+func engineAddr(engine gnet.Engine) (net.Addr, error) {
+	fd, err := engine.Dup()
+	if err != nil {
+		return nil, err
+	}
+
+	file := os.NewFile(uintptr(fd), "remote-installing-listener")
+	defer file.Close()
+
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	return listener.Addr(), nil
+}
+
 // AddNotifier adds a notifier to the runtime
 func (r *RemotePluginServer) AddNotifier(notifier PluginRuntimeNotifier) {
 	r.server.AddNotifier(notifier)
@@ -0,0 +1,182 @@
+package debugging_runtime
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/mapping"
+)
+
+const (
+	// connections which don't complete the tls handshake in time are closed
+	tlsHandshakeTimeout = 10 * time.Second
+)
+
+var (
+	ErrTLSRequired          = errors.New("handshake failed, connections must be made over tls")
+	ErrTLSTenantNotMatch    = errors.New("handshake failed, the client certificate is not issued for the tenant of the key")
+	ErrTLSClientCANotLoaded = errors.New("no certificates found in the client ca file")
+)
+
+// tlsPeer is a client connected through the tls terminator
+type tlsPeer struct {
+	// common name of the verified client certificate, empty if client certificates are not required
+	TenantID string
+	// address the client connected from
+	RemoteAddr string
+}
+
+// tlsTerminator accepts tls connections on the public address and forwards plaintext to the gnet engine
+// gnet doesn't support tls, the engine listens on loopback and only accepts connections forwarded by it
+type tlsTerminator struct {
+	listener net.Listener
+	// whether client certificates are required, tenants are bound to their certificates
+	mutual bool
+
+	upstream     string
+	upstreamLock sync.RWMutex
+
+	// local address of a forwarding connection, it's the remote address seen by the engine -> peer
+	peers mapping.Map[string, tlsPeer]
+}
+
+// newTLSConfig loads the server certificate and the client ca from files
+func newTLSConfig(config *app.Config) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(
+		config.PluginRemoteInstallingTLSCertFile,
+		config.PluginRemoteInstallingTLSKeyFile,
+	)
+	if err != nil {
+		return nil, errors.Join(err, errors.New("failed to load tls certificate"))
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if config.PluginRemoteInstallingTLSClientCAFile != "" {
+		content, err := os.ReadFile(config.PluginRemoteInstallingTLSClientCAFile)
+		if err != nil {
+			return nil, errors.Join(err, errors.New("failed to read tls client ca file"))
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, ErrTLSClientCANotLoaded
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// newTLSTerminator listens on `addr`, connections are accepted once `Serve` is called
+func newTLSTerminator(addr string, tlsConfig *tls.Config) (*tlsTerminator, error) {
+	listener, err := tls.Listen("tcp", addr, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tlsTerminator{
+		listener: listener,
+		mutual:   tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert,
+	}, nil
+}
+
+// SetUpstream sets the address connections are forwarded to, e.g. 127.0.0.1:5004
+func (t *tlsTerminator) SetUpstream(upstream string) {
+	t.upstreamLock.Lock()
+	defer t.upstreamLock.Unlock()
+
+	t.upstream = upstream
+}
+
+// Serve accepts connections until the terminator is closed
+func (t *tlsTerminator) Serve() {
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Error("tls terminator of remote installing server stopped: %s", err.Error())
+			}
+			return
+		}
+
+		go t.forward(conn.(*tls.Conn))
+	}
+}
+
+// Close stops accepting connections, forwarding connections are closed by the engine
+func (t *tlsTerminator) Close() error {
+	return t.listener.Close()
+}
+
+// Peer returns the client forwarded through `remoteAddr`, false if it's not connected through the terminator
+func (t *tlsTerminator) Peer(remoteAddr net.Addr) (tlsPeer, bool) {
+	if remoteAddr == nil {
+		return tlsPeer{}, false
+	}
+
+	return t.peers.Load(remoteAddr.String())
+}
+
+func (t *tlsTerminator) forward(conn *tls.Conn) {
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(tlsHandshakeTimeout))
+	if err := conn.Handshake(); err != nil {
+		log.Warn("tls handshake with %s failed: %s", conn.RemoteAddr().String(), err.Error())
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	peer := tlsPeer{RemoteAddr: conn.RemoteAddr().String()}
+	if t.mutual {
+		certificates := conn.ConnectionState().PeerCertificates
+		if len(certificates) == 0 || certificates[0].Subject.CommonName == "" {
+			conn.Write([]byte("handshake failed, the client certificate has no tenant id as its common name\n"))
+			return
+		}
+		peer.TenantID = certificates[0].Subject.CommonName
+	}
+
+	t.upstreamLock.RLock()
+	upstream := t.upstream
+	t.upstreamLock.RUnlock()
+
+	upstreamConn, err := net.Dial("tcp", upstream)
+	if err != nil {
+		log.Error("failed to forward tls connection to remote installing server: %s", err.Error())
+		return
+	}
+	defer upstreamConn.Close()
+
+	// registered before any byte is forwarded, the engine looks it up on handshake
+	key := upstreamConn.LocalAddr().String()
+	t.peers.Store(key, peer)
+	defer t.peers.Delete(key)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(upstreamConn, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, upstreamConn)
+		done <- struct{}{}
+	}()
+
+	// either side closed, the deferred closes stop the other direction
+	<-done
+}
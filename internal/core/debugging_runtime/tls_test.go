@@ -0,0 +1,228 @@
+package debugging_runtime
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/network"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	tls         tls.Certificate
+}
+
+func issueTestCertificate(t *testing.T, commonName string, parent *testCertificate, isCA bool) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.certificate, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCertificate{
+		certificate: certificate,
+		key:         key,
+		tls:         tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+	}
+}
+
+func writeTestCertificate(t *testing.T, dir string, name string, certificate *testCertificate) (string, string) {
+	keyDer, err := x509.MarshalECPrivateKey(certificate.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{
+		Type: "CERTIFICATE", Bytes: certificate.certificate.Raw,
+	}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{
+		Type: "EC PRIVATE KEY", Bytes: keyDer,
+	}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certFile, keyFile
+}
+
+func TestTLSTerminatorBindsTenantToClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueTestCertificate(t, "ca", nil, true)
+	server := issueTestCertificate(t, "server", ca, false)
+	client := issueTestCertificate(t, "tenant-a", ca, false)
+
+	certFile, keyFile := writeTestCertificate(t, dir, "server", server)
+	caFile, _ := writeTestCertificate(t, dir, "ca", ca)
+
+	tlsConfig, err := newTLSConfig(&app.Config{
+		PluginRemoteInstallingTLSCertFile:     certFile,
+		PluginRemoteInstallingTLSKeyFile:      keyFile,
+		PluginRemoteInstallingTLSClientCAFile: caFile,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// upstream reports the peer of each forwarded connection
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer upstream.Close()
+
+	terminator, err := newTLSTerminator("127.0.0.1:0", tlsConfig)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer terminator.Close()
+	terminator.SetUpstream(upstream.Addr().String())
+	go terminator.Serve()
+
+	go func() {
+		for {
+			conn, err := upstream.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil {
+					return
+				}
+				peer, ok := terminator.Peer(conn.RemoteAddr())
+				fmt.Fprintf(conn, "%s %v %s", peer.TenantID, ok, line)
+			}()
+		}
+	}()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.certificate)
+
+	conn, err := tls.Dial("tcp", terminator.listener.Addr().String(), &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{client.tls},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("hello\n"))
+	assert.NoError(t, err)
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "tenant-a true hello\n", line)
+
+	// connections made to the upstream directly are not forwarded
+	direct, err := net.Dial("tcp", upstream.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer direct.Close()
+	_, err = direct.Write([]byte("hello\n"))
+	assert.NoError(t, err)
+	line, err = bufio.NewReader(direct).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, " false hello\n", line)
+
+	// clients without certificates are rejected
+	anonymous, err := tls.Dial("tcp", terminator.listener.Addr().String(), &tls.Config{RootCAs: pool})
+	if err == nil {
+		defer anonymous.Close()
+		anonymous.SetDeadline(time.Now().Add(5 * time.Second))
+		_, err = bufio.NewReader(anonymous).ReadString('\n')
+	}
+	assert.Error(t, err)
+}
+
+func TestLaunchPluginServerWithTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueTestCertificate(t, "ca", nil, true)
+	certFile, keyFile := writeTestCertificate(t, dir, "server", issueTestCertificate(t, "server", ca, false))
+
+	port, err := network.GetRandomPort()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	server := NewDebuggingPluginServer(&app.Config{
+		PluginRemoteInstallingHost:             "127.0.0.1",
+		PluginRemoteInstallingPort:             port,
+		PluginRemoteInstallingMaxConn:          1,
+		PluginRemoteInstallServerEventLoopNums: 1,
+		PluginRemoteInstallingTLSEnabled:       true,
+		PluginRemoteInstallingTLSCertFile:      certFile,
+		PluginRemoteInstallingTLSKeyFile:       keyFile,
+	}, nil)
+	go server.Launch()
+	defer server.Stop()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.certificate)
+
+	var conn *tls.Conn
+	for i := 0; i < 50; i++ {
+		conn, err = tls.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port), &tls.Config{RootCAs: pool})
+		if err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	// messages are forwarded to the engine
+	_, err = conn.Write([]byte("not a handshake\n"))
+	assert.NoError(t, err)
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "handshake failed, invalid handshake message\n", line)
+}
@@ -19,6 +19,7 @@ type RemotePluginRuntime struct {
 
 	// connection
 	conn   gnet.Conn
+	codec  *codec
 	closed int32
 
 	// response entity to accept new events
@@ -59,6 +60,14 @@ type RemotePluginRuntime struct {
 	// tenant id
 	tenantId string
 
+	// the key used to connect and the user who created it
+	keyId  string
+	userId string
+
+	// when the handshake completed and where the plugin connected from
+	connectedAt time.Time
+	remoteAddr  string
+
 	alive bool
 
 	// checksum
@@ -91,6 +100,18 @@ func (r *RemotePluginRuntime) removeMessageCallbackHandler(session_id string) {
 	r.messageCallbacksLock.Unlock()
 }
 
+// InFlightSessions returns the number of sessions waiting for messages from the plugin
+func (r *RemotePluginRuntime) InFlightSessions() int {
+	r.messageCallbacksLock.RLock()
+	defer r.messageCallbacksLock.RUnlock()
+	return len(r.messageCallbacks)
+}
+
+// LastActiveAt returns the last time the plugin sent a heartbeat
+func (r *RemotePluginRuntime) LastActiveAt() time.Time {
+	return r.lastActiveAt
+}
+
 // addSessionMessageCloser adds a closer for the given session_id
 // once the session is closed or the connection is closed, the closer will be called
 func (r *RemotePluginRuntime) addSessionMessageCloser(session_id string, fn func()) {
@@ -145,6 +166,26 @@ func (r *RemotePluginRuntime) TenantId() string {
 	return r.tenantId
 }
 
+// KeyId returns id of the debugging key the plugin connected with
+func (r *RemotePluginRuntime) KeyId() string {
+	return r.keyId
+}
+
+// UserId returns the user who created the debugging key, empty for the default key of a tenant
+func (r *RemotePluginRuntime) UserId() string {
+	return r.userId
+}
+
+// ConnectedAt returns when the plugin completed its handshake
+func (r *RemotePluginRuntime) ConnectedAt() time.Time {
+	return r.connectedAt
+}
+
+// RemoteAddr returns the address the plugin connected from
+func (r *RemotePluginRuntime) RemoteAddr() string {
+	return r.remoteAddr
+}
+
 func (r *RemotePluginRuntime) InstallationId() string {
 	return r.installationId
 }
@@ -13,6 +13,11 @@ const (
 	PLUGIN_ACCESS_TYPE_TRIGGER           PluginAccessType = "trigger"
 )
 
+// liveness probes sent by the daemon to local plugin instances, not invokable by callers
+const (
+	PLUGIN_ACCESS_TYPE_PING PluginAccessType = "ping"
+)
+
 func (p PluginAccessType) IsValid() bool {
 	return p == PLUGIN_ACCESS_TYPE_TOOL ||
 		p == PLUGIN_ACCESS_TYPE_MODEL ||
@@ -62,6 +67,10 @@ const (
 	PLUGIN_ACCESS_ACTION_VALIDATE_TRIGGER_CREDENTIALS                       PluginAccessAction = "validate_trigger_credentials"
 )
 
+const (
+	PLUGIN_ACCESS_ACTION_PING PluginAccessAction = "ping"
+)
+
 func (p PluginAccessAction) IsValid() bool {
 	return p == PLUGIN_ACCESS_ACTION_INVOKE_TOOL ||
 		p == PLUGIN_ACCESS_ACTION_VALIDATE_TOOL_CREDENTIALS ||
@@ -0,0 +1,163 @@
+package local_runtime
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+)
+
+const (
+	// weight of the newest sample in the moving average of first response latency
+	firstResponseEWMAWeight = 0.2
+)
+
+type AutoscalerConfig struct {
+	// lower and upper bound of `instanceNums`
+	MinReplicas int32
+	MaxReplicas int32
+
+	// expected in-flight sessions per instance
+	// once the average exceeds it, a new instance will be launched
+	TargetInFlightPerInstance int
+
+	// once the average first response latency exceeds it, a new instance will be launched
+	// it includes the time the plugin takes to produce its first message, not only the time spent waiting
+	FirstResponseThreshold time.Duration
+
+	// minimum interval between two scale up / scale down operations
+	ScaleUpCooldown   time.Duration
+	ScaleDownCooldown time.Duration
+}
+
+func newAutoscalerConfig(appConfig *app.Config) AutoscalerConfig {
+	return AutoscalerConfig{
+		MinReplicas:               int32(appConfig.PluginLocalAutoscaleMinReplicas),
+		MaxReplicas:               int32(appConfig.PluginLocalAutoscaleMaxReplicas),
+		TargetInFlightPerInstance: appConfig.PluginLocalAutoscaleTargetInFlight,
+		FirstResponseThreshold:    time.Duration(appConfig.PluginLocalAutoscaleFirstResponseThreshold) * time.Millisecond,
+		ScaleUpCooldown:           time.Duration(appConfig.PluginLocalAutoscaleScaleUpCooldown) * time.Second,
+		ScaleDownCooldown:         time.Duration(appConfig.PluginLocalAutoscaleScaleDownCooldown) * time.Second,
+	}
+}
+
+// Autoscaler adjusts the replicas of a local runtime according to its load
+// it's driven by the schedule loop, no extra goroutine is needed
+type Autoscaler struct {
+	config AutoscalerConfig
+
+	lastScaleUpAt   time.Time
+	lastScaleDownAt time.Time
+}
+
+// AutoscalerMetrics is a snapshot of the runtime load
+type AutoscalerMetrics struct {
+	// expected instance nums
+	Replicas int32
+	// instances which are ready to accept requests
+	ReadyInstances int
+	// in-flight sessions across all ready instances
+	InFlightSessions int
+	// moving average of the time between a session being dispatched and the first message from the plugin
+	FirstResponseLatency time.Duration
+}
+
+func newAutoscaler(config AutoscalerConfig) *Autoscaler {
+	return &Autoscaler{config: config}
+}
+
+// decide returns the replica delta, 1 to scale up, -1 to scale down and 0 to keep it
+func (a *Autoscaler) decide(now time.Time, metrics AutoscalerMetrics) int {
+	// always keep replicas in [min, max]
+	if metrics.Replicas < a.config.MinReplicas {
+		return 1
+	}
+	if metrics.Replicas > a.config.MaxReplicas {
+		return -1
+	}
+
+	// do not make decisions while instances are launching or shutting down
+	// the metrics are not stable yet
+	if metrics.ReadyInstances != int(metrics.Replicas) || metrics.ReadyInstances == 0 {
+		return 0
+	}
+
+	overloaded := metrics.InFlightSessions >= metrics.ReadyInstances*a.config.TargetInFlightPerInstance ||
+		(a.config.FirstResponseThreshold > 0 && metrics.FirstResponseLatency >= a.config.FirstResponseThreshold)
+
+	if overloaded {
+		if metrics.Replicas >= a.config.MaxReplicas {
+			return 0
+		}
+		if now.Sub(a.lastScaleUpAt) < a.config.ScaleUpCooldown {
+			return 0
+		}
+		a.lastScaleUpAt = now
+		return 1
+	}
+
+	// scale down only if the load still fits the target with one instance less
+	underloaded := metrics.InFlightSessions < (metrics.ReadyInstances-1)*a.config.TargetInFlightPerInstance
+	if underloaded && metrics.Replicas > a.config.MinReplicas {
+		// a scale up resets the cooldown of scale down as well, avoid flapping
+		if now.Sub(a.lastScaleDownAt) < a.config.ScaleDownCooldown ||
+			now.Sub(a.lastScaleUpAt) < a.config.ScaleDownCooldown {
+			return 0
+		}
+		a.lastScaleDownAt = now
+		return -1
+	}
+
+	return 0
+}
+
+// autoscale collects metrics and adjusts replicas, it's called by the schedule loop
+func (r *LocalPluginRuntime) autoscale() {
+	if r.autoscaler == nil {
+		return
+	}
+
+	metrics := r.autoscalerMetrics()
+	if metrics.InFlightSessions == 0 {
+		// no traffic, the moving average is stale
+		atomic.StoreInt64(&r.firstResponseEWMA, 0)
+	}
+
+	switch r.autoscaler.decide(time.Now(), metrics) {
+	case 1:
+		r.ScaleUp()
+	case -1:
+		r.ScaleDown()
+	}
+}
+
+func (r *LocalPluginRuntime) autoscalerMetrics() AutoscalerMetrics {
+	r.instanceLocker.RLock()
+	defer r.instanceLocker.RUnlock()
+
+	inFlight := 0
+	for _, instance := range r.instances {
+		inFlight += instance.InFlightSessions()
+	}
+
+	return AutoscalerMetrics{
+		Replicas:             atomic.LoadInt32(&r.instanceNums),
+		ReadyInstances:       len(r.instances),
+		InFlightSessions:     inFlight,
+		FirstResponseLatency: time.Duration(atomic.LoadInt64(&r.firstResponseEWMA)),
+	}
+}
+
+// recordFirstResponse updates the moving average of first response latency
+func (r *LocalPluginRuntime) recordFirstResponse(wait time.Duration) {
+	for {
+		old := atomic.LoadInt64(&r.firstResponseEWMA)
+		updated := int64(float64(old)*(1-firstResponseEWMAWeight) + float64(wait)*firstResponseEWMAWeight)
+		if old == 0 {
+			updated = int64(wait)
+		}
+		if atomic.CompareAndSwapInt64(&r.firstResponseEWMA, old, updated) {
+			return
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package local_runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAutoscaler() *Autoscaler {
+	return newAutoscaler(AutoscalerConfig{
+		MinReplicas:               1,
+		MaxReplicas:               3,
+		TargetInFlightPerInstance: 4,
+		FirstResponseThreshold:    2 * time.Second,
+		ScaleUpCooldown:           30 * time.Second,
+		ScaleDownCooldown:         300 * time.Second,
+	})
+}
+
+func TestAutoscalerKeepsReplicasInRange(t *testing.T) {
+	autoscaler := newTestAutoscaler()
+	now := time.Now()
+
+	assert.Equal(t, 1, autoscaler.decide(now, AutoscalerMetrics{Replicas: 0}))
+	assert.Equal(t, -1, autoscaler.decide(now, AutoscalerMetrics{Replicas: 4, ReadyInstances: 4}))
+}
+
+func TestAutoscalerScaleUp(t *testing.T) {
+	autoscaler := newTestAutoscaler()
+	now := time.Now()
+
+	// overloaded by in-flight sessions
+	assert.Equal(t, 1, autoscaler.decide(now, AutoscalerMetrics{
+		Replicas: 1, ReadyInstances: 1, InFlightSessions: 4,
+	}))
+
+	// cooldown
+	assert.Equal(t, 0, autoscaler.decide(now.Add(10*time.Second), AutoscalerMetrics{
+		Replicas: 2, ReadyInstances: 2, InFlightSessions: 8,
+	}))
+
+	// overloaded by first response latency
+	assert.Equal(t, 1, autoscaler.decide(now.Add(31*time.Second), AutoscalerMetrics{
+		Replicas: 2, ReadyInstances: 2, InFlightSessions: 1, FirstResponseLatency: 3 * time.Second,
+	}))
+
+	// max replicas reached
+	assert.Equal(t, 0, autoscaler.decide(now.Add(time.Hour), AutoscalerMetrics{
+		Replicas: 3, ReadyInstances: 3, InFlightSessions: 100,
+	}))
+}
+
+func TestAutoscalerWaitsForPendingInstances(t *testing.T) {
+	autoscaler := newTestAutoscaler()
+	assert.Equal(t, 0, autoscaler.decide(time.Now(), AutoscalerMetrics{
+		Replicas: 2, ReadyInstances: 1, InFlightSessions: 100,
+	}))
+}
+
+func TestAutoscalerScaleDown(t *testing.T) {
+	autoscaler := newTestAutoscaler()
+	now := time.Now()
+
+	assert.Equal(t, 1, autoscaler.decide(now, AutoscalerMetrics{
+		Replicas: 1, ReadyInstances: 1, InFlightSessions: 4,
+	}))
+
+	// recently scaled up
+	assert.Equal(t, 0, autoscaler.decide(now.Add(time.Minute), AutoscalerMetrics{
+		Replicas: 2, ReadyInstances: 2, InFlightSessions: 0,
+	}))
+
+	// load still needs both instances
+	assert.Equal(t, 0, autoscaler.decide(now.Add(time.Hour), AutoscalerMetrics{
+		Replicas: 2, ReadyInstances: 2, InFlightSessions: 5,
+	}))
+
+	assert.Equal(t, -1, autoscaler.decide(now.Add(time.Hour), AutoscalerMetrics{
+		Replicas: 2, ReadyInstances: 2, InFlightSessions: 1,
+	}))
+
+	// min replicas reached
+	assert.Equal(t, 0, autoscaler.decide(now.Add(2*time.Hour), AutoscalerMetrics{
+		Replicas: 1, ReadyInstances: 1, InFlightSessions: 0,
+	}))
+}
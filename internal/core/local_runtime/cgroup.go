@@ -0,0 +1,117 @@
+package local_runtime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// cgroup v2 is not available on the current platform
+	ErrCgroupNotSupported = errors.New("cgroup v2 is not supported on this platform")
+
+	// the instance was killed by the kernel as it exceeded the memory limit
+	ErrInstanceOOMKilled = errors.New("instance was killed by the OOM killer")
+)
+
+// CgroupLimits is the resource limits applied to each plugin instance
+// zero means unlimited
+type CgroupLimits struct {
+	// memory.max in bytes
+	Memory int64
+	// cpu.max in millicores, 1000 means 1 core
+	CPUMillicores int64
+	// pids.max
+	Pids int64
+}
+
+func (l CgroupLimits) memoryMax() string {
+	if l.Memory <= 0 {
+		return "max"
+	}
+	return fmt.Sprintf("%d", l.Memory)
+}
+
+func (l CgroupLimits) cpuMax() string {
+	const period = 100000
+	if l.CPUMillicores <= 0 {
+		return fmt.Sprintf("max %d", period)
+	}
+	return fmt.Sprintf("%d %d", l.CPUMillicores*period/1000, period)
+}
+
+func (l CgroupLimits) pidsMax() string {
+	if l.Pids <= 0 {
+		return "max"
+	}
+	return fmt.Sprintf("%d", l.Pids)
+}
+
+// cgroupLimits calculates the limits of the runtime
+// memory is taken from the plugin declaration and clamped by operator configured caps
+func (r *LocalPluginRuntime) cgroupLimits() CgroupLimits {
+	memory := r.Config.Resource.Memory
+	if r.appConfig.PluginLocalCgroupMemoryMin > 0 && memory < r.appConfig.PluginLocalCgroupMemoryMin {
+		memory = r.appConfig.PluginLocalCgroupMemoryMin
+	}
+	if r.appConfig.PluginLocalCgroupMemoryMax > 0 && memory > r.appConfig.PluginLocalCgroupMemoryMax {
+		memory = r.appConfig.PluginLocalCgroupMemoryMax
+	}
+
+	return CgroupLimits{
+		Memory:        memory,
+		CPUMillicores: r.appConfig.PluginLocalCgroupCPUMillicores,
+		Pids:          r.appConfig.PluginLocalCgroupPidsMax,
+	}
+}
+
+// newInstanceCgroupName generates a unique cgroup name for a new instance
+// cgroup names can not contain `/`, replace it like working path does for `:`
+func (r *LocalPluginRuntime) newInstanceCgroupName() string {
+	identity := strings.NewReplacer("/", "_", ":", "-").Replace(r.Config.Identity())
+	return fmt.Sprintf("%s-%s", identity, uuid.New().String()[:8])
+}
+
+// prepareInstanceCgroup creates a cgroup for a new instance if enabled
+// returns nil if cgroup is disabled
+func (r *LocalPluginRuntime) prepareInstanceCgroup() (*instanceCgroup, error) {
+	if !r.appConfig.PluginLocalCgroupEnabled {
+		return nil, nil
+	}
+
+	cgroup, err := createInstanceCgroup(
+		r.appConfig.PluginLocalCgroupRoot,
+		r.newInstanceCgroupName(),
+		r.cgroupLimits(),
+	)
+	if err != nil {
+		return nil, errors.Join(err, fmt.Errorf("failed to create cgroup for plugin instance"))
+	}
+
+	return cgroup, nil
+}
+
+// exitError explains why an instance exited, it's used when reporting launch failures
+func (s *PluginInstance) exitError() error {
+	if s.oomKilled.Load() {
+		return fmt.Errorf(
+			"plugin %s: %w, memory limit of %d bytes exceeded",
+			s.pluginUniqueIdentifier,
+			ErrInstanceOOMKilled,
+			s.cgroup.limits.Memory,
+		)
+	}
+
+	return fmt.Errorf("plugin failed to start, %s", s.stderrReport())
+}
+
+// crashError explains why a ready instance exited without being requested to stop
+func (s *PluginInstance) crashError() error {
+	if s.oomKilled.Load() {
+		return s.exitError()
+	}
+
+	return fmt.Errorf("plugin %s exited unexpectedly, %s", s.pluginUniqueIdentifier, s.stderrReport())
+}
@@ -0,0 +1,126 @@
+package local_runtime
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// instanceCgroup is a cgroup v2 directory which holds exactly one plugin instance
+type instanceCgroup struct {
+	path   string
+	limits CgroupLimits
+	fd     *os.File
+}
+
+func createInstanceCgroup(root string, name string, limits CgroupLimits) (*instanceCgroup, error) {
+	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err != nil {
+		return nil, ErrCgroupNotSupported
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cgroup root %s: %w", root, err)
+	}
+
+	// enable controllers for children, it's fine if they were enabled already
+	if err := os.WriteFile(
+		path.Join(root, "cgroup.subtree_control"),
+		[]byte("+memory +cpu +pids"),
+		0644,
+	); err != nil {
+		return nil, fmt.Errorf("failed to enable cgroup controllers in %s: %w", root, err)
+	}
+
+	cgroupPath := path.Join(root, name)
+	if err := os.Mkdir(cgroupPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cgroup %s: %w", cgroupPath, err)
+	}
+
+	cgroup := &instanceCgroup{path: cgroupPath, limits: limits}
+
+	settings := [][2]string{
+		{"memory.max", limits.memoryMax()},
+		// disable swap, otherwise memory.max could be bypassed
+		{"memory.swap.max", "0"},
+		// kill all processes in the cgroup together, a half killed plugin is useless
+		{"memory.oom.group", "1"},
+		{"cpu.max", limits.cpuMax()},
+		{"pids.max", limits.pidsMax()},
+	}
+
+	for _, setting := range settings {
+		if err := os.WriteFile(path.Join(cgroupPath, setting[0]), []byte(setting[1]), 0644); err != nil {
+			// memory.swap.max does not exist if swap accounting is disabled
+			if setting[0] == "memory.swap.max" && errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			cgroup.remove()
+			return nil, fmt.Errorf("failed to write %s of cgroup %s: %w", setting[0], cgroupPath, err)
+		}
+	}
+
+	fd, err := os.Open(cgroupPath)
+	if err != nil {
+		cgroup.remove()
+		return nil, fmt.Errorf("failed to open cgroup %s: %w", cgroupPath, err)
+	}
+	cgroup.fd = fd
+
+	return cgroup, nil
+}
+
+// attach makes the command to be started inside the cgroup directly
+// so that no memory could be allocated outside the limits
+func (c *instanceCgroup) attach(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(c.fd.Fd())
+}
+
+// oomKilled checks memory.events to see if any process was killed by the OOM killer
+func (c *instanceCgroup) oomKilled() bool {
+	file, err := os.Open(path.Join(c.path, "memory.events"))
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			count, err := strconv.Atoi(fields[1])
+			return err == nil && count > 0
+		}
+	}
+
+	return false
+}
+
+// remove deletes the cgroup, it retries for a while as the kernel may
+// still be cleaning up exiting processes
+func (c *instanceCgroup) remove() error {
+	if c.fd != nil {
+		c.fd.Close()
+	}
+
+	var err error
+	for i := 0; i < 10; i++ {
+		err = os.Remove(c.path)
+		if err == nil || errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	return fmt.Errorf("failed to remove cgroup %s: %w", c.path, err)
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+
+package local_runtime
+
+import "os/exec"
+
+// instanceCgroup is not available on platforms other than linux
+type instanceCgroup struct {
+	limits CgroupLimits
+}
+
+func createInstanceCgroup(root string, name string, limits CgroupLimits) (*instanceCgroup, error) {
+	return nil, ErrCgroupNotSupported
+}
+
+func (c *instanceCgroup) attach(cmd *exec.Cmd) {}
+
+func (c *instanceCgroup) oomKilled() bool {
+	return false
+}
+
+func (c *instanceCgroup) remove() error {
+	return nil
+}
@@ -0,0 +1,30 @@
+package local_runtime
+
+import (
+	"testing"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCgroupLimits(t *testing.T) {
+	runtime := &LocalPluginRuntime{
+		appConfig: &app.Config{
+			PluginLocalCgroupMemoryMin:     128 * 1024 * 1024,
+			PluginLocalCgroupMemoryMax:     1024 * 1024 * 1024,
+			PluginLocalCgroupCPUMillicores: 1500,
+		},
+	}
+
+	runtime.Config.Resource = plugin_entities.PluginResourceRequirement{Memory: 1024}
+	limits := runtime.cgroupLimits()
+	assert.Equal(t, int64(128*1024*1024), limits.Memory)
+
+	runtime.Config.Resource = plugin_entities.PluginResourceRequirement{Memory: 4 * 1024 * 1024 * 1024}
+	limits = runtime.cgroupLimits()
+	assert.Equal(t, int64(1024*1024*1024), limits.Memory)
+	assert.Equal(t, "1073741824", limits.memoryMax())
+	assert.Equal(t, "150000 100000", limits.cpuMax())
+	assert.Equal(t, "max", limits.pidsMax())
+}
@@ -52,9 +52,21 @@ func ConstructPluginRuntime(
 		instances:      []*PluginInstance{},
 		instanceLocker: &sync.RWMutex{},
 
+		loadBalancingStrategy: resolveLoadBalancingStrategy(
+			appConfig.PluginLocalLoadBalancingStrategy,
+			appConfig.PluginLocalLoadBalancingStrategyOverrides,
+			fmt.Sprintf("%s/%s", manifest.Author, manifest.Name),
+		),
+
+		restartPolicy: newRestartPolicy(newRestartPolicyConfig(appConfig)),
+
 		notifiers:    []PluginRuntimeNotifier{},
 		notifierLock: &sync.Mutex{},
 	}
+	if appConfig.PluginLocalAutoscaleEnabled {
+		runtime.autoscaler = newAutoscaler(newAutoscalerConfig(appConfig))
+	}
+
 	return runtime, nil
 }
 
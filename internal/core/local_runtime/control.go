@@ -1,6 +1,10 @@
 package local_runtime
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -9,7 +13,8 @@ import (
 )
 
 const (
-	ScheduleLoopInterval = 5 * time.Second
+	ScheduleLoopInterval  = 5 * time.Second
+	DrainProgressInterval = 5 * time.Second
 )
 
 // Start schedule loop, it's a routine method will never block
@@ -19,6 +24,9 @@ func (r *LocalPluginRuntime) Schedule() error {
 		return ErrRuntimeAlreadyStarted
 	}
 
+	// a runtime without sessions is idle since it's scheduled
+	r.touchSession()
+
 	// start schedule loop
 	routine.Submit(routinepkg.Labels{
 		routinepkg.RoutineLabelKeyModule: "local_runtime",
@@ -30,18 +38,44 @@ func (r *LocalPluginRuntime) Schedule() error {
 
 // Increase replicas
 func (r *LocalPluginRuntime) ScaleUp() {
-	atomic.AddInt32(&r.instanceNums, 1)
+	instanceNums := atomic.AddInt32(&r.instanceNums, 1)
 	r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
-		notifier.OnInstanceScaleUp(r.instanceNums)
+		notifier.OnInstanceScaleUp(instanceNums)
 	})
 }
 
 // Decrease replicas
 func (r *LocalPluginRuntime) ScaleDown() {
-	atomic.AddInt32(&r.instanceNums, -1)
+	instanceNums := atomic.AddInt32(&r.instanceNums, -1)
+	r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
+		notifier.OnInstanceScaleDown(instanceNums)
+	})
+}
+
+// SetReplicas sets the expected nums of instances, the schedule loop starts or stops instances to match it
+func (r *LocalPluginRuntime) SetReplicas(replicas int32) error {
+	if replicas < 1 {
+		return errors.Join(ErrInvalidReplicas, fmt.Errorf("replicas %d, at least 1", replicas))
+	}
+	if r.autoscaler != nil {
+		return ErrReplicasManagedByAutoscaler
+	}
+
+	previous := atomic.SwapInt32(&r.instanceNums, replicas)
 	r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
-		notifier.OnInstanceScaleDown(r.instanceNums)
+		if replicas > previous {
+			notifier.OnInstanceScaleUp(replicas)
+		} else if replicas < previous {
+			notifier.OnInstanceScaleDown(replicas)
+		}
 	})
+
+	return nil
+}
+
+// Replicas returns the expected nums of instances
+func (r *LocalPluginRuntime) Replicas() int32 {
+	return atomic.LoadInt32(&r.instanceNums)
 }
 
 func (r *LocalPluginRuntime) scheduleLoop() {
@@ -49,26 +83,35 @@ func (r *LocalPluginRuntime) scheduleLoop() {
 	ticker := time.NewTicker(ScheduleLoopInterval)
 
 	for atomic.LoadInt32(&r.scheduleStatus) == ScheduleStatusRunning {
+		// adjust the expected instance nums according to the load
+		r.autoscale()
+
 		// check if the instance nums is match
 		r.instanceLocker.RLock()
 		currentInstanceNums := len(r.instances)
 		r.instanceLocker.RUnlock()
 
 		// if the current instance nums is less than the expected instance nums, start a new instance
-		if currentInstanceNums < int(r.instanceNums) {
-			// start a new instance
-			if err := r.startNewInstance(); err != nil {
-				// notify callers that a new instance failed to start
-				r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
-					notifier.OnInstanceLaunchFailed(nil, err)
-				})
-			} else {
-				// notify callers that a new instance started
-				r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
-					notifier.OnInstanceStarting()
-				})
+		// a warm instance is promoted if there is one, no need to wait for it to start
+		// failed instances are replaced only when the restart policy allows
+		if currentInstanceNums < int(atomic.LoadInt32(&r.instanceNums)) {
+			if !r.promoteWarmInstance() && r.allowInstanceStart() {
+				// start a new instance
+				if err := r.startNewInstance(); err != nil {
+					r.recordInstanceFailure(0)
+					r.recordLastError(err)
+					// notify callers that a new instance failed to start
+					r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
+						notifier.OnInstanceLaunchFailed(nil, err)
+					})
+				} else {
+					// notify callers that a new instance started
+					r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
+						notifier.OnInstanceStarting()
+					})
+				}
 			}
-		} else if currentInstanceNums > int(r.instanceNums) {
+		} else if currentInstanceNums > int(atomic.LoadInt32(&r.instanceNums)) {
 			// gracefully shutdown the instance
 			if err := r.gracefullyStopLowestLoadInstance(); err != nil {
 				// notify callers that failed to gracefully stop a instance
@@ -76,6 +119,9 @@ func (r *LocalPluginRuntime) scheduleLoop() {
 					notifier.OnInstanceScaleDownFailed(err)
 				})
 			}
+		} else {
+			// launch spare instances only when the runtime is stable
+			r.refillWarmPool()
 		}
 
 		// wait for the next tick
@@ -85,6 +131,9 @@ func (r *LocalPluginRuntime) scheduleLoop() {
 
 	ticker.Stop()
 
+	// spare instances are not serving any session
+	r.stopWarmInstances()
+
 	// notify callers that the runtime is not running anymore
 	r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
 		notifier.OnRuntimeStopSchedule()
@@ -161,7 +210,6 @@ func (r *LocalPluginRuntime) forcefullyShutdownAllInstances() {
 		}
 		instance := instances[0]
 		instance.Stop()
-		r.instanceLocker.RUnlock()
 
 		// sleep for 1 second to avoid busy waiting
 		time.Sleep(time.Second * 1)
@@ -172,18 +220,78 @@ func (r *LocalPluginRuntime) forcefullyShutdownAllInstances() {
 // please make sure to call this method after stop schedule loop
 // otherwise new instances are going to start
 func (r *LocalPluginRuntime) stopAndWaitForAllInstancesToBeShutdown() {
+	r.drainInstances(time.Duration(r.appConfig.PluginMaxExecutionTimeout)*time.Second, nil)
+	r.waitForAllInstancesToBeShutdown()
+}
+
+// Drain stops the runtime without interrupting in-flight sessions
+// instances are stopped once their sessions are finished, the rest are killed after `timeout`
+// `onProgress` is called with the number of in-flight sessions until all instances are stopped
+//
+// NOTE: the runtime still accepts sessions, callers should dispatch new sessions to other runtimes
+func (r *LocalPluginRuntime) Drain(timeout time.Duration, onProgress func(inFlightSessions int)) {
+	// inherit from PluginRuntime
+	r.PluginRuntime.Stop()
+
+	// stop schedule loop
+	r.stopSchedule()
+
+	r.drainInstances(timeout, onProgress)
+}
+
+// InFlightSessions returns the number of sessions which are still being handled by all instances
+func (r *LocalPluginRuntime) InFlightSessions() int {
+	r.instanceLocker.RLock()
+	defer r.instanceLocker.RUnlock()
+
+	sessions := 0
+	for _, instance := range r.instances {
+		sessions += instance.InFlightSessions()
+	}
+	return sessions
+}
+
+// drainInstances gracefully stops all instances concurrently and waits until all of them are stopped
+func (r *LocalPluginRuntime) drainInstances(timeout time.Duration, onProgress func(inFlightSessions int)) {
+	r.instanceLocker.RLock()
+	instances := slices.Clone(r.instances)
+	r.instanceLocker.RUnlock()
+
+	wg := sync.WaitGroup{}
+	for _, instance := range instances {
+		wg.Add(1)
+		routine.Submit(routinepkg.Labels{
+			routinepkg.RoutineLabelKeyModule: "local_runtime",
+			routinepkg.RoutineLabelKeyMethod: "drainInstances",
+		}, func() {
+			defer wg.Done()
+			instance.GracefulStop(timeout)
+		})
+	}
+
+	done := make(chan bool)
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(DrainProgressInterval)
+	defer ticker.Stop()
+
 	for {
-		r.instanceLocker.RLock()
-		instances := r.instances
-		r.instanceLocker.RUnlock()
-		if len(instances) == 0 {
-			break
+		if onProgress != nil {
+			sessions := 0
+			for _, instance := range instances {
+				sessions += instance.InFlightSessions()
+			}
+			onProgress(sessions)
 		}
-		instance := instances[0]
-		instance.GracefulStop(time.Duration(r.appConfig.PluginMaxExecutionTimeout) * time.Second)
 
-		// sleep for 1 second to avoid busy waiting
-		time.Sleep(time.Second * 1)
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
 	}
 }
 
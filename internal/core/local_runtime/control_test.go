@@ -0,0 +1,81 @@
+package local_runtime
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/routine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrain(t *testing.T) {
+	routine.InitPool(1024)
+
+	idle := newProbedInstance(func(*PluginInstance, string) {})
+	busy := newProbedInstance(func(*PluginInstance, string) {})
+	// a session which never finishes
+	busy.setupStdioEventListener("session", func([]byte) {})
+
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding, idle, busy)
+	assert.Equal(t, 1, runtime.InFlightSessions())
+
+	progressLock := sync.Mutex{}
+	progress := []int{}
+
+	done := make(chan bool)
+	go func() {
+		runtime.Drain(100*time.Millisecond, func(inFlightSessions int) {
+			progressLock.Lock()
+			progress = append(progress, inFlightSessions)
+			progressLock.Unlock()
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "runtime was not drained before the deadline")
+		return
+	}
+
+	// all instances are stopped, the busy one is killed after the deadline
+	assert.True(t, idle.stopRequested.Load())
+	assert.True(t, busy.stopRequested.Load())
+
+	progressLock.Lock()
+	defer progressLock.Unlock()
+	if assert.NotEmpty(t, progress) {
+		assert.Equal(t, 1, progress[0])
+	}
+}
+
+func TestSetReplicas(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding)
+	runtime.instanceNums = 1
+	runtime.notifierLock = &sync.Mutex{}
+
+	scaled := []int32{}
+	runtime.AddNotifier(&PluginRuntimeNotifierTemplate{
+		OnInstanceScaleUpImpl: func(replicas int32) {
+			scaled = append(scaled, replicas)
+		},
+		OnInstanceScaleDownImpl: func(replicas int32) {
+			scaled = append(scaled, -replicas)
+		},
+	})
+
+	assert.NoError(t, runtime.SetReplicas(3))
+	assert.NoError(t, runtime.SetReplicas(3))
+	assert.NoError(t, runtime.SetReplicas(2))
+	assert.Equal(t, int32(2), runtime.Replicas())
+	assert.Equal(t, []int32{3, -2}, scaled)
+
+	assert.ErrorIs(t, runtime.SetReplicas(0), ErrInvalidReplicas)
+
+	// the autoscaler owns replicas once it's enabled
+	runtime.autoscaler = newAutoscaler(AutoscalerConfig{MinReplicas: 1, MaxReplicas: 4})
+	assert.ErrorIs(t, runtime.SetReplicas(3), ErrReplicasManagedByAutoscaler)
+	assert.Equal(t, int32(2), runtime.Replicas())
+}
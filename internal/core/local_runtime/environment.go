@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities/constants"
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
@@ -21,6 +22,10 @@ func (r *LocalPluginRuntime) InitEnvironment(decoder *decoder.ZipPluginDecoder)
 	switch r.Config.Meta.Runner.Language {
 	case constants.Python:
 		err = r.InitPythonEnvironment()
+	case constants.Go:
+		err = r.InitGoEnvironment()
+	case constants.NodeJS:
+		err = r.InitNodeEnvironment()
 	default:
 		return fmt.Errorf("unsupported language: %s", r.Config.Meta.Runner.Language)
 	}
@@ -68,17 +73,33 @@ func (r *LocalPluginRuntime) extractPluginToWorkingPath(decoder *decoder.ZipPlug
 
 // return nil if environment is valid, otherwise return error
 func (r *LocalPluginRuntime) EnvironmentValidation() error {
-	if r.Config.Meta.Runner.Language == constants.Python {
+	switch r.Config.Meta.Runner.Language {
+	case constants.Python:
 		_, err := r.checkPythonVirtualEnvironment()
 		if err != nil {
 			return err
 		}
 		return nil
+	case constants.Go:
+		return r.checkGoBinary()
+	case constants.NodeJS:
+		return r.checkNodeEnvironment()
 	}
 
 	return fmt.Errorf("unsupported language: %s", r.Config.Meta.Runner.Language)
 }
 
+// EnvironmentInitTimeout returns how long the environment initialization of the plugin may take
+func (r *LocalPluginRuntime) EnvironmentInitTimeout() time.Duration {
+	switch r.Config.Meta.Runner.Language {
+	case constants.Go:
+		return time.Duration(r.appConfig.GoBuildTimeout) * time.Second
+	case constants.NodeJS:
+		return time.Duration(r.appConfig.NodeEnvInitTimeout) * time.Second
+	}
+	return time.Duration(r.appConfig.PythonEnvInitTimeout) * time.Second
+}
+
 func (r *LocalPluginRuntime) Identity() (plugin_entities.PluginUniqueIdentifier, error) {
 	checksum, err := r.Checksum()
 	if err != nil {
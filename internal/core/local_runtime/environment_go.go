@@ -0,0 +1,143 @@
+package local_runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrGoBinaryNotFound = errors.New("go binary not found")
+	ErrGoBinaryOutdated = errors.New("go binary is outdated")
+)
+
+const (
+	// everything produced by the go toolchain lives here, it's removed together with the working path
+	goBuildPath = ".dify/go"
+	// compiled entrypoint of the plugin
+	goBinaryPath = goBuildPath + "/bin/plugin"
+	// checksum of the plugin which the binary was built from
+	goBinaryChecksumFile = goBuildPath + "/bin/checksum"
+)
+
+// InitGoEnvironment builds the entrypoint of the plugin into a binary
+// the binary is cached by the checksum of the plugin, it's built only once
+func (r *LocalPluginRuntime) InitGoEnvironment() error {
+	err := r.checkGoBinary()
+	switch err {
+	case nil:
+		return nil
+	case ErrGoBinaryNotFound, ErrGoBinaryOutdated:
+		// build it
+	default:
+		return fmt.Errorf("failed to check go binary: %w", err)
+	}
+
+	if err := r.buildGoBinary(); err != nil {
+		return fmt.Errorf("failed to build go plugin: %w", err)
+	}
+
+	return nil
+}
+
+func (r *LocalPluginRuntime) getGoBinaryPath() (string, error) {
+	return filepath.Abs(path.Join(r.State.WorkingPath, goBinaryPath))
+}
+
+// checkGoBinary checks if the binary exists and was built from the current plugin
+func (r *LocalPluginRuntime) checkGoBinary() error {
+	if _, err := os.Stat(path.Join(r.State.WorkingPath, goBinaryPath)); err != nil {
+		return ErrGoBinaryNotFound
+	}
+
+	checksum, err := r.Checksum()
+	if err != nil {
+		return err
+	}
+
+	builtFrom, err := os.ReadFile(path.Join(r.State.WorkingPath, goBinaryChecksumFile))
+	if err != nil || strings.TrimSpace(string(builtFrom)) != checksum {
+		return ErrGoBinaryOutdated
+	}
+
+	return nil
+}
+
+func (r *LocalPluginRuntime) buildGoBinary() error {
+	goPath, err := exec.LookPath(r.appConfig.GoBinaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to find go toolchain: %w", err)
+	}
+
+	buildPath, err := filepath.Abs(path.Join(r.State.WorkingPath, goBuildPath))
+	if err != nil {
+		return err
+	}
+
+	binaryPath, err := r.getGoBinaryPath()
+	if err != nil {
+		return err
+	}
+
+	// remove the outdated binary and its checksum
+	os.RemoveAll(filepath.Dir(binaryPath))
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(),
+		time.Duration(r.appConfig.GoBuildTimeout)*time.Second,
+	)
+	defer cancel()
+
+	// `-modcacherw` keeps the module cache removable, it's removed with the working path
+	cmd := exec.CommandContext(
+		ctx, goPath, "build", "-modcacherw", "-trimpath",
+		"-o", binaryPath, r.Config.Meta.Runner.Entrypoint,
+	)
+	cmd.Dir = r.State.WorkingPath
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + buildPath,
+		"GOPATH=" + path.Join(buildPath, "path"),
+		"GOCACHE=" + path.Join(buildPath, "cache"),
+		"GOMODCACHE=" + path.Join(buildPath, "path", "pkg", "mod"),
+		"CGO_ENABLED=0",
+	}
+	if r.appConfig.GoProxy != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("GOPROXY=%s", r.appConfig.GoProxy))
+	}
+	if r.appConfig.HttpProxy != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("HTTP_PROXY=%s", r.appConfig.HttpProxy))
+	}
+	if r.appConfig.HttpsProxy != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("HTTPS_PROXY=%s", r.appConfig.HttpsProxy))
+	}
+	if r.appConfig.NoProxy != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("NO_PROXY=%s", r.appConfig.NoProxy))
+	}
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("build timed out after %d seconds, output: %s", r.appConfig.GoBuildTimeout, output)
+	}
+	if err != nil {
+		return fmt.Errorf("%w, output: %s", err, output)
+	}
+
+	checksum, err := r.Checksum()
+	if err != nil {
+		return err
+	}
+
+	// mark the binary as built from the current plugin once everything goes well
+	if err := os.WriteFile(path.Join(r.State.WorkingPath, goBinaryChecksumFile), []byte(checksum), 0644); err != nil {
+		return fmt.Errorf("failed to write checksum of go binary: %w", err)
+	}
+
+	return nil
+}
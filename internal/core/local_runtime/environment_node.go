@@ -0,0 +1,178 @@
+package local_runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+var (
+	ErrNodeEnvironmentNotFound = errors.New("node environment not found")
+	ErrNodeEnvironmentInvalid  = errors.New("node environment is invalid")
+	ErrNodeLockfileNotFound    = errors.New("package-lock.json or npm-shrinkwrap.json is required to install dependencies")
+)
+
+const (
+	nodeModulesPath       = "node_modules"
+	nodeEnvValidFlagFile  = nodeModulesPath + "/.dify/plugin.json"
+	nodePackageJsonFile   = "package.json"
+	nodePackageLockFile   = "package-lock.json"
+	nodeShrinkwrapFile    = "npm-shrinkwrap.json"
+	nodeBuildScriptName   = "build"
+	nodeTempDirectoryPath = ".dify/node"
+)
+
+// InitNodeEnvironment installs dependencies from the lockfile and builds the plugin if a build script exists
+func (r *LocalPluginRuntime) InitNodeEnvironment() error {
+	err := r.checkNodeEnvironment()
+	switch err {
+	case nil:
+		return nil
+	case ErrNodeEnvironmentInvalid, ErrNodeEnvironmentNotFound:
+		// remove the partially installed dependencies and reinstall them
+		os.RemoveAll(path.Join(r.State.WorkingPath, nodeModulesPath))
+	default:
+		return fmt.Errorf("failed to check node environment: %w", err)
+	}
+
+	if err := r.installNodeDependencies(); err != nil {
+		return fmt.Errorf("failed to install dependencies: %w", err)
+	}
+
+	hasBuildScript, err := r.hasNodeBuildScript()
+	if err != nil {
+		return err
+	}
+	if hasBuildScript {
+		if err := r.runNpm("run", nodeBuildScriptName); err != nil {
+			return fmt.Errorf("failed to build the plugin: %w", err)
+		}
+	}
+
+	// mark the environment as valid if everything goes well
+	if err := r.markEnvironmentAsValid(nodeEnvValidFlagFile); err != nil {
+		return fmt.Errorf("failed to mark the node environment as valid: %w", err)
+	}
+
+	return nil
+}
+
+// checkNodeEnvironment checks if dependencies were installed completely
+func (r *LocalPluginRuntime) checkNodeEnvironment() error {
+	if _, err := os.Stat(path.Join(r.State.WorkingPath, nodeModulesPath)); err != nil {
+		return ErrNodeEnvironmentNotFound
+	}
+
+	if _, err := os.Stat(path.Join(r.State.WorkingPath, nodeEnvValidFlagFile)); err != nil {
+		return ErrNodeEnvironmentInvalid
+	}
+
+	return nil
+}
+
+func (r *LocalPluginRuntime) installNodeDependencies() error {
+	// `npm ci` installs exactly what the lockfile describes and never updates it
+	lockfileFound := false
+	for _, lockfile := range []string{nodePackageLockFile, nodeShrinkwrapFile} {
+		if _, err := os.Stat(path.Join(r.State.WorkingPath, lockfile)); err == nil {
+			lockfileFound = true
+			break
+		}
+	}
+	if !lockfileFound {
+		return ErrNodeLockfileNotFound
+	}
+
+	args := []string{"ci", "--no-audit", "--no-fund"}
+	if r.appConfig.NpmRegistry != "" {
+		args = append(args, "--registry", r.appConfig.NpmRegistry)
+	}
+
+	return r.runNpm(args...)
+}
+
+func (r *LocalPluginRuntime) hasNodeBuildScript() (bool, error) {
+	content, err := os.ReadFile(path.Join(r.State.WorkingPath, nodePackageJsonFile))
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", nodePackageJsonFile, err)
+	}
+
+	var packageJson struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(content, &packageJson); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", nodePackageJsonFile, err)
+	}
+
+	_, ok := packageJson.Scripts[nodeBuildScriptName]
+	return ok, nil
+}
+
+func (r *LocalPluginRuntime) runNpm(args ...string) error {
+	npmPath, err := exec.LookPath(r.appConfig.NpmBinaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to find npm: %w", err)
+	}
+
+	tempPath, err := filepath.Abs(path.Join(r.State.WorkingPath, nodeTempDirectoryPath))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(),
+		time.Duration(r.appConfig.NodeEnvInitTimeout)*time.Second,
+	)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, npmPath, args...)
+	cmd.Dir = r.State.WorkingPath
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		// keep npm cache and config inside the working path, they're removed together
+		"HOME=" + tempPath,
+		"npm_config_cache=" + path.Join(tempPath, "cache"),
+		"npm_config_update_notifier=false",
+	}
+	if r.appConfig.HttpProxy != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("HTTP_PROXY=%s", r.appConfig.HttpProxy))
+	}
+	if r.appConfig.HttpsProxy != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("HTTPS_PROXY=%s", r.appConfig.HttpsProxy))
+	}
+	if r.appConfig.NoProxy != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("NO_PROXY=%s", r.appConfig.NoProxy))
+	}
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf(
+			"npm %s timed out after %d seconds, output: %s",
+			args[0], r.appConfig.NodeEnvInitTimeout, output,
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("npm %s failed: %w, output: %s", args[0], err, output)
+	}
+
+	return nil
+}
+
+func (r *LocalPluginRuntime) getNodeEntrypointPath() (string, error) {
+	entrypoint, err := filepath.Abs(path.Join(r.State.WorkingPath, r.Config.Meta.Runner.Entrypoint))
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(entrypoint); err != nil {
+		return "", fmt.Errorf("failed to find entrypoint %s: %w", r.Config.Meta.Runner.Entrypoint, err)
+	}
+
+	return entrypoint, nil
+}
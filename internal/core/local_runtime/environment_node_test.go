@@ -0,0 +1,46 @@
+package local_runtime
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestNodeRuntime(t *testing.T, packageJson string) *LocalPluginRuntime {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding)
+	runtime.appConfig = &app.Config{NpmBinaryPath: "npm", NodeEnvInitTimeout: 10}
+	runtime.State.WorkingPath = t.TempDir()
+	assert.NoError(t, os.WriteFile(path.Join(runtime.State.WorkingPath, nodePackageJsonFile), []byte(packageJson), 0644))
+	return runtime
+}
+
+func TestNodeBuildScript(t *testing.T) {
+	runtime := newTestNodeRuntime(t, `{"scripts": {"build": "tsc"}}`)
+	hasBuildScript, err := runtime.hasNodeBuildScript()
+	assert.NoError(t, err)
+	assert.True(t, hasBuildScript)
+
+	runtime = newTestNodeRuntime(t, `{"scripts": {"start": "node index.js"}}`)
+	hasBuildScript, err = runtime.hasNodeBuildScript()
+	assert.NoError(t, err)
+	assert.False(t, hasBuildScript)
+}
+
+func TestNodeDependenciesRequireLockfile(t *testing.T) {
+	runtime := newTestNodeRuntime(t, `{}`)
+	assert.ErrorIs(t, runtime.installNodeDependencies(), ErrNodeLockfileNotFound)
+}
+
+func TestCheckNodeEnvironment(t *testing.T) {
+	runtime := newTestNodeRuntime(t, `{}`)
+	assert.ErrorIs(t, runtime.checkNodeEnvironment(), ErrNodeEnvironmentNotFound)
+
+	assert.NoError(t, os.MkdirAll(path.Join(runtime.State.WorkingPath, nodeModulesPath), 0755))
+	assert.ErrorIs(t, runtime.checkNodeEnvironment(), ErrNodeEnvironmentInvalid)
+
+	assert.NoError(t, runtime.markEnvironmentAsValid(nodeEnvValidFlagFile))
+	assert.NoError(t, runtime.checkNodeEnvironment())
+}
@@ -3,6 +3,7 @@ package local_runtime
 import (
 	_ "embed"
 	"fmt"
+	"path"
 
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
 )
@@ -14,6 +15,11 @@ func (p *LocalPluginRuntime) InitPythonEnvironment() error {
 		return fmt.Errorf("failed to find uv path: %w", err)
 	}
 
+	// share the environment with other plugins which have the same dependencies
+	if p.pythonEnvironmentCache != nil {
+		return p.initSharedPythonEnvironment(uvPath)
+	}
+
 	// check if virtual environment exists
 	venv, err := p.checkPythonVirtualEnvironment()
 	switch err {
@@ -34,9 +40,8 @@ func (p *LocalPluginRuntime) InitPythonEnvironment() error {
 		}
 	case nil:
 		// PATCH:
-		//  plugin sdk version less than 0.0.1b70 contains a memory leak bug
-		//  to reach a better user experience, we will patch it here using a patched file
-		// https://github.com/langgenius/dify-plugin-sdks/commit/161045b65f708d8ef0837da24440ab3872821b3b
+		//  fix known bugs of old plugin sdk versions, patches are declared in patches/manifest.yaml
+		//  patches are applied idempotently, it's safe to apply them on every launch
 		if err := p.patchPluginSdk(
 			p.getRequirementsPath(),
 			venv.pythonInterpreterPath,
@@ -51,7 +56,7 @@ func (p *LocalPluginRuntime) InitPythonEnvironment() error {
 	}
 
 	// install dependencies
-	if err := p.installDependencies(uvPath); err != nil {
+	if err := p.installDependencies(uvPath, path.Join(p.State.WorkingPath, envPath)); err != nil {
 		return fmt.Errorf("failed to install dependencies: %w", err)
 	}
 
@@ -61,9 +66,7 @@ func (p *LocalPluginRuntime) InitPythonEnvironment() error {
 	}
 
 	// PATCH:
-	//  plugin sdk version less than 0.0.1b70 contains a memory leak bug
-	//  to reach a better user experience, we will patch it here using a patched file
-	// https://github.com/langgenius/dify-plugin-sdks/commit/161045b65f708d8ef0837da24440ab3872821b3b
+	//  fix known bugs of old plugin sdk versions, patches are declared in patches/manifest.yaml
 	if err := p.patchPluginSdk(
 		p.getRequirementsPath(),
 		venv.pythonInterpreterPath,
@@ -0,0 +1,182 @@
+package local_runtime
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/framing"
+)
+
+// EnvironmentVariableSource tells where an environment variable of a plugin instance comes from
+type EnvironmentVariableSource string
+
+const (
+	// inherited from the daemon, only names in the allowlist are inherited
+	EnvironmentVariableSourceHost EnvironmentVariableSource = "host"
+	// set by the daemon, e.g. proxies and install method
+	EnvironmentVariableSourceDaemon EnvironmentVariableSource = "daemon"
+	// defined by operators for all versions of a plugin
+	EnvironmentVariableSourcePlugin EnvironmentVariableSource = "plugin"
+	// defined by operators for a specific installed package of a plugin
+	EnvironmentVariableSourceInstallation EnvironmentVariableSource = "installation"
+)
+
+const (
+	redactedEnvironmentValue = "[REDACTED]"
+)
+
+// variables a plugin needs to run properly, they never contain secrets of the daemon
+// operators are able to extend it by `PLUGIN_ENV_ALLOWLIST`
+var baseEnvironmentAllowlist = []string{
+	"PATH",
+	"HOME",
+	"USER",
+	"LANG",
+	"LANGUAGE",
+	"LC_ALL",
+	"LC_CTYPE",
+	"TZ",
+	"TMPDIR",
+	"SSL_CERT_FILE",
+	"SSL_CERT_DIR",
+	"REQUESTS_CA_BUNDLE",
+}
+
+// variables managed by the daemon, operators are not allowed to override them
+var reservedEnvironmentVariables = []string{
+	"INSTALL_METHOD",
+	stdioProtocolsEnvironmentVariable,
+}
+
+// framed protocols the daemon accepts on stdio, SDKs which support one of them negotiate it at startup
+const stdioProtocolsEnvironmentVariable = "DIFY_PLUGIN_STDIO_PROTOCOLS"
+
+var environmentVariableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type EnvironmentVariable struct {
+	Name   string                    `json:"name"`
+	Value  string                    `json:"value"`
+	Source EnvironmentVariableSource `json:"source"`
+}
+
+// ValidateExtraEnvironment checks variables defined by operators
+func ValidateExtraEnvironment(variables map[string]string) error {
+	for name := range variables {
+		if !environmentVariableNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name: %s", name)
+		}
+		if slices.Contains(reservedEnvironmentVariables, name) {
+			return fmt.Errorf("environment variable %s is reserved by the daemon", name)
+		}
+	}
+	return nil
+}
+
+// SetExtraEnvironment sets variables defined by operators, it only takes effect on new instances
+// installation variables take precedence over plugin variables
+func (r *LocalPluginRuntime) SetExtraEnvironment(plugin map[string]string, installation map[string]string) {
+	r.extraEnvironmentLock.Lock()
+	defer r.extraEnvironmentLock.Unlock()
+
+	r.pluginEnvironment = plugin
+	r.installationEnvironment = installation
+}
+
+// buildInstanceEnvironment builds the environment of a new instance
+// nothing is inherited from the daemon except the allowlist, to avoid leaking secrets like `DB_PASSWORD`
+func (r *LocalPluginRuntime) buildInstanceEnvironment() []EnvironmentVariable {
+	variables := []EnvironmentVariable{}
+	set := func(name string, value string, source EnvironmentVariableSource) {
+		// the latter one overrides the former one
+		variables = slices.DeleteFunc(variables, func(v EnvironmentVariable) bool {
+			return v.Name == name
+		})
+		variables = append(variables, EnvironmentVariable{Name: name, Value: value, Source: source})
+	}
+
+	for _, name := range slices.Concat(baseEnvironmentAllowlist, r.appConfig.PluginEnvAllowlist) {
+		if value, ok := os.LookupEnv(name); ok {
+			set(name, value, EnvironmentVariableSourceHost)
+		}
+	}
+
+	if r.appConfig.HttpsProxy != "" {
+		set("HTTPS_PROXY", r.appConfig.HttpsProxy, EnvironmentVariableSourceDaemon)
+	}
+	if r.appConfig.HttpProxy != "" {
+		set("HTTP_PROXY", r.appConfig.HttpProxy, EnvironmentVariableSourceDaemon)
+	}
+	if r.appConfig.NoProxy != "" {
+		set("NO_PROXY", r.appConfig.NoProxy, EnvironmentVariableSourceDaemon)
+	}
+
+	r.extraEnvironmentLock.RLock()
+	for _, name := range sortedKeys(r.pluginEnvironment) {
+		set(name, r.pluginEnvironment[name], EnvironmentVariableSourcePlugin)
+	}
+	for _, name := range sortedKeys(r.installationEnvironment) {
+		set(name, r.installationEnvironment[name], EnvironmentVariableSourceInstallation)
+	}
+	r.extraEnvironmentLock.RUnlock()
+
+	set("INSTALL_METHOD", "local", EnvironmentVariableSourceDaemon)
+	if r.appConfig.PluginRuntimeFramingEnabled {
+		set(stdioProtocolsEnvironmentVariable, framing.AdvertisedProtocols(), EnvironmentVariableSourceDaemon)
+	}
+
+	return variables
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func environmentToList(variables []EnvironmentVariable) []string {
+	env := make([]string, 0, len(variables))
+	for _, v := range variables {
+		env = append(env, fmt.Sprintf("%s=%s", v.Name, v.Value))
+	}
+	return env
+}
+
+// redactEnvironment hides values of the variables, only names and sources are kept
+func redactEnvironment(variables []EnvironmentVariable) []EnvironmentVariable {
+	redacted := make([]EnvironmentVariable, 0, len(variables))
+	for _, v := range variables {
+		if v.Value != "" {
+			v.Value = redactedEnvironmentValue
+		}
+		redacted = append(redacted, v)
+	}
+	return redacted
+}
+
+// InstanceEnvironment is the effective environment of a running instance
+type InstanceEnvironment struct {
+	InstanceID  string                `json:"instance_id"`
+	Environment []EnvironmentVariable `json:"environment"`
+}
+
+// InstanceEnvironments returns the effective environment of all running instances
+// values are redacted, it's safe to be exposed to operators
+func (r *LocalPluginRuntime) InstanceEnvironments() []InstanceEnvironment {
+	r.instanceLocker.RLock()
+	defer r.instanceLocker.RUnlock()
+
+	environments := make([]InstanceEnvironment, 0, len(r.instances))
+	for _, instance := range r.instances {
+		environments = append(environments, InstanceEnvironment{
+			InstanceID:  instance.instanceId,
+			Environment: redactEnvironment(instance.environment),
+		})
+	}
+
+	return environments
+}
@@ -0,0 +1,55 @@
+package local_runtime
+
+import (
+	"testing"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildInstanceEnvironment(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("DB_PASSWORD", "secret")
+	t.Setenv("SERVER_KEY", "secret")
+	t.Setenv("EXTRA_ALLOWED", "yes")
+
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding)
+	runtime.appConfig = &app.Config{
+		PluginEnvAllowlist: []string{"EXTRA_ALLOWED"},
+		HttpProxy:          "http://proxy:7890",
+	}
+	runtime.SetExtraEnvironment(
+		map[string]string{"API_BASE": "plugin", "LOG_LEVEL": "info"},
+		map[string]string{"API_BASE": "installation"},
+	)
+
+	variables := map[string]EnvironmentVariable{}
+	for _, v := range runtime.buildInstanceEnvironment() {
+		variables[v.Name] = v
+	}
+
+	assert.NotContains(t, variables, "DB_PASSWORD")
+	assert.NotContains(t, variables, "SERVER_KEY")
+	assert.Equal(t, EnvironmentVariable{"PATH", "/usr/bin", EnvironmentVariableSourceHost}, variables["PATH"])
+	assert.Equal(t, "yes", variables["EXTRA_ALLOWED"].Value)
+	assert.Equal(t, EnvironmentVariableSourceDaemon, variables["HTTP_PROXY"].Source)
+	assert.Equal(t, EnvironmentVariable{"API_BASE", "installation", EnvironmentVariableSourceInstallation}, variables["API_BASE"])
+	assert.Equal(t, EnvironmentVariableSourcePlugin, variables["LOG_LEVEL"].Source)
+	assert.Equal(t, "local", variables["INSTALL_METHOD"].Value)
+}
+
+func TestValidateExtraEnvironment(t *testing.T) {
+	assert.Nil(t, ValidateExtraEnvironment(map[string]string{"API_KEY": "x", "_private": "y"}))
+	assert.NotNil(t, ValidateExtraEnvironment(map[string]string{"1INVALID": "x"}))
+	assert.NotNil(t, ValidateExtraEnvironment(map[string]string{"A=B": "x"}))
+	assert.NotNil(t, ValidateExtraEnvironment(map[string]string{"INSTALL_METHOD": "remote"}))
+}
+
+func TestRedactEnvironment(t *testing.T) {
+	redacted := redactEnvironment([]EnvironmentVariable{
+		{"API_KEY", "secret", EnvironmentVariableSourcePlugin},
+		{"EMPTY", "", EnvironmentVariableSourcePlugin},
+	})
+	assert.Equal(t, redactedEnvironmentValue, redacted[0].Value)
+	assert.Equal(t, "", redacted[1].Value)
+}
@@ -18,4 +18,10 @@ var (
 
 	// Runtime is not active
 	ErrRuntimeNotActive = errors.New("runtime is not active")
+
+	// Replicas must be at least 1
+	ErrInvalidReplicas = errors.New("invalid replicas")
+
+	// Replicas are adjusted by the autoscaler, they can't be set manually
+	ErrReplicasManagedByAutoscaler = errors.New("replicas are managed by the autoscaler")
 )
@@ -0,0 +1,26 @@
+package local_runtime
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// touchSession records that a session started or finished
+func (r *LocalPluginRuntime) touchSession() {
+	atomic.StoreInt64(&r.lastSessionAt, time.Now().UnixNano())
+}
+
+// IdleDuration returns how long the runtime has had no sessions, 0 if sessions are being handled
+// a runtime which never had sessions is idle since it's scheduled
+func (r *LocalPluginRuntime) IdleDuration() time.Duration {
+	if r.InFlightSessions() > 0 {
+		return 0
+	}
+
+	lastSessionAt := atomic.LoadInt64(&r.lastSessionAt)
+	if lastSessionAt == 0 {
+		return 0
+	}
+
+	return time.Since(time.Unix(0, lastSessionAt))
+}
@@ -0,0 +1,25 @@
+package local_runtime
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdleDuration(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyRoundRobin, newTestInstance("idle", 0))
+	// not scheduled yet
+	assert.Equal(t, time.Duration(0), runtime.IdleDuration())
+
+	atomic.StoreInt64(&runtime.lastSessionAt, time.Now().Add(-time.Minute).UnixNano())
+	assert.GreaterOrEqual(t, runtime.IdleDuration(), time.Minute)
+
+	runtime.touchSession()
+	assert.Less(t, runtime.IdleDuration(), time.Minute)
+
+	busy := newTestRuntime(LoadBalancingStrategyRoundRobin, newTestInstance("busy", 1))
+	atomic.StoreInt64(&busy.lastSessionAt, time.Now().Add(-time.Minute).UnixNano())
+	assert.Equal(t, time.Duration(0), busy.IdleDuration())
+}
@@ -1,17 +1,18 @@
 package local_runtime
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
 	"io"
 	"os/exec"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/framing"
 )
 
 const (
@@ -29,20 +30,42 @@ type PluginInstance struct {
 	errReader              io.ReadCloser
 	l                      *sync.Mutex
 	listener               map[string]func([]byte)
+	// reader of stdout, it holds the protocol negotiated by the plugin
+	stdout *framing.Reader
+	// pending liveness probes, closed once answered
+	probes map[string]chan bool
 
-	started  bool // mark the instance as started
-	shutdown bool // mark the instance as shutdown
+	started  bool        // mark the instance as started
+	shutdown atomic.Bool // mark the instance as shutdown
+
+	// the time the first heartbeat was received
+	readyAt time.Time
+	// marks the daemon asked the instance to stop, otherwise the exit is a failure
+	stopRequested atomic.Bool
 
 	// app config
 	appConfig *app.Config
 
-	// error message container
+	// error message container, the last bytes of stderr
 	errMessage              string
 	lastErrMessageUpdatedAt time.Time
+	errLock                 sync.Mutex
+
+	// file stderr is persisted to, nil if disabled
+	stderrLog     io.WriteCloser
+	stderrLogPath string
 
 	// the last time the plugin sent a heartbeat
 	lastActiveAt time.Time
 
+	// environment variables the instance was started with
+	environment []EnvironmentVariable
+
+	// cgroup which limits resources of the instance, nil if disabled
+	cgroup *instanceCgroup
+	// marks the instance was killed by the OOM killer
+	oomKilled atomic.Bool
+
 	// notifier
 	notifiers    []PluginInstanceNotifier
 	notifierLock *sync.Mutex
@@ -95,7 +118,22 @@ func (s *PluginInstance) removeStdioHandlerListener(session_id string) {
 	delete(s.listener, session_id)
 }
 
+// ID returns the id of the instance, it's unique across all instances of the plugin
+func (s *PluginInstance) ID() string {
+	return s.instanceId
+}
+
+// InFlightSessions returns the number of sessions which are still being handled by the instance
+func (s *PluginInstance) InFlightSessions() int {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return len(s.listener)
+}
+
 func (s *PluginInstance) Error() error {
+	s.errLock.Lock()
+	defer s.errLock.Unlock()
+
 	if time.Since(s.lastErrMessageUpdatedAt) < 60*time.Second {
 		if s.errMessage != "" {
 			return errors.New(s.errMessage)
@@ -105,8 +143,32 @@ func (s *PluginInstance) Error() error {
 	return nil
 }
 
+// stderrReport describes captured stderr for failure reports
+// unlike `Error()`, the tail never expires, it's followed by where the full stderr is
+func (s *PluginInstance) stderrReport() string {
+	s.errLock.Lock()
+	tail := s.errMessage
+	s.errLock.Unlock()
+
+	report := "no stderr captured"
+	if tail != "" {
+		report = fmt.Sprintf("last %d bytes of stderr: %s", len(tail), tail)
+	}
+	if s.stderrLogPath != "" {
+		report = fmt.Sprintf("%s, full stderr: %s", report, s.stderrLogPath)
+	}
+
+	return report
+}
+
 // Stop stops the stdio, of course, it will shutdown the plugin asynchronously
 func (s *PluginInstance) Stop() {
+	s.stopRequested.Store(true)
+	s.closeStdio()
+}
+
+// closeStdio shuts down the plugin without marking it as requested, used for dead instances
+func (s *PluginInstance) closeStdio() {
 	s.inWriter.Close()
 	s.outReader.Close()
 	s.errReader.Close()
@@ -124,16 +186,36 @@ func (s *PluginInstance) StartStdout() {
 
 	once := &sync.Once{}
 
-	scanner := bufio.NewScanner(s.outReader)
-	scanner.Buffer(
-		make([]byte, s.appConfig.GetLocalRuntimeBufferSize()),
-		s.appConfig.GetLocalRuntimeMaxBufferSize(),
-	)
+	// json lines by default, the plugin may negotiate a framed protocol with its first message
+	reader := framing.NewReader(s.outReader, framing.Options{
+		BufferSize:   s.appConfig.GetLocalRuntimeBufferSize(),
+		MaxLineSize:  s.appConfig.GetLocalRuntimeMaxBufferSize(),
+		MaxFrameSize: s.appConfig.PluginRuntimeMaxFrameSize,
+		Negotiable:   s.appConfig.PluginRuntimeFramingEnabled,
+	})
+	s.l.Lock()
+	s.stdout = reader
+	s.l.Unlock()
 
-	for scanner.Scan() {
+	for {
 		// read data, once s.outReader.Close was called
-		// scanner.Scan() breaks immediately
-		data := scanner.Bytes()
+		// reader.Read() returns an error immediately
+		data, err := reader.Read()
+		if err != nil {
+			if err != io.EOF {
+				s.WalkNotifiers(func(notifier PluginInstanceNotifier) {
+					notifier.OnInstanceErrorLog(
+						s,
+						fmt.Errorf(
+							"plugin %s has an error on stdout: %s",
+							s.pluginUniqueIdentifier,
+							err,
+						),
+					)
+				})
+			}
+			break
+		}
 
 		if len(data) == 0 {
 			continue
@@ -148,16 +230,12 @@ func (s *PluginInstance) StartStdout() {
 		})
 	}
 
-	if err := scanner.Err(); err != nil {
+	// check if the instance was killed due to exceeding the memory limit
+	// it must be done before shutdown notifiers, the cgroup is removed after that
+	if s.cgroup != nil && s.cgroup.oomKilled() {
+		s.oomKilled.Store(true)
 		s.WalkNotifiers(func(notifier PluginInstanceNotifier) {
-			notifier.OnInstanceErrorLog(
-				s,
-				fmt.Errorf(
-					"plugin %s has an error on stdout: %s",
-					s.pluginUniqueIdentifier,
-					err,
-				),
-			)
+			notifier.OnInstanceErrorLog(s, s.exitError())
 		})
 	}
 
@@ -178,9 +256,15 @@ func (s *PluginInstance) handleStdout(data []byte, once *sync.Once) {
 			// FIX: avoid deadlock to plugin invoke
 			s.l.Lock()
 			listener := s.listener[sessionId]
+			probe := s.probes[sessionId]
+			if probe != nil {
+				delete(s.probes, sessionId)
+			}
 			s.l.Unlock()
 			if listener != nil {
 				listener(data)
+			} else if probe != nil {
+				close(probe)
 			}
 		},
 		func() {
@@ -201,23 +285,38 @@ func (s *PluginInstance) handleStdout(data []byte, once *sync.Once) {
 				notifier.OnInstanceErrorLog(s, errors.New(err))
 			})
 		},
-		func(message string) {
-			// plain text log
+		func(sessionId string, event plugin_entities.PluginLogEvent) {
+			// structured log, keep level, time and the session it belongs to
+			entry := PluginLogEntry{
+				Level:      NormalizePluginLogLevel(event.Level),
+				Message:    event.Message,
+				Timestamp:  event.Time(time.Now()),
+				InstanceId: s.instanceId,
+				SessionId:  sessionId,
+			}
 			s.WalkNotifiers(func(notifier PluginInstanceNotifier) {
-				notifier.OnInstanceLog(s, message)
+				notifier.OnInstanceLog(s, entry)
 			})
 		},
 	)
 }
 
 // WriteError writes the error message to the stdio holder
-// it will keep the last 1024 bytes of the error message
+// it will keep the last `PluginStderrTailSize` bytes of the error message, 1024 by default
 func (s *PluginInstance) WriteError(msg string) {
-	if len(msg) > MAX_ERR_MSG_LEN {
-		msg = msg[:MAX_ERR_MSG_LEN]
+	maxLen := MAX_ERR_MSG_LEN
+	if s.appConfig != nil && s.appConfig.PluginStderrTailSize > 0 {
+		maxLen = s.appConfig.PluginStderrTailSize
+	}
+
+	s.errLock.Lock()
+	defer s.errLock.Unlock()
+
+	if len(msg) > maxLen {
+		msg = msg[len(msg)-maxLen:]
 	}
 
-	reduce := len(msg) + len(s.errMessage) - MAX_ERR_MSG_LEN
+	reduce := len(msg) + len(s.errMessage) - maxLen
 	if reduce > 0 {
 		if reduce > len(s.errMessage) {
 			s.errMessage = ""
@@ -231,20 +330,24 @@ func (s *PluginInstance) WriteError(msg string) {
 }
 
 // StartStderr starts to read the stderr of the plugin
-// it will write the error message to the stdio holder
+// it will write the error message to the stdio holder and the stderr file
 func (s *PluginInstance) StartStderr() {
+	if s.stderrLog != nil {
+		defer s.stderrLog.Close()
+	}
+
+	buf := make([]byte, 1024)
 	for {
-		buf := make([]byte, 1024)
 		n, err := s.errReader.Read(buf)
-		if err != nil && err != io.EOF {
-			break
-		} else if err != nil {
-			s.WriteError(fmt.Sprintf("%s\n", buf[:n]))
-			break
+		if n > 0 {
+			s.WriteError(string(buf[:n]))
+			if s.stderrLog != nil {
+				s.stderrLog.Write(buf[:n])
+			}
 		}
 
-		if n > 0 {
-			s.WriteError(fmt.Sprintf("%s\n", buf[:n]))
+		if err != nil {
+			break
 		}
 	}
 }
@@ -265,15 +368,15 @@ func (s *PluginInstance) Monitor() error {
 				notifier.OnInstanceLaunchFailed(
 					s,
 					fmt.Errorf(
-						"plugin %s is not active for %f seconds, it may be dead, captured error logs: %v",
+						"plugin %s is not active for %f seconds, it may be dead, %s",
 						s.pluginUniqueIdentifier,
 						time.Since(s.lastActiveAt).Seconds(),
-						s.Error(),
+						s.stderrReport(),
 					),
 				)
 			})
 			// dead instance detected, kill it
-			s.Stop()
+			s.closeStdio()
 			return ErrRuntimeNotActive
 		}
 		if time.Since(s.lastActiveAt) > MAX_HEARTBEAT_INTERVAL/2 {
@@ -300,6 +403,27 @@ func (s *PluginInstance) Write(data []byte) error {
 	return err
 }
 
+// Protocol returns the protocol negotiated on stdout, stdin uses the same one
+func (s *PluginInstance) Protocol() framing.Protocol {
+	s.l.Lock()
+	reader := s.stdout
+	s.l.Unlock()
+
+	if reader == nil {
+		return framing.PROTOCOL_JSON_LINES
+	}
+	return reader.Protocol()
+}
+
+// WriteMessage encodes a json message with the negotiated protocol and writes it into stdin
+func (s *PluginInstance) WriteMessage(message []byte) error {
+	data, err := s.Protocol().Encode(message)
+	if err != nil {
+		return err
+	}
+	return s.Write(data)
+}
+
 // GracefulStop stops the instance gracefully
 // wait for at most maxWaitTime to shutdown, forcefully kill it if timeout reached
 func (s *PluginInstance) GracefulStop(maxWaitTime time.Duration) {
@@ -0,0 +1,67 @@
+package local_runtime
+
+import (
+	"time"
+)
+
+// InstanceInventory describes a running instance of a local plugin
+type InstanceInventory struct {
+	InstanceID       string `json:"instance_id"`
+	PID              int    `json:"pid"`
+	InFlightSessions int    `json:"in_flight_sessions"`
+	// spare instances in the warm pool serve no session until they are promoted
+	Warm         bool       `json:"warm"`
+	ReadyAt      *time.Time `json:"ready_at"`
+	LastActiveAt time.Time  `json:"last_active_at"`
+}
+
+// RuntimeError is the last error reported by a runtime or its instances
+type RuntimeError struct {
+	Message    string    `json:"message"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func (s *PluginInstance) inventory(warm bool) InstanceInventory {
+	inventory := InstanceInventory{
+		InstanceID:       s.instanceId,
+		InFlightSessions: s.InFlightSessions(),
+		Warm:             warm,
+		LastActiveAt:     s.lastActiveAt,
+	}
+	if s.cmd != nil && s.cmd.Process != nil {
+		inventory.PID = s.cmd.Process.Pid
+	}
+	if !s.readyAt.IsZero() {
+		readyAt := s.readyAt
+		inventory.ReadyAt = &readyAt
+	}
+	return inventory
+}
+
+// Instances returns all instances of the runtime, including the warm pool
+func (r *LocalPluginRuntime) Instances() []InstanceInventory {
+	r.instanceLocker.RLock()
+	defer r.instanceLocker.RUnlock()
+
+	instances := make([]InstanceInventory, 0, len(r.instances)+len(r.warmInstances))
+	for _, instance := range r.instances {
+		instances = append(instances, instance.inventory(false))
+	}
+	for _, instance := range r.warmInstances {
+		instances = append(instances, instance.inventory(true))
+	}
+
+	return instances
+}
+
+// LastError returns the last error of the runtime, nil if nothing went wrong
+func (r *LocalPluginRuntime) LastError() *RuntimeError {
+	return r.lastError.Load()
+}
+
+func (r *LocalPluginRuntime) recordLastError(err error) {
+	r.lastError.Store(&RuntimeError{
+		Message:    err.Error(),
+		OccurredAt: time.Now(),
+	})
+}
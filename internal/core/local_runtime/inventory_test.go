@@ -0,0 +1,24 @@
+package local_runtime
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstances(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding, newTestInstance("busy", 2))
+	runtime.warmInstances = []*PluginInstance{newTestInstance("warm", 0)}
+
+	instances := runtime.Instances()
+	if !assert.Len(t, instances, 2) {
+		return
+	}
+	assert.Equal(t, InstanceInventory{InstanceID: "busy", InFlightSessions: 2}, instances[0])
+	assert.Equal(t, InstanceInventory{InstanceID: "warm", Warm: true}, instances[1])
+
+	assert.Nil(t, runtime.LastError())
+	runtime.recordLastError(errors.New("exit status 1"))
+	assert.Equal(t, "exit status 1", runtime.LastError().Message)
+}
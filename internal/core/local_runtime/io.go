@@ -1,6 +1,9 @@
 package local_runtime
 
 import (
+	"sync"
+	"time"
+
 	"github.com/langgenius/dify-plugin-daemon/internal/core/io_tunnel/access_types"
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities"
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
@@ -19,15 +22,26 @@ func (r *LocalPluginRuntime) Listen(sessionId string) (
 
 	// keep the mapping between sessionId and instance
 	r.sessionToInstanceMap.Store(sessionId, instance)
+	r.touchSession()
 
 	// setup listener to handle session message from plugin
 	listener := entities.NewCallbackHandler[plugin_entities.SessionMessage]()
 	listener.OnClose(func() {
 		instance.removeStdioHandlerListener(sessionId)
 		r.sessionToInstanceMap.Delete(sessionId)
+		r.touchSession()
 	})
 
+	// the time between the session being dispatched and the first response from the plugin
+	// is the first response latency, it's used by autoscaler
+	listenedAt := time.Now()
+	once := sync.Once{}
+
 	instance.setupStdioEventListener(sessionId, func(b []byte) {
+		once.Do(func() {
+			r.recordFirstResponse(time.Since(listenedAt))
+		})
+
 		data, err := parser.UnmarshalJsonBytes[plugin_entities.SessionMessage](b)
 		if err != nil {
 			log.Error("unmarshal json failed: %s, failed to parse session message", err.Error())
@@ -52,5 +66,5 @@ func (r *LocalPluginRuntime) Write(
 	}
 
 	// write to the instance
-	return instance.Write(append(data, '\n'))
+	return instance.WriteMessage(data)
 }
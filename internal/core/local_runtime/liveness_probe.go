@@ -0,0 +1,118 @@
+package local_runtime
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/io_tunnel/access_types"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/parser"
+)
+
+var (
+	ErrLivenessProbeTimeout = errors.New("liveness probe timed out")
+)
+
+type LivenessProbeConfig struct {
+	Interval time.Duration
+	Timeout  time.Duration
+	// probes failed in a row before the instance is recycled
+	FailureThreshold int
+}
+
+func newLivenessProbeConfig(appConfig *app.Config) LivenessProbeConfig {
+	return LivenessProbeConfig{
+		Interval:         time.Duration(appConfig.PluginLocalLivenessProbeInterval) * time.Second,
+		Timeout:          time.Duration(appConfig.PluginLocalLivenessProbeTimeout) * time.Second,
+		FailureThreshold: appConfig.PluginLocalLivenessProbeFailureThreshold,
+	}
+}
+
+// Ping sends a ping request through stdin and waits for the plugin to answer it
+// any session message with the same session id is an answer, it proves the request loop is alive
+func (s *PluginInstance) Ping(timeout time.Duration) error {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+	sessionId := id.String()
+
+	answered := make(chan bool)
+	s.l.Lock()
+	if s.probes == nil {
+		s.probes = map[string]chan bool{}
+	}
+	s.probes[sessionId] = answered
+	s.l.Unlock()
+
+	defer func() {
+		s.l.Lock()
+		delete(s.probes, sessionId)
+		s.l.Unlock()
+	}()
+
+	request := parser.MarshalJsonBytes(map[string]any{
+		"session_id": sessionId,
+		"event":      "request",
+		"data": map[string]any{
+			"type":   access_types.PLUGIN_ACCESS_TYPE_PING,
+			"action": access_types.PLUGIN_ACCESS_ACTION_PING,
+		},
+	})
+	if err := s.WriteMessage(request); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-answered:
+		return nil
+	case <-timer.C:
+		return ErrLivenessProbeTimeout
+	}
+}
+
+// probeLiveness pings the instance until it's shutdown
+// once `FailureThreshold` probes failed in a row, stdio is closed and the instance is
+// recycled by shutdown notifiers, the same as an instance without heartbeats
+func (s *PluginInstance) probeLiveness(config LivenessProbeConfig) {
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for range ticker.C {
+		if s.shutdown.Load() || s.stopRequested.Load() {
+			return
+		}
+
+		err := s.Ping(config.Timeout)
+		if err == nil {
+			failures = 0
+			continue
+		}
+
+		failures++
+		s.WalkNotifiers(func(notifier PluginInstanceNotifier) {
+			notifier.OnInstanceWarningLog(s, fmt.Sprintf(
+				"plugin %s: liveness probe failed (%d/%d): %s",
+				s.pluginUniqueIdentifier, failures, config.FailureThreshold, err.Error(),
+			))
+		})
+
+		if failures >= config.FailureThreshold {
+			s.WalkNotifiers(func(notifier PluginInstanceNotifier) {
+				notifier.OnInstanceErrorLog(s, fmt.Errorf(
+					"plugin %s failed %d liveness probes in a row, it may be stuck, %s",
+					s.pluginUniqueIdentifier, failures, s.stderrReport(),
+				))
+			})
+			// dead instance detected, kill it
+			s.closeStdio()
+			return
+		}
+	}
+}
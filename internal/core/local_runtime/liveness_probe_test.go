@@ -0,0 +1,76 @@
+package local_runtime
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+// newProbedInstance returns an instance whose stdin is handled by `plugin`
+func newProbedInstance(plugin func(instance *PluginInstance, sessionId string)) *PluginInstance {
+	inReader, inWriter := io.Pipe()
+	outReader, _ := io.Pipe()
+	errReader, _ := io.Pipe()
+	instance := newPluginInstance("langgenius/a:1.0.0", nil, inWriter, outReader, errReader, nil)
+
+	go func() {
+		scanner := bufio.NewScanner(inReader)
+		for scanner.Scan() {
+			request, err := parser.UnmarshalJsonBytes[map[string]any](scanner.Bytes())
+			if err != nil {
+				continue
+			}
+			plugin(instance, request["session_id"].(string))
+		}
+	}()
+
+	return instance
+}
+
+func answerPing(instance *PluginInstance, sessionId string) {
+	instance.handleStdout(parser.MarshalJsonBytes(map[string]any{
+		"event":      "session",
+		"session_id": sessionId,
+		"data":       map[string]any{"type": "end", "data": nil},
+	}), &sync.Once{})
+}
+
+func TestPing(t *testing.T) {
+	alive := newProbedInstance(answerPing)
+	assert.NoError(t, alive.Ping(time.Second))
+	// answered probes don't count as in-flight sessions
+	assert.Equal(t, 0, alive.InFlightSessions())
+
+	stuck := newProbedInstance(func(*PluginInstance, string) {})
+	assert.ErrorIs(t, stuck.Ping(50*time.Millisecond), ErrLivenessProbeTimeout)
+	assert.Empty(t, stuck.probes)
+}
+
+func TestProbeLivenessRecyclesStuckInstance(t *testing.T) {
+	stuck := newProbedInstance(func(*PluginInstance, string) {})
+
+	done := make(chan bool)
+	go func() {
+		stuck.probeLiveness(LivenessProbeConfig{
+			Interval:         10 * time.Millisecond,
+			Timeout:          10 * time.Millisecond,
+			FailureThreshold: 2,
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "stuck instance was not recycled")
+		return
+	}
+
+	// stdio is closed
+	assert.Error(t, stuck.Write([]byte("\n")))
+}
@@ -1,9 +1,56 @@
 package local_runtime
 
-import "sync/atomic"
+import (
+	"math/rand/v2"
+	"sync/atomic"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+)
+
+// LoadBalancingStrategy decides which instance a new session is dispatched to
+type LoadBalancingStrategy string
+
+const (
+	// pick instances one by one, ignore the load of each instance
+	LoadBalancingStrategyRoundRobin LoadBalancingStrategy = "round_robin"
+
+	// pick the instance with the least in-flight sessions
+	LoadBalancingStrategyLeastOutstanding LoadBalancingStrategy = "least_outstanding"
+
+	// randomly sample two instances and pick the less loaded one
+	// it avoids herding on a single instance when many sessions arrive at the same time
+	LoadBalancingStrategyPowerOfTwoChoices LoadBalancingStrategy = "power_of_two_choices"
+)
+
+// resolveLoadBalancingStrategy returns the strategy for the plugin
+// per plugin overrides take precedence over the global strategy
+func resolveLoadBalancingStrategy(
+	globalStrategy string,
+	overrides map[string]string,
+	pluginID string,
+) LoadBalancingStrategy {
+	strategy := globalStrategy
+	if override, ok := overrides[pluginID]; ok {
+		strategy = override
+	}
+
+	switch LoadBalancingStrategy(strategy) {
+	case LoadBalancingStrategyRoundRobin,
+		LoadBalancingStrategyLeastOutstanding,
+		LoadBalancingStrategyPowerOfTwoChoices:
+		return LoadBalancingStrategy(strategy)
+	case "":
+		return LoadBalancingStrategyLeastOutstanding
+	default:
+		log.Warn(
+			"unknown load balancing strategy %s for plugin %s, fallback to %s",
+			strategy, pluginID, LoadBalancingStrategyLeastOutstanding,
+		)
+		return LoadBalancingStrategyLeastOutstanding
+	}
+}
 
 // load balancing is a mechanism to distribute the workload across multiple instances of the plugin
-// NOTE: currently, we only support round robin
 func (r *LocalPluginRuntime) pickLowestLoadInstance() (*PluginInstance, error) {
 	// lock the instances to avoid array out of bounds
 	r.instanceLocker.RLock()
@@ -13,7 +60,58 @@ func (r *LocalPluginRuntime) pickLowestLoadInstance() (*PluginInstance, error) {
 		return nil, ErrNoProperInstance
 	}
 
-	// Just a round robin
+	switch r.loadBalancingStrategy {
+	case LoadBalancingStrategyRoundRobin:
+		return r.pickRoundRobinInstance(), nil
+	case LoadBalancingStrategyPowerOfTwoChoices:
+		return r.pickPowerOfTwoChoicesInstance(), nil
+	default:
+		return r.pickLeastOutstandingInstance(), nil
+	}
+}
+
+// NOTE: all pick methods below require `instanceLocker` to be held by the caller
+// and `instances` to be non-empty
+
+func (r *LocalPluginRuntime) pickRoundRobinInstance() *PluginInstance {
 	idx := atomic.AddInt64(&r.roundRobinIndex, 1)
-	return r.instances[idx%int64(len(r.instances))], nil
+	return r.instances[idx%int64(len(r.instances))]
+}
+
+func (r *LocalPluginRuntime) pickLeastOutstandingInstance() *PluginInstance {
+	// start from a rotating offset, so that instances with the same load
+	// are picked in turn instead of always returning the first one
+	offset := atomic.AddInt64(&r.roundRobinIndex, 1)
+
+	var picked *PluginInstance
+	lowest := -1
+	for i := range r.instances {
+		instance := r.instances[(offset+int64(i))%int64(len(r.instances))]
+		load := instance.InFlightSessions()
+		if lowest == -1 || load < lowest {
+			picked = instance
+			lowest = load
+		}
+	}
+
+	return picked
+}
+
+func (r *LocalPluginRuntime) pickPowerOfTwoChoicesInstance() *PluginInstance {
+	if len(r.instances) == 1 {
+		return r.instances[0]
+	}
+
+	first := rand.IntN(len(r.instances))
+	// pick another one which is different from the first one
+	second := rand.IntN(len(r.instances) - 1)
+	if second >= first {
+		second++
+	}
+
+	a, b := r.instances[first], r.instances[second]
+	if b.InFlightSessions() < a.InFlightSessions() {
+		return b
+	}
+	return a
 }
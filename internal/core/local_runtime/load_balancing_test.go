@@ -0,0 +1,93 @@
+package local_runtime
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestInstance(id string, sessions int) *PluginInstance {
+	instance := &PluginInstance{
+		instanceId: id,
+		l:          &sync.Mutex{},
+		listener:   map[string]func([]byte){},
+	}
+	for i := 0; i < sessions; i++ {
+		instance.listener[string(rune('a'+i))] = func([]byte) {}
+	}
+	return instance
+}
+
+func newTestRuntime(strategy LoadBalancingStrategy, instances ...*PluginInstance) *LocalPluginRuntime {
+	return &LocalPluginRuntime{
+		instances:             instances,
+		instanceLocker:        &sync.RWMutex{},
+		loadBalancingStrategy: strategy,
+	}
+}
+
+func TestPickLeastOutstandingInstance(t *testing.T) {
+	runtime := newTestRuntime(
+		LoadBalancingStrategyLeastOutstanding,
+		newTestInstance("busy", 3),
+		newTestInstance("idle", 0),
+		newTestInstance("normal", 1),
+	)
+
+	for i := 0; i < 10; i++ {
+		instance, err := runtime.pickLowestLoadInstance()
+		assert.Nil(t, err)
+		assert.Equal(t, "idle", instance.instanceId)
+	}
+}
+
+func TestPickPowerOfTwoChoicesInstance(t *testing.T) {
+	runtime := newTestRuntime(
+		LoadBalancingStrategyPowerOfTwoChoices,
+		newTestInstance("busy", 3),
+		newTestInstance("idle", 0),
+	)
+
+	// with only two instances, both are always sampled
+	for i := 0; i < 10; i++ {
+		instance, err := runtime.pickLowestLoadInstance()
+		assert.Nil(t, err)
+		assert.Equal(t, "idle", instance.instanceId)
+	}
+}
+
+func TestPickRoundRobinInstance(t *testing.T) {
+	runtime := newTestRuntime(
+		LoadBalancingStrategyRoundRobin,
+		newTestInstance("a", 3),
+		newTestInstance("b", 0),
+	)
+
+	picked := map[string]int{}
+	for i := 0; i < 10; i++ {
+		instance, err := runtime.pickLowestLoadInstance()
+		assert.Nil(t, err)
+		picked[instance.instanceId]++
+	}
+	assert.Equal(t, 5, picked["a"])
+	assert.Equal(t, 5, picked["b"])
+}
+
+func TestPickInstanceWithoutInstances(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding)
+	_, err := runtime.pickLowestLoadInstance()
+	assert.Equal(t, ErrNoProperInstance, err)
+}
+
+func TestResolveLoadBalancingStrategy(t *testing.T) {
+	overrides := map[string]string{
+		"langgenius/openai": "round_robin",
+		"langgenius/broken": "unknown",
+	}
+
+	assert.Equal(t, LoadBalancingStrategyRoundRobin, resolveLoadBalancingStrategy("power_of_two_choices", overrides, "langgenius/openai"))
+	assert.Equal(t, LoadBalancingStrategyPowerOfTwoChoices, resolveLoadBalancingStrategy("power_of_two_choices", overrides, "langgenius/anthropic"))
+	assert.Equal(t, LoadBalancingStrategyLeastOutstanding, resolveLoadBalancingStrategy("power_of_two_choices", overrides, "langgenius/broken"))
+	assert.Equal(t, LoadBalancingStrategyLeastOutstanding, resolveLoadBalancingStrategy("", nil, "langgenius/openai"))
+}
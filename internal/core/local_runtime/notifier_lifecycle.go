@@ -29,7 +29,7 @@ func (n *NotifierHeartbeat) OnInstanceLaunchFailed(instance *PluginInstance, err
 }
 
 func (n *NotifierHeartbeat) OnInstanceShutdown(instance *PluginInstance) {
-	instance.shutdown = true
+	instance.shutdown.Store(true)
 
 	for _, callback := range n.afterShutdown {
 		callback()
@@ -41,7 +41,7 @@ func (n *NotifierHeartbeat) OnInstanceHeartbeat(instance *PluginInstance) {
 	instance.lastActiveAt = time.Now()
 }
 
-func (n *NotifierHeartbeat) OnInstanceLog(instance *PluginInstance, message string) {
+func (n *NotifierHeartbeat) OnInstanceLog(instance *PluginInstance, entry PluginLogEntry) {
 
 }
 
@@ -28,13 +28,22 @@ func (n *NotifierLogger) OnInstanceHeartbeat(instance *PluginInstance) {
 	// Nop
 }
 
-func (n *NotifierLogger) OnInstanceLog(instance *PluginInstance, message string) {
-	// notify terminal
-	log.Info(
+func (n *NotifierLogger) OnInstanceLog(instance *PluginInstance, entry PluginLogEntry) {
+	// notify terminal, keep the level of the plugin
+	logFunc := log.Info
+	switch entry.Level {
+	case PluginLogLevelDebug:
+		logFunc = log.Debug
+	case PluginLogLevelWarn:
+		logFunc = log.Warn
+	case PluginLogLevelError:
+		logFunc = log.Error
+	}
+	logFunc(
 		"plugin %s: instance %s log: %s",
 		instance.pluginUniqueIdentifier,
 		instance.instanceId[:8],
-		message,
+		entry.Message,
 	)
 }
 
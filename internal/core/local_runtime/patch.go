@@ -1,27 +1,192 @@
 package local_runtime
 
 import (
-	_ "embed"
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	version "github.com/hashicorp/go-version"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+	"gopkg.in/yaml.v3"
 )
 
-//go:embed patches/0.0.1b70.ai_model.py.patch
-var python001b70aiModelsPatches []byte
+//go:embed patches
+var builtinSdkPatches embed.FS
 
-//go:embed patches/0.1.1.llm.py.patch
-var python011llmPatches []byte
+const (
+	sdkPatchManifestFile = "manifest.yaml"
+	// records patches applied to the plugin sdk, placed in the working path
+	sdkPatchRecordFile = ".sdk_patches.json"
+)
+
+var (
+	ErrInvalidSdkPatch = errors.New("invalid sdk patch")
+	// the sdk version is not declared in requirements.txt, the sdk is left as is
+	errPluginSdkVersionUnknown = errors.New("unknown plugin sdk version")
+)
+
+// SdkPatch replaces a file of the python plugin sdk `dify_plugin` for plugins of matched sdk versions
+type SdkPatch struct {
+	Name string `yaml:"name"`
+	// comma separated constraints, e.g. "< 0.1.1" or ">= 0.1.0, < 0.2.0"
+	SdkVersions string `yaml:"sdk_versions"`
+	// path relative to the `dify_plugin` package
+	Target string `yaml:"target"`
+	// path of the patched file relative to the manifest
+	File   string `yaml:"file"`
+	Reason string `yaml:"reason"`
+
+	constraints []sdkVersionConstraint
+	content     []byte
+}
+
+type sdkPatchManifest struct {
+	Patches []SdkPatch `yaml:"patches"`
+}
+
+type sdkVersionConstraint struct {
+	operator string
+	version  *version.Version
+}
+
+// parseSdkVersionRange parses comma separated constraints
+// unlike constraints of go-version, pre-releases are compared with other versions directly
+// as the sdk was released as `0.0.1bXX` for a long time
+func parseSdkVersionRange(versionRange string) ([]sdkVersionConstraint, error) {
+	constraints := []sdkVersionConstraint{}
+	for _, constraint := range strings.Split(versionRange, ",") {
+		constraint = strings.TrimSpace(constraint)
+		operator := "=="
+		for _, op := range []string{">=", "<=", "==", "!=", ">", "<"} {
+			if strings.HasPrefix(constraint, op) {
+				operator = op
+				constraint = strings.TrimSpace(strings.TrimPrefix(constraint, op))
+				break
+			}
+		}
+
+		v, err := version.NewVersion(constraint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sdk version range %q: %w", versionRange, err)
+		}
+		constraints = append(constraints, sdkVersionConstraint{operator: operator, version: v})
+	}
+	return constraints, nil
+}
+
+// Matches returns true if the sdk version satisfies all constraints of the patch
+func (p *SdkPatch) Matches(sdkVersion *version.Version) bool {
+	for _, constraint := range p.constraints {
+		result := sdkVersion.Compare(constraint.version)
+		var ok bool
+		switch constraint.operator {
+		case ">=":
+			ok = result >= 0
+		case "<=":
+			ok = result <= 0
+		case ">":
+			ok = result > 0
+		case "<":
+			ok = result < 0
+		case "!=":
+			ok = result != 0
+		default:
+			ok = result == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadSdkPatches loads builtin patches and patches declared by `<dir>/manifest.yaml`
+// a patch in the directory overrides the builtin one with the same name, it's skipped if dir is empty
+func LoadSdkPatches(dir string) ([]SdkPatch, error) {
+	builtin, err := fs.Sub(builtinSdkPatches, "patches")
+	if err != nil {
+		return nil, err
+	}
+
+	patches, err := loadSdkPatchManifest(builtin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load builtin sdk patches: %w", err)
+	}
+
+	if dir == "" {
+		return patches, nil
+	}
+
+	overrides, err := loadSdkPatchManifest(os.DirFS(dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sdk patches from %s: %w", dir, err)
+	}
+
+	for _, override := range overrides {
+		replaced := false
+		for i := range patches {
+			if patches[i].Name == override.Name {
+				patches[i] = override
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			patches = append(patches, override)
+		}
+	}
+
+	return patches, nil
+}
+
+func loadSdkPatchManifest(fsys fs.FS) ([]SdkPatch, error) {
+	data, err := fs.ReadFile(fsys, sdkPatchManifestFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest sdkPatchManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	for i := range manifest.Patches {
+		patch := &manifest.Patches[i]
+		if patch.Name == "" || patch.SdkVersions == "" || patch.File == "" {
+			return nil, errors.Join(ErrInvalidSdkPatch, fmt.Errorf("name, sdk_versions and file of patch %q are required", patch.Name))
+		}
+		// patches are not allowed to touch files outside the sdk
+		if !filepath.IsLocal(patch.Target) {
+			return nil, errors.Join(ErrInvalidSdkPatch, fmt.Errorf("target of patch %s is not a relative path in the sdk", patch.Name))
+		}
+
+		patch.constraints, err = parseSdkVersionRange(patch.SdkVersions)
+		if err != nil {
+			return nil, errors.Join(ErrInvalidSdkPatch, err)
+		}
+
+		patch.content, err = fs.ReadFile(fsys, patch.File)
+		if err != nil {
+			return nil, errors.Join(ErrInvalidSdkPatch, fmt.Errorf("failed to read patch %s: %w", patch.Name, err))
+		}
+	}
 
-//go:embed patches/0.1.1.request_reader.py.patch
-var python011requestReaderPatches []byte
+	return manifest.Patches, nil
+}
 
+// patchPluginSdk applies patches matching the sdk version declared in requirements.txt
+// applied patches are recorded in the working path and the runtime state
 func (p *LocalPluginRuntime) patchPluginSdk(
 	requirementsPath string,
 	pythonInterpreterPath string,
@@ -32,19 +197,16 @@ func (p *LocalPluginRuntime) patchPluginSdk(
 		return fmt.Errorf("failed to read requirements.txt: %s", err)
 	}
 
-	pluginSdkVersion, err := p.getPluginSdkVersion(string(requirements))
-	if err != nil {
-		log.Error("failed to get the version of the plugin sdk: %s", err)
-		return nil
-	}
-
-	pluginSdkVersionObj, err := version.NewVersion(pluginSdkVersion)
-	if err != nil {
-		log.Error("failed to create the version: %s", err)
+	pluginSdkVersion, matched, err := p.matchSdkPatches(string(requirements))
+	if errors.Is(err, errPluginSdkVersionUnknown) {
+		log.Error("%s", err)
 		return nil
+	} else if err != nil {
+		return err
 	}
 
-	if pluginSdkVersionObj.LessThan(version.Must(version.NewVersion("0.0.1b70"))) {
+	records := []plugin_entities.PluginSdkPatchRecord{}
+	if len(matched) > 0 {
 		// get dify-plugin path
 		command := exec.Command(pythonInterpreterPath, "-c", "import importlib.util;print(importlib.util.find_spec('dify_plugin').origin)")
 		command.Dir = p.State.WorkingPath
@@ -54,49 +216,126 @@ func (p *LocalPluginRuntime) patchPluginSdk(
 		}
 
 		pluginSdkPath := path.Dir(strings.TrimSpace(string(output)))
-		patchPath := path.Join(pluginSdkPath, "interfaces/model/ai_model.py")
-
-		// apply the patch
-		if _, err := os.Stat(patchPath); err != nil {
-			return fmt.Errorf("failed to find the patch file: %s", err)
+		records, err = applySdkPatches(pluginSdkPath, pluginSdkVersion, matched, p.appConfig.PluginSdkPatchDryRun)
+		if err != nil {
+			return err
 		}
+	}
 
-		if err := os.WriteFile(patchPath, python001b70aiModelsPatches, 0644); err != nil {
-			return fmt.Errorf("failed to write the patch file: %s", err)
-		}
+	records = mergeSdkPatchRecords(readSdkPatchRecords(p.State.WorkingPath), records)
+	p.State.Patches = records
+	return writeSdkPatchRecords(p.State.WorkingPath, records)
+}
+
+// matchSdkPatches returns the sdk version declared in requirements.txt and patches matching it
+func (p *LocalPluginRuntime) matchSdkPatches(requirements string) (string, []SdkPatch, error) {
+	pluginSdkVersion, err := p.getPluginSdkVersion(requirements)
+	if err != nil {
+		return "", nil, errors.Join(errPluginSdkVersionUnknown, fmt.Errorf("failed to get the version of the plugin sdk: %s", err))
 	}
 
-	if pluginSdkVersionObj.LessThan(version.Must(version.NewVersion("0.1.1"))) {
-		// get dify-plugin path
-		command := exec.Command(pythonInterpreterPath, "-c", "import importlib.util;print(importlib.util.find_spec('dify_plugin').origin)")
-		command.Dir = p.State.WorkingPath
-		output, err := command.Output()
-		if err != nil {
-			return fmt.Errorf("failed to get the path of the plugin sdk: %s", err)
+	pluginSdkVersionObj, err := version.NewVersion(pluginSdkVersion)
+	if err != nil {
+		return "", nil, errors.Join(errPluginSdkVersionUnknown, fmt.Errorf("failed to create the version: %s", err))
+	}
+
+	patches, err := LoadSdkPatches(p.appConfig.PluginSdkPatchesPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	matched := []SdkPatch{}
+	for _, patch := range patches {
+		if patch.Matches(pluginSdkVersionObj) {
+			matched = append(matched, patch)
 		}
+	}
 
-		pluginSdkPath := path.Dir(strings.TrimSpace(string(output)))
-		patchPath := path.Join(pluginSdkPath, "entities/model/llm.py")
+	return pluginSdkVersion, matched, nil
+}
 
-		// apply the patch
-		if _, err := os.Stat(patchPath); err != nil {
-			return fmt.Errorf("failed to find the patch file: %s", err)
+// applySdkPatches replaces targets in the sdk with patches, targets identical to patches are untouched
+// so that it's safe to apply patches multiple times
+func applySdkPatches(
+	pluginSdkPath string,
+	pluginSdkVersion string,
+	patches []SdkPatch,
+	dryRun bool,
+) ([]plugin_entities.PluginSdkPatchRecord, error) {
+	records := []plugin_entities.PluginSdkPatchRecord{}
+	for _, patch := range patches {
+		patchPath := path.Join(pluginSdkPath, patch.Target)
+		current, err := os.ReadFile(patchPath)
+		if err != nil {
+			return records, fmt.Errorf("failed to find the patch file: %s", err)
 		}
 
-		if err := os.WriteFile(patchPath, python011llmPatches, 0644); err != nil {
-			return fmt.Errorf("failed to write the patch file: %s", err)
+		record := plugin_entities.PluginSdkPatchRecord{
+			Name:       patch.Name,
+			Target:     patch.Target,
+			Reason:     patch.Reason,
+			SdkVersion: pluginSdkVersion,
+			PatchedAt:  time.Now(),
 		}
 
-		patchPath = path.Join(pluginSdkPath, "core/server/stdio/request_reader.py")
-		if _, err := os.Stat(patchPath); err != nil {
-			return fmt.Errorf("failed to find the patch file: %s", err)
+		switch {
+		case bytes.Equal(current, patch.content):
+			record.Status = plugin_entities.PLUGIN_SDK_PATCH_STATUS_UP_TO_DATE
+		case dryRun:
+			record.Status = plugin_entities.PLUGIN_SDK_PATCH_STATUS_DRY_RUN
+			log.Info("sdk patch %s would be applied to %s: %s", patch.Name, patchPath, patch.Reason)
+		default:
+			if err := os.WriteFile(patchPath, patch.content, 0644); err != nil {
+				return records, fmt.Errorf("failed to write the patch file: %s", err)
+			}
+			record.Status = plugin_entities.PLUGIN_SDK_PATCH_STATUS_APPLIED
 		}
 
-		if err := os.WriteFile(patchPath, python011requestReaderPatches, 0644); err != nil {
-			return fmt.Errorf("failed to write the patch file: %s", err)
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// mergeSdkPatchRecords keeps the record of the first application for patches which are up to date now
+func mergeSdkPatchRecords(
+	previous []plugin_entities.PluginSdkPatchRecord,
+	current []plugin_entities.PluginSdkPatchRecord,
+) []plugin_entities.PluginSdkPatchRecord {
+	for i, record := range current {
+		if record.Status != plugin_entities.PLUGIN_SDK_PATCH_STATUS_UP_TO_DATE {
+			continue
+		}
+		for _, previousRecord := range previous {
+			if previousRecord.Name == record.Name &&
+				previousRecord.Status == plugin_entities.PLUGIN_SDK_PATCH_STATUS_APPLIED {
+				current[i] = previousRecord
+				break
+			}
 		}
 	}
-	return nil
+	return current
+}
+
+func readSdkPatchRecords(workingPath string) []plugin_entities.PluginSdkPatchRecord {
+	data, err := os.ReadFile(path.Join(workingPath, sdkPatchRecordFile))
+	if err != nil {
+		return nil
+	}
+
+	records := []plugin_entities.PluginSdkPatchRecord{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil
+	}
+	return records
+}
+
+func writeSdkPatchRecords(workingPath string, records []plugin_entities.PluginSdkPatchRecord) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(workingPath, sdkPatchRecordFile), data, 0644)
 }
 
 func (p *LocalPluginRuntime) getPluginSdkVersion(requirements string) (string, error) {
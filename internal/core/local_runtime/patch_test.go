@@ -0,0 +1,112 @@
+package local_runtime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSdkPatchMatches(t *testing.T) {
+	constraints, err := parseSdkVersionRange(">= 0.0.1b60, < 0.1.1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	patch := SdkPatch{constraints: constraints}
+
+	for sdkVersion, expected := range map[string]bool{
+		"0.0.1b50": false,
+		"0.0.1b65": true,
+		"0.1.0":    true,
+		"0.1.1":    false,
+	} {
+		assert.Equal(t, expected, patch.Matches(version.Must(version.NewVersion(sdkVersion))), sdkVersion)
+	}
+
+	_, err = parseSdkVersionRange("< latest")
+	assert.Error(t, err)
+}
+
+func TestLoadSdkPatches(t *testing.T) {
+	builtin, err := LoadSdkPatches("")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, builtin, 3)
+	for _, patch := range builtin {
+		assert.NotEmpty(t, patch.content, patch.Name)
+		assert.NotEmpty(t, patch.Reason, patch.Name)
+	}
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte(`
+patches:
+  - name: ai_model_memory_leak
+    sdk_versions: "< 0.0.1b80"
+    target: interfaces/model/ai_model.py
+    file: ai_model.py
+    reason: overridden
+  - name: extra
+    sdk_versions: "== 0.2.0"
+    target: extra.py
+    file: extra.py
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ai_model.py"), []byte("ai_model"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "extra.py"), []byte("extra"), 0644))
+
+	patches, err := LoadSdkPatches(dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, patches, 4)
+	assert.Equal(t, "overridden", patches[0].Reason)
+	assert.Equal(t, []byte("ai_model"), patches[0].content)
+	assert.Equal(t, "extra", patches[3].Name)
+
+	// patches are not allowed to escape the sdk
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte(`
+patches:
+  - name: escape
+    sdk_versions: "< 1.0.0"
+    target: ../../site.py
+    file: extra.py
+`), 0644))
+	_, err = LoadSdkPatches(dir)
+	assert.ErrorIs(t, err, ErrInvalidSdkPatch)
+}
+
+func TestApplySdkPatches(t *testing.T) {
+	sdkPath := t.TempDir()
+	target := filepath.Join(sdkPath, "a.py")
+	assert.NoError(t, os.WriteFile(target, []byte("buggy"), 0644))
+
+	patches := []SdkPatch{{Name: "a", Target: "a.py", content: []byte("fixed")}}
+
+	// dry run changes nothing
+	records, err := applySdkPatches(sdkPath, "0.1.0", patches, true)
+	assert.NoError(t, err)
+	assert.Equal(t, plugin_entities.PLUGIN_SDK_PATCH_STATUS_DRY_RUN, records[0].Status)
+	content, _ := os.ReadFile(target)
+	assert.Equal(t, "buggy", string(content))
+
+	applied, err := applySdkPatches(sdkPath, "0.1.0", patches, false)
+	assert.NoError(t, err)
+	assert.Equal(t, plugin_entities.PLUGIN_SDK_PATCH_STATUS_APPLIED, applied[0].Status)
+	content, _ = os.ReadFile(target)
+	assert.Equal(t, "fixed", string(content))
+
+	// applying again is a no-op, the first application is kept in records
+	workingPath := t.TempDir()
+	assert.NoError(t, writeSdkPatchRecords(workingPath, applied))
+
+	records, err = applySdkPatches(sdkPath, "0.1.0", patches, false)
+	assert.NoError(t, err)
+	assert.Equal(t, plugin_entities.PLUGIN_SDK_PATCH_STATUS_UP_TO_DATE, records[0].Status)
+
+	records = mergeSdkPatchRecords(readSdkPatchRecords(workingPath), records)
+	assert.Equal(t, plugin_entities.PLUGIN_SDK_PATCH_STATUS_APPLIED, records[0].Status)
+	assert.True(t, applied[0].PatchedAt.Equal(records[0].PatchedAt))
+}
@@ -0,0 +1,269 @@
+package local_runtime
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/core/session_manager"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/stream"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	PluginLogLevelDebug = "debug"
+	PluginLogLevelInfo  = "info"
+	PluginLogLevelWarn  = "warn"
+	PluginLogLevelError = "error"
+
+	// entries buffered for a slow tail subscriber before new ones are dropped
+	pluginLogTailBufferSize = 1024
+)
+
+var pluginLogLevelSeverity = map[string]int{
+	PluginLogLevelDebug: 0,
+	PluginLogLevelInfo:  1,
+	PluginLogLevelWarn:  2,
+	PluginLogLevelError: 3,
+}
+
+// NormalizePluginLogLevel maps levels used by plugin SDKs, e.g. `WARNING`, `CRITICAL`, to the ones above
+// unknown levels are treated as info
+func NormalizePluginLogLevel(level string) string {
+	switch strings.ToLower(level) {
+	case "debug", "trace":
+		return PluginLogLevelDebug
+	case "warn", "warning":
+		return PluginLogLevelWarn
+	case "error", "critical", "fatal", "exception":
+		return PluginLogLevelError
+	default:
+		return PluginLogLevelInfo
+	}
+}
+
+// PluginLogEntry is a log sent by a plugin instance
+// entries of a session belong to the tenant of the session, others belong to the instance, e.g. crash output
+type PluginLogEntry struct {
+	Level      string    `json:"level"`
+	Message    string    `json:"message"`
+	Timestamp  time.Time `json:"timestamp"`
+	InstanceId string    `json:"instance_id"`
+	SessionId  string    `json:"session_id,omitempty"`
+	TenantId   string    `json:"tenant_id,omitempty"`
+}
+
+// PluginLogQuery filters entries of a plugin, zero values match everything
+type PluginLogQuery struct {
+	// minimum level
+	Level string
+	Since time.Time
+	Until time.Time
+	// returns the latest entries if exceeded
+	Limit int
+	// only entries of sessions of the tenant, entries without a session are excluded
+	// a runtime is shared by all tenants installed the plugin, it must be set for tenant facing requests
+	TenantId string
+}
+
+func (q *PluginLogQuery) match(entry *PluginLogEntry) bool {
+	if q.TenantId != "" && entry.TenantId != q.TenantId {
+		return false
+	}
+	if q.Level != "" && pluginLogLevelSeverity[entry.Level] < pluginLogLevelSeverity[NormalizePluginLogLevel(q.Level)] {
+		return false
+	}
+	if !q.Since.IsZero() && entry.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && entry.Timestamp.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// PluginLogBuffer keeps the latest logs of a plugin across its instances and runtimes
+type PluginLogBuffer struct {
+	lock sync.RWMutex
+
+	// ring buffer, `start` is the index of the oldest entry
+	entries []PluginLogEntry
+	start   int
+	size    int
+
+	// streams of tailing requests
+	subscribers map[*stream.Stream[PluginLogEntry]]*PluginLogQuery
+
+	// rotating file the entries are persisted to, nil if disabled
+	file *lumberjack.Logger
+}
+
+func NewPluginLogBuffer(capacity int) *PluginLogBuffer {
+	return &PluginLogBuffer{
+		entries:     make([]PluginLogEntry, max(capacity, 1)),
+		subscribers: map[*stream.Stream[PluginLogEntry]]*PluginLogQuery{},
+	}
+}
+
+// NewPluginLogBufferFromConfig creates the log buffer of a plugin, persisted to
+// `PluginLogPath/<identifier>.log` if enabled
+func NewPluginLogBufferFromConfig(
+	appConfig *app.Config,
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) *PluginLogBuffer {
+	buffer := NewPluginLogBuffer(appConfig.PluginLogBufferSize)
+	if appConfig.PluginLogPersistenceEnabled {
+		buffer.file = &lumberjack.Logger{
+			// some platform like windows may not allow : in the path
+			Filename:   path.Join(appConfig.PluginLogPath, strings.ReplaceAll(pluginUniqueIdentifier.String(), ":", "-")+".log"),
+			MaxSize:    appConfig.PluginLogMaxFileSize,
+			MaxBackups: appConfig.PluginLogMaxBackups,
+		}
+	}
+	return buffer
+}
+
+// Append adds an entry, the oldest one is dropped once the buffer is full
+func (b *PluginLogBuffer) Append(entry PluginLogEntry) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.size < len(b.entries) {
+		b.entries[(b.start+b.size)%len(b.entries)] = entry
+		b.size++
+	} else {
+		b.entries[b.start] = entry
+		b.start = (b.start + 1) % len(b.entries)
+	}
+
+	for subscriber, query := range b.subscribers {
+		if query.match(&entry) {
+			// never block the stdout of the plugin, entries are dropped for slow subscribers
+			_ = subscriber.Write(entry)
+		}
+	}
+
+	if b.file != nil {
+		line, _ := json.Marshal(entry)
+		if _, err := b.file.Write(append(line, '\n')); err != nil {
+			log.Error("failed to persist plugin log: %s", err.Error())
+		}
+	}
+}
+
+// Query returns entries matching the query, oldest first
+func (b *PluginLogBuffer) Query(query PluginLogQuery) []PluginLogEntry {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.query(&query)
+}
+
+// NOTE: lock must be held
+func (b *PluginLogBuffer) query(query *PluginLogQuery) []PluginLogEntry {
+	result := []PluginLogEntry{}
+	for i := 0; i < b.size; i++ {
+		entry := &b.entries[(b.start+i)%len(b.entries)]
+		if query.match(entry) {
+			result = append(result, *entry)
+		}
+	}
+
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[len(result)-query.Limit:]
+	}
+
+	return result
+}
+
+// Tail returns a stream of entries matching the query, starting with buffered ones
+// the stream is unsubscribed once it's closed
+func (b *PluginLogBuffer) Tail(query PluginLogQuery) *stream.Stream[PluginLogEntry] {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	backlog := b.query(&query)
+	response := stream.NewStream[PluginLogEntry](len(backlog) + pluginLogTailBufferSize)
+	for _, entry := range backlog {
+		response.Write(entry)
+	}
+
+	// `Until` makes no sense for new entries
+	live := query
+	live.Until = time.Time{}
+	b.subscribers[response] = &live
+
+	response.OnClose(func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		delete(b.subscribers, response)
+	})
+
+	return response
+}
+
+// Close closes all tailing streams and the persisted file
+func (b *PluginLogBuffer) Close() {
+	b.lock.Lock()
+	subscribers := b.subscribers
+	b.subscribers = map[*stream.Stream[PluginLogEntry]]*PluginLogQuery{}
+	file := b.file
+	b.file = nil
+	b.lock.Unlock()
+
+	for subscriber := range subscribers {
+		subscriber.Close()
+	}
+
+	if file != nil {
+		file.Close()
+	}
+}
+
+// SetLogBuffer sets where logs of instances are captured
+// the buffer outlives the runtime, logs of previous runtimes of the plugin are kept
+func (r *LocalPluginRuntime) SetLogBuffer(buffer *PluginLogBuffer) {
+	r.logBuffer = buffer
+}
+
+// captureInstanceLogs records logs and errors of the instance into the log buffer
+func (r *LocalPluginRuntime) captureInstanceLogs(instance *PluginInstance) {
+	if r.logBuffer == nil {
+		return
+	}
+
+	instance.AddNotifier(&PluginInstanceNotifierTemplate{
+		OnInstanceLogImpl: func(pi *PluginInstance, entry PluginLogEntry) {
+			if entry.SessionId != "" {
+				entry.TenantId = sessionTenantId(entry.SessionId)
+			}
+			r.logBuffer.Append(entry)
+		},
+		OnInstanceErrorLogImpl: func(pi *PluginInstance, err error) {
+			r.logBuffer.Append(PluginLogEntry{
+				Level:      PluginLogLevelError,
+				Message:    err.Error(),
+				Timestamp:  time.Now(),
+				InstanceId: pi.instanceId,
+			})
+		},
+	})
+}
+
+// sessionTenantId returns the tenant of a session, empty if the session is gone
+// entries without a tenant are only visible to operators
+func sessionTenantId(sessionId string) string {
+	session, err := session_manager.GetSession(session_manager.GetSessionPayload{
+		ID:          sessionId,
+		IgnoreCache: true,
+	})
+	if err != nil {
+		return ""
+	}
+	return session.TenantID
+}
@@ -0,0 +1,153 @@
+package local_runtime
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/core/session_manager"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluginLogBufferQuery(t *testing.T) {
+	buffer := NewPluginLogBuffer(3)
+	base := time.Now()
+
+	for i, level := range []string{"DEBUG", "INFO", "WARNING", "ERROR"} {
+		buffer.Append(PluginLogEntry{
+			Level:     NormalizePluginLogLevel(level),
+			Message:   level,
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	messages := func(entries []PluginLogEntry) []string {
+		result := []string{}
+		for _, entry := range entries {
+			result = append(result, entry.Message)
+		}
+		return result
+	}
+
+	// the oldest entry is dropped
+	assert.Equal(t, []string{"INFO", "WARNING", "ERROR"}, messages(buffer.Query(PluginLogQuery{})))
+	assert.Equal(t, []string{"WARNING", "ERROR"}, messages(buffer.Query(PluginLogQuery{Level: PluginLogLevelWarn})))
+	assert.Equal(t, []string{"WARNING"}, messages(buffer.Query(PluginLogQuery{
+		Since: base.Add(2 * time.Second),
+		Until: base.Add(2 * time.Second),
+	})))
+	assert.Equal(t, []string{"ERROR"}, messages(buffer.Query(PluginLogQuery{Limit: 1})))
+}
+
+func TestPluginLogBufferTail(t *testing.T) {
+	buffer := NewPluginLogBuffer(10)
+	buffer.Append(PluginLogEntry{Level: PluginLogLevelError, Message: "before", Timestamp: time.Now()})
+
+	logs := buffer.Tail(PluginLogQuery{Level: PluginLogLevelWarn})
+	buffer.Append(PluginLogEntry{Level: PluginLogLevelInfo, Message: "filtered", Timestamp: time.Now()})
+	buffer.Append(PluginLogEntry{Level: PluginLogLevelWarn, Message: "after", Timestamp: time.Now()})
+
+	for _, expected := range []string{"before", "after"} {
+		assert.True(t, logs.Next())
+		entry, err := logs.Read()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, entry.Message)
+	}
+
+	// closed streams are unsubscribed
+	logs.Close()
+	buffer.lock.RLock()
+	assert.Empty(t, buffer.subscribers)
+	buffer.lock.RUnlock()
+}
+
+func TestPluginLogsSeparatedByTenant(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding)
+	runtime.SetLogBuffer(NewPluginLogBuffer(10))
+
+	// sessions of two tenants are dispatched to the same instance
+	sessionA := session_manager.NewSession(session_manager.NewSessionPayload{TenantID: "tenant-a", IgnoreCache: true})
+	defer session_manager.DeleteSession(session_manager.DeleteSessionPayload{ID: sessionA.ID, IgnoreCache: true})
+	sessionB := session_manager.NewSession(session_manager.NewSessionPayload{TenantID: "tenant-b", IgnoreCache: true})
+	defer session_manager.DeleteSession(session_manager.DeleteSessionPayload{ID: sessionB.ID, IgnoreCache: true})
+
+	instance := newPluginInstance("langgenius/a:1.0.0", nil, nil, nil, nil, &app.Config{})
+	runtime.captureInstanceLogs(instance)
+
+	tailA := runtime.logBuffer.Tail(PluginLogQuery{TenantId: "tenant-a"})
+	defer tailA.Close()
+
+	log := func(sessionId string, message string) {
+		instance.WalkNotifiers(func(notifier PluginInstanceNotifier) {
+			notifier.OnInstanceLog(instance, PluginLogEntry{
+				Level:      PluginLogLevelInfo,
+				Message:    message,
+				Timestamp:  time.Now(),
+				InstanceId: instance.instanceId,
+				SessionId:  sessionId,
+			})
+		})
+	}
+	log(sessionA.ID, "prompt of a")
+	log(sessionB.ID, "prompt of b")
+	// output of the instance itself belongs to no tenant
+	log("", "instance started")
+
+	messages := func(entries []PluginLogEntry) []string {
+		result := []string{}
+		for _, entry := range entries {
+			result = append(result, entry.Message)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"prompt of a"}, messages(runtime.logBuffer.Query(PluginLogQuery{TenantId: "tenant-a"})))
+	assert.Equal(t, []string{"prompt of b"}, messages(runtime.logBuffer.Query(PluginLogQuery{TenantId: "tenant-b"})))
+	// operators see everything
+	assert.Equal(
+		t,
+		[]string{"prompt of a", "prompt of b", "instance started"},
+		messages(runtime.logBuffer.Query(PluginLogQuery{})),
+	)
+
+	// tailing is filtered as well
+	log(sessionB.ID, "completion of b")
+	log(sessionA.ID, "completion of a")
+	for _, expected := range []string{"prompt of a", "completion of a"} {
+		assert.True(t, tailA.Next())
+		entry, err := tailA.Read()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, entry.Message)
+		assert.Equal(t, "tenant-a", entry.TenantId)
+	}
+}
+
+func TestPluginLogBufferPersistence(t *testing.T) {
+	config := &app.Config{
+		PluginLogBufferSize:         10,
+		PluginLogPersistenceEnabled: true,
+		PluginLogPath:               t.TempDir(),
+		PluginLogMaxFileSize:        1,
+		PluginLogMaxBackups:         1,
+	}
+	identifier, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/a:1.0.0@1234567890abcdef1234567890abcdef1234567890abcdef")
+	assert.NoError(t, err)
+
+	buffer := NewPluginLogBufferFromConfig(config, identifier)
+	buffer.Append(PluginLogEntry{Level: PluginLogLevelInfo, Message: "hello", Timestamp: time.Now(), InstanceId: "instance"})
+	buffer.Close()
+
+	content, err := os.ReadFile(filepath.Join(config.PluginLogPath, "langgenius", "a-1.0.0@1234567890abcdef1234567890abcdef1234567890abcdef.log"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var entry PluginLogEntry
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(string(content))), &entry))
+	assert.Equal(t, "hello", entry.Message)
+	assert.Equal(t, "instance", entry.InstanceId)
+}
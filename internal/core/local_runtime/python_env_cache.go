@@ -0,0 +1,426 @@
+package local_runtime
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/lock"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+)
+
+const (
+	// python version used to create virtual environments if the plugin does not request one
+	defaultPythonVersion = "3.12"
+
+	pythonEnvCacheEnvironmentsPath = "envs"
+	pythonEnvCacheReferencesPath   = "refs"
+	pythonEnvCacheUvPath           = "uv"
+)
+
+// PythonEnvironmentCache shares python virtual environments across local plugins
+//
+// environments are content-addressed, the key is a hash of the requirements and the interpreter,
+// plugins (and versions of a plugin) with the same dependencies use the same environment,
+// packages are hardlinked from a shared uv cache so that similar environments are cheap as well
+//
+// layout of the cache:
+//
+//	<root>/uv                 shared uv cache
+//	<root>/envs/<key>         virtual environments
+//	<root>/refs/<key>/<ref>   references of an environment, one file per plugin package
+type PythonEnvironmentCache struct {
+	root string
+
+	// an unreferenced environment is kept for a while, plugins being upgraded or reinstalled reuse it
+	gracePeriod time.Duration
+
+	// locks an environment while it's being built or referenced
+	environmentLock *lock.GranularityLock
+
+	// garbage collection holds the write lock, referencing an environment holds the read lock
+	gcLock sync.RWMutex
+}
+
+func NewPythonEnvironmentCache(root string, gracePeriod time.Duration) (*PythonEnvironmentCache, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path of python environment cache: %w", err)
+	}
+
+	for _, dir := range []string{
+		pythonEnvCacheEnvironmentsPath,
+		pythonEnvCacheReferencesPath,
+		pythonEnvCacheUvPath,
+	} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create python environment cache: %w", err)
+		}
+	}
+
+	return &PythonEnvironmentCache{
+		root:            root,
+		gracePeriod:     gracePeriod,
+		environmentLock: lock.NewGranularityLock(),
+	}, nil
+}
+
+func (c *PythonEnvironmentCache) environmentPath(key string) string {
+	return filepath.Join(c.root, pythonEnvCacheEnvironmentsPath, key)
+}
+
+func (c *PythonEnvironmentCache) referencesPath(key string) string {
+	return filepath.Join(c.root, pythonEnvCacheReferencesPath, key)
+}
+
+func (c *PythonEnvironmentCache) referencePath(key string, identifier plugin_entities.PluginUniqueIdentifier) string {
+	// identifiers contain `/` and `:`, use a hash of it as the file name
+	sum := sha256.Sum256([]byte(identifier.String()))
+	return filepath.Join(c.referencesPath(key), hex.EncodeToString(sum[:16]))
+}
+
+func (c *PythonEnvironmentCache) uvCachePath() string {
+	return filepath.Join(c.root, pythonEnvCacheUvPath)
+}
+
+// acquire locks the environment and adds a reference of the plugin to it
+// the environment is protected from garbage collection until release is called
+func (c *PythonEnvironmentCache) acquire(
+	key string,
+	identifier plugin_entities.PluginUniqueIdentifier,
+) (func(), error) {
+	c.gcLock.RLock()
+	c.environmentLock.Lock(key)
+	release := func() {
+		c.environmentLock.Unlock(key)
+		c.gcLock.RUnlock()
+	}
+
+	referencePath := c.referencePath(key, identifier)
+	if err := os.MkdirAll(filepath.Dir(referencePath), 0755); err != nil {
+		release()
+		return nil, fmt.Errorf("failed to reference python environment: %w", err)
+	}
+	if err := os.WriteFile(referencePath, []byte(identifier.String()), 0644); err != nil {
+		release()
+		return nil, fmt.Errorf("failed to reference python environment: %w", err)
+	}
+
+	return release, nil
+}
+
+// GC removes references of plugins which are no longer installed, and then removes
+// environments which have not been referenced for the grace period
+// it returns keys of the removed environments
+func (c *PythonEnvironmentCache) GC(
+	installed func(plugin_entities.PluginUniqueIdentifier) (bool, error),
+) ([]string, error) {
+	c.gcLock.Lock()
+	defer c.gcLock.Unlock()
+
+	entries, err := os.ReadDir(filepath.Join(c.root, pythonEnvCacheEnvironmentsPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list python environments: %w", err)
+	}
+
+	removed := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		key := entry.Name()
+		referenced, lastReferencedAt, err := c.collectReferences(key, installed)
+		if err != nil {
+			log.Error("failed to collect references of python environment %s: %s", key, err.Error())
+			continue
+		}
+
+		if referenced || time.Since(lastReferencedAt) < c.gracePeriod {
+			continue
+		}
+
+		if err := os.RemoveAll(c.environmentPath(key)); err != nil {
+			log.Error("failed to remove python environment %s: %s", key, err.Error())
+			continue
+		}
+		os.RemoveAll(c.referencesPath(key))
+
+		removed = append(removed, key)
+	}
+
+	return removed, nil
+}
+
+// collectReferences removes references of uninstalled plugins
+// returns whether the environment is still referenced and when it was referenced last time
+func (c *PythonEnvironmentCache) collectReferences(
+	key string,
+	installed func(plugin_entities.PluginUniqueIdentifier) (bool, error),
+) (bool, time.Time, error) {
+	referencesPath := c.referencesPath(key)
+	references, err := os.ReadDir(referencesPath)
+	if errors.Is(err, os.ErrNotExist) {
+		// never referenced, e.g. the daemon exited while building it
+		info, err := os.Stat(c.environmentPath(key))
+		if err != nil {
+			return false, time.Time{}, err
+		}
+		return false, info.ModTime(), nil
+	} else if err != nil {
+		return false, time.Time{}, err
+	}
+
+	referenced := false
+	for _, reference := range references {
+		referencePath := filepath.Join(referencesPath, reference.Name())
+		content, err := os.ReadFile(referencePath)
+		if err != nil {
+			return false, time.Time{}, err
+		}
+
+		identifier, err := plugin_entities.NewPluginUniqueIdentifier(strings.TrimSpace(string(content)))
+		if err != nil {
+			// broken reference
+			os.Remove(referencePath)
+			continue
+		}
+
+		exists, err := installed(identifier)
+		if err != nil {
+			// keep it, it will be checked next time
+			log.Error("check if plugin %s is installed failed: %s", identifier.String(), err.Error())
+			referenced = true
+			continue
+		}
+
+		if !exists {
+			if err := os.Remove(referencePath); err != nil {
+				return false, time.Time{}, err
+			}
+			continue
+		}
+
+		referenced = true
+	}
+
+	// removing a reference updates the modification time of the directory
+	info, err := os.Stat(referencesPath)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	return referenced, info.ModTime(), nil
+}
+
+// SetPythonEnvironmentCache enables the shared python environment cache for the plugin
+func (r *LocalPluginRuntime) SetPythonEnvironmentCache(cache *PythonEnvironmentCache) {
+	r.pythonEnvironmentCache = cache
+}
+
+// pythonEnvironmentKey returns the content address of the environment of the plugin
+// everything affecting packages installed into the environment is hashed
+func (r *LocalPluginRuntime) pythonEnvironmentKey(requirements []byte) string {
+	hash := sha256.New()
+	for _, line := range normalizeRequirements(requirements) {
+		hash.Write([]byte(line))
+		hash.Write([]byte{'\n'})
+	}
+
+	// environments of invalid versions are never built, no need to tell them apart
+	pythonVersion, _ := r.pythonVersion()
+	fmt.Fprintf(hash, "python=%s\n", pythonVersion)
+	fmt.Fprintf(hash, "platform=%s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(hash, "index=%s\n", r.appConfig.PipMirrorUrl)
+	fmt.Fprintf(hash, "extra=%s\n", r.appConfig.PipExtraArgs)
+
+	// vendored wheels pin exact versions, their names are enough to tell them apart
+	if wheelhousePath, ok := r.getWheelhousePath(); ok {
+		entries, _ := os.ReadDir(wheelhousePath)
+		for _, entry := range entries {
+			fmt.Fprintf(hash, "wheel=%s\n", entry.Name())
+		}
+	}
+
+	// sdk patches are applied to the environment, environments patched differently are never shared
+	// nothing is patched if the sdk version is unknown or patches failed to load
+	_, patches, _ := r.matchSdkPatches(string(requirements))
+	for _, patch := range patches {
+		fmt.Fprintf(hash, "patch=%s:%s:%x\n", patch.Name, patch.Target, sha256.Sum256(patch.content))
+	}
+	if len(patches) > 0 {
+		fmt.Fprintf(hash, "patch_dry_run=%v\n", r.appConfig.PluginSdkPatchDryRun)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// normalizeRequirements strips comments and blank lines, the order of requirements does not matter
+func normalizeRequirements(requirements []byte) []string {
+	lines := []string{}
+	for _, line := range strings.Split(string(requirements), "\n") {
+		// a comment starts with `#` at the beginning of a line or after a whitespace
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if index := strings.Index(line, " #"); index >= 0 {
+			line = line[:index]
+		}
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	slices.Sort(lines)
+	return slices.Compact(lines)
+}
+
+// initSharedPythonEnvironment links `.venv` of the plugin to a shared environment, builds it if needed
+func (r *LocalPluginRuntime) initSharedPythonEnvironment(uvPath string) error {
+	cache := r.pythonEnvironmentCache
+
+	requirements, err := os.ReadFile(r.getRequirementsPath())
+	if err != nil {
+		return fmt.Errorf("failed to read requirements.txt: %w", err)
+	}
+
+	identifier, err := r.Identity()
+	if err != nil {
+		return err
+	}
+
+	key := r.pythonEnvironmentKey(requirements)
+	release, err := cache.acquire(key, identifier)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	environmentPath := cache.environmentPath(key)
+	pythonPath := filepath.Join(environmentPath, "bin", "python")
+
+	built := false
+	if !isSharedPythonEnvironmentValid(environmentPath) {
+		// remove the partially built environment and rebuild it
+		if err := os.RemoveAll(environmentPath); err != nil {
+			return fmt.Errorf("failed to remove invalid python environment: %w", err)
+		}
+
+		if err := r.createSharedVirtualEnvironment(uvPath, environmentPath); err != nil {
+			return fmt.Errorf("failed to create virtual environment: %w", err)
+		}
+
+		if err := r.installDependencies(uvPath, environmentPath); err != nil {
+			return fmt.Errorf("failed to install dependencies: %w", err)
+		}
+
+		built = true
+	} else {
+		log.Info("reuse python environment %s for %s", key, identifier.String())
+	}
+
+	linked, err := r.linkSharedPythonEnvironment(environmentPath)
+	if err != nil {
+		return err
+	}
+
+	if linked {
+		// pre-compile the plugin to avoid costly compilation on first invocation
+		if err := r.preCompile(pythonPath); err != nil {
+			return fmt.Errorf("failed to pre-compile the plugin: %w", err)
+		}
+	}
+
+	// PATCH:
+	//  fix known bugs of old plugin sdk versions, patches are declared in patches/manifest.yaml
+	if err := r.patchPluginSdk(r.getRequirementsPath(), pythonPath); err != nil {
+		log.Error("failed to patch the plugin sdk: %s", err)
+	}
+
+	if built {
+		// `.venv` is linked to the environment, the flag file is written into the environment
+		if err := r.markVirtualEnvironmentAsValid(); err != nil {
+			return fmt.Errorf("failed to mark the virtual environment as valid: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func isSharedPythonEnvironmentValid(environmentPath string) bool {
+	if _, err := os.Stat(filepath.Join(environmentPath, "bin", "python")); err != nil {
+		return false
+	}
+
+	if _, err := os.Stat(filepath.Join(environmentPath, "dify", "plugin.json")); err != nil {
+		return false
+	}
+
+	return true
+}
+
+func (r *LocalPluginRuntime) createSharedVirtualEnvironment(uvPath string, environmentPath string) error {
+	interpreter, err := r.provisionPythonInterpreter(uvPath)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(uvPath, "venv", environmentPath, "--python", interpreter)
+	cmd.Dir = r.State.WorkingPath
+	cmd.Env = append(os.Environ(), r.pythonEnvironmentCacheEnv()...)
+	b := bytes.NewBuffer(nil)
+	cmd.Stdout = b
+	cmd.Stderr = b
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s, output: %s", err, b.String())
+	}
+
+	return nil
+}
+
+// linkSharedPythonEnvironment points `.venv` of the plugin to the shared environment
+// returns true if the link was (re)created
+func (r *LocalPluginRuntime) linkSharedPythonEnvironment(environmentPath string) (bool, error) {
+	linkPath := path.Join(r.State.WorkingPath, envPath)
+	if target, err := os.Readlink(linkPath); err == nil && target == environmentPath {
+		return false, nil
+	}
+
+	// a private environment created before the cache was enabled, or a link to an outdated environment
+	if err := os.RemoveAll(linkPath); err != nil {
+		return false, fmt.Errorf("failed to remove %s: %w", envPath, err)
+	}
+
+	if err := os.Symlink(environmentPath, linkPath); err != nil {
+		return false, fmt.Errorf("failed to link python environment: %w", err)
+	}
+
+	return true, nil
+}
+
+// pythonEnvironmentCacheEnv returns variables to make uv share packages across environments
+func (r *LocalPluginRuntime) pythonEnvironmentCacheEnv() []string {
+	if r.pythonEnvironmentCache == nil {
+		return nil
+	}
+
+	return []string{
+		"UV_CACHE_DIR=" + r.pythonEnvironmentCache.uvCachePath(),
+		// the cache and environments are on the same filesystem, hardlinks avoid copying packages
+		"UV_LINK_MODE=hardlink",
+	}
+}
@@ -0,0 +1,122 @@
+package local_runtime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPythonEnvironmentKey(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding)
+	runtime.appConfig = &app.Config{}
+
+	key := runtime.pythonEnvironmentKey([]byte("dify_plugin==0.2.0\nrequests>=2.31 # http\n"))
+
+	// order, comments and blank lines don't matter
+	assert.Equal(t, key, runtime.pythonEnvironmentKey([]byte("# deps\n\nrequests>=2.31\ndify_plugin==0.2.0\n")))
+	assert.NotEqual(t, key, runtime.pythonEnvironmentKey([]byte("dify_plugin==0.2.1\nrequests>=2.31\n")))
+
+	// the index affects resolved packages
+	runtime.appConfig.PipMirrorUrl = "https://mirror.example.com/simple"
+	assert.NotEqual(t, key, runtime.pythonEnvironmentKey([]byte("dify_plugin==0.2.0\nrequests>=2.31\n")))
+}
+
+func TestPythonEnvironmentKeyWithSdkPatches(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding)
+	runtime.appConfig = &app.Config{}
+
+	patched := []byte("dify_plugin==0.1.0\n")
+	unpatched := []byte("dify_plugin==0.2.0\n")
+	patchedKey := runtime.pythonEnvironmentKey(patched)
+	unpatchedKey := runtime.pythonEnvironmentKey(unpatched)
+
+	// an environment patched in dry run mode is left as is
+	runtime.appConfig.PluginSdkPatchDryRun = true
+	assert.NotEqual(t, patchedKey, runtime.pythonEnvironmentKey(patched))
+	assert.Equal(t, unpatchedKey, runtime.pythonEnvironmentKey(unpatched))
+	runtime.appConfig.PluginSdkPatchDryRun = false
+
+	// patches overridden by operators are applied to a new environment
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte(`
+patches:
+  - name: llm_model_config_protected_namespaces
+    sdk_versions: "< 0.1.1"
+    target: entities/model/llm.py
+    file: llm.py
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "llm.py"), []byte("llm"), 0644))
+	runtime.appConfig.PluginSdkPatchesPath = dir
+	assert.NotEqual(t, patchedKey, runtime.pythonEnvironmentKey(patched))
+	assert.Equal(t, unpatchedKey, runtime.pythonEnvironmentKey(unpatched))
+}
+
+func TestPythonEnvironmentCacheGC(t *testing.T) {
+	cache, err := NewPythonEnvironmentCache(t.TempDir(), time.Hour)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	installed, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/a:1.0.0@1234567890abcdef1234567890abcdef1234567890abcdef")
+	assert.NoError(t, err)
+	uninstalled, err := plugin_entities.NewPluginUniqueIdentifier("langgenius/b:1.0.0@1234567890abcdef1234567890abcdef1234567890abcdef")
+	assert.NoError(t, err)
+
+	reference := func(key string, identifier plugin_entities.PluginUniqueIdentifier) {
+		assert.NoError(t, os.MkdirAll(cache.environmentPath(key), 0755))
+		release, err := cache.acquire(key, identifier)
+		assert.NoError(t, err)
+		release()
+	}
+	reference("shared", installed)
+	reference("shared", uninstalled)
+	reference("unused", uninstalled)
+	reference("expired", uninstalled)
+
+	isInstalled := func(identifier plugin_entities.PluginUniqueIdentifier) (bool, error) {
+		return identifier == installed, nil
+	}
+
+	// within the grace period, only references are collected
+	removed, err := cache.GC(isInstalled)
+	assert.NoError(t, err)
+	assert.Empty(t, removed)
+	assert.NoFileExists(t, cache.referencePath("shared", uninstalled))
+	assert.FileExists(t, cache.referencePath("shared", installed))
+
+	expiredAt := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, os.Chtimes(cache.referencesPath("expired"), expiredAt, expiredAt))
+
+	removed, err = cache.GC(isInstalled)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"expired"}, removed)
+	assert.NoDirExists(t, cache.environmentPath("expired"))
+	assert.DirExists(t, cache.environmentPath("shared"))
+	assert.DirExists(t, cache.environmentPath("unused"))
+}
+
+func TestLinkSharedPythonEnvironment(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding)
+	runtime.State.WorkingPath = t.TempDir()
+	environmentPath := t.TempDir()
+
+	// a private environment is replaced by the link
+	assert.NoError(t, os.MkdirAll(filepath.Join(runtime.State.WorkingPath, envPath, "bin"), 0755))
+
+	linked, err := runtime.linkSharedPythonEnvironment(environmentPath)
+	assert.NoError(t, err)
+	assert.True(t, linked)
+
+	target, err := os.Readlink(filepath.Join(runtime.State.WorkingPath, envPath))
+	assert.NoError(t, err)
+	assert.Equal(t, environmentPath, target)
+
+	linked, err = runtime.linkSharedPythonEnvironment(environmentPath)
+	assert.NoError(t, err)
+	assert.False(t, linked)
+}
@@ -0,0 +1,120 @@
+package local_runtime
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+)
+
+var (
+	ErrPythonVersionInvalid     = errors.New("invalid python version")
+	ErrPythonVersionUnavailable = errors.New("python version is not available")
+)
+
+// major, major.minor or major.minor.patch, e.g. 3.12
+var pythonVersionPattern = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+
+// pythonVersion returns the python version requested by `runner.version` of the manifest
+func (r *LocalPluginRuntime) pythonVersion() (string, error) {
+	version := strings.TrimSpace(r.Config.Meta.Runner.Version)
+	if version == "" {
+		return defaultPythonVersion, nil
+	}
+
+	if !pythonVersionPattern.MatchString(version) {
+		return "", errors.Join(
+			ErrPythonVersionInvalid,
+			fmt.Errorf("runner.version %q of the plugin is not a python version like 3.12", version),
+		)
+	}
+
+	return version, nil
+}
+
+// pythonInterpreterEnv returns variables to make uv manage interpreters in the local cache
+func (r *LocalPluginRuntime) pythonInterpreterEnv() []string {
+	env := []string{}
+	if r.appConfig.PythonInterpreterCachePath != "" {
+		cachePath, err := filepath.Abs(r.appConfig.PythonInterpreterCachePath)
+		if err == nil {
+			env = append(env, "UV_PYTHON_INSTALL_DIR="+cachePath)
+		}
+	}
+
+	if !r.appConfig.PythonInterpreterDownloadEnabled {
+		env = append(env, "UV_PYTHON_DOWNLOADS=never")
+	}
+
+	if r.appConfig.HttpProxy != "" {
+		env = append(env, fmt.Sprintf("HTTP_PROXY=%s", r.appConfig.HttpProxy))
+	}
+	if r.appConfig.HttpsProxy != "" {
+		env = append(env, fmt.Sprintf("HTTPS_PROXY=%s", r.appConfig.HttpsProxy))
+	}
+	if r.appConfig.NoProxy != "" {
+		env = append(env, fmt.Sprintf("NO_PROXY=%s", r.appConfig.NoProxy))
+	}
+
+	return env
+}
+
+// provisionPythonInterpreter returns the path of an interpreter of the version requested by the plugin
+// interpreters in the local cache or on the host are preferred, so that it works offline
+// the version is downloaded into the cache by uv only if none of them matches
+func (r *LocalPluginRuntime) provisionPythonInterpreter(uvPath string) (string, error) {
+	version, err := r.pythonVersion()
+	if err != nil {
+		return "", err
+	}
+
+	if interpreter, _, err := r.runUvPython(uvPath, "find", version); err == nil {
+		return interpreter, nil
+	}
+
+	if !r.appConfig.PythonInterpreterDownloadEnabled {
+		return "", errors.Join(ErrPythonVersionUnavailable, fmt.Errorf(
+			"python %s required by the plugin is not found and downloading interpreters is disabled, "+
+				"please install it on the host or into %s", version, r.appConfig.PythonInterpreterCachePath,
+		))
+	}
+
+	log.Info("python %s required by %s is not found, installing it", version, r.Config.Identity())
+	if _, output, err := r.runUvPython(uvPath, "install", version); err != nil {
+		return "", errors.Join(ErrPythonVersionUnavailable, fmt.Errorf(
+			"failed to install python %s required by the plugin: %s, output: %s", version, err, output,
+		))
+	}
+
+	interpreter, output, err := r.runUvPython(uvPath, "find", version)
+	if err != nil {
+		return "", errors.Join(ErrPythonVersionUnavailable, fmt.Errorf(
+			"python %s required by the plugin is not found after installation: %s, output: %s", version, err, output,
+		))
+	}
+
+	return interpreter, nil
+}
+
+// runUvPython runs `uv python <command> <version>`, returns stdout and the combined output
+func (r *LocalPluginRuntime) runUvPython(uvPath string, command string, version string) (string, string, error) {
+	cmd := exec.Command(uvPath, "python", command, version)
+	cmd.Dir = r.State.WorkingPath
+	cmd.Env = append(os.Environ(), r.pythonInterpreterEnv()...)
+
+	stdout := bytes.NewBuffer(nil)
+	output := bytes.NewBuffer(nil)
+	cmd.Stdout = stdout
+	cmd.Stderr = output
+	if err := cmd.Run(); err != nil {
+		return "", stdout.String() + output.String(), err
+	}
+
+	return strings.TrimSpace(stdout.String()), stdout.String() + output.String(), nil
+}
@@ -0,0 +1,97 @@
+package local_runtime
+
+import (
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"testing"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeUv behaves like `uv python find|install` with interpreters as plain files in the install dir
+const fakeUv = `#!/bin/sh
+case "$2" in
+find)
+	if [ -f "$UV_PYTHON_INSTALL_DIR/$3" ]; then echo "$UV_PYTHON_INSTALL_DIR/$3"; exit 0; fi
+	echo "No interpreter found for Python $3" >&2; exit 2;;
+install)
+	if [ "$3" = "3.99" ]; then echo "No download found for Python 3.99" >&2; exit 2; fi
+	touch "$UV_PYTHON_INSTALL_DIR/$3";;
+esac
+`
+
+func newInterpreterTestRuntime(t *testing.T, version string, downloadEnabled bool) (*LocalPluginRuntime, string) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding)
+	runtime.State.WorkingPath = t.TempDir()
+	runtime.Config.Meta.Runner.Version = version
+	runtime.appConfig = &app.Config{
+		PythonInterpreterCachePath:       t.TempDir(),
+		PythonInterpreterDownloadEnabled: downloadEnabled,
+	}
+
+	uvPath := filepath.Join(t.TempDir(), "uv")
+	assert.NoError(t, os.WriteFile(uvPath, []byte(fakeUv), 0755))
+	return runtime, uvPath
+}
+
+func TestPythonVersion(t *testing.T) {
+	for version, expected := range map[string]string{
+		"":         defaultPythonVersion,
+		" 3.11 ":   "3.11",
+		"3.12.4":   "3.12.4",
+		"python3":  "",
+		"3.12; rm": "",
+	} {
+		runtime, _ := newInterpreterTestRuntime(t, version, true)
+		actual, err := runtime.pythonVersion()
+		if expected == "" {
+			assert.ErrorIs(t, err, ErrPythonVersionInvalid, version)
+		} else {
+			assert.NoError(t, err, version)
+			assert.Equal(t, expected, actual, version)
+		}
+	}
+}
+
+func TestGetWheelhousePath(t *testing.T) {
+	runtime, _ := newInterpreterTestRuntime(t, "3.11.2", true)
+
+	// wheels built for another python version are not used
+	assert.NoError(t, os.MkdirAll(filepath.Join(runtime.State.WorkingPath, "wheelhouse", "3.12", goruntime.GOARCH), 0755))
+	_, ok := runtime.getWheelhousePath()
+	assert.False(t, ok)
+	assert.NotContains(t, runtime.preparePipArgs(), "--no-index")
+
+	wheelhousePath := filepath.Join(runtime.State.WorkingPath, "wheelhouse", "3.11", goruntime.GOARCH)
+	assert.NoError(t, os.MkdirAll(wheelhousePath, 0755))
+	actual, ok := runtime.getWheelhousePath()
+	assert.True(t, ok)
+	assert.Equal(t, wheelhousePath, actual)
+	assert.Contains(t, runtime.preparePipArgs(), "--no-index")
+}
+
+func TestProvisionPythonInterpreter(t *testing.T) {
+	runtime, uvPath := newInterpreterTestRuntime(t, "3.11", true)
+
+	// missing versions are installed into the cache
+	interpreter, err := runtime.provisionPythonInterpreter(uvPath)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(runtime.appConfig.PythonInterpreterCachePath, "3.11"), interpreter)
+
+	// cached interpreters are used offline
+	runtime.appConfig.PythonInterpreterDownloadEnabled = false
+	interpreter, err = runtime.provisionPythonInterpreter(uvPath)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(runtime.appConfig.PythonInterpreterCachePath, "3.11"), interpreter)
+
+	runtime.Config.Meta.Runner.Version = "3.10"
+	_, err = runtime.provisionPythonInterpreter(uvPath)
+	assert.ErrorIs(t, err, ErrPythonVersionUnavailable)
+
+	runtime.appConfig.PythonInterpreterDownloadEnabled = true
+	runtime.Config.Meta.Runner.Version = "3.99"
+	_, err = runtime.provisionPythonInterpreter(uvPath)
+	assert.ErrorIs(t, err, ErrPythonVersionUnavailable)
+}
@@ -0,0 +1,214 @@
+package local_runtime
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+)
+
+type RestartPolicyConfig struct {
+	// delay before the first restart, doubled after each consecutive failure
+	InitialBackoff time.Duration
+	// upper bound of the delay
+	MaxBackoff time.Duration
+
+	// once failures within `Window` exceed it, the runtime is considered crash-looping
+	// and no more instances are started until the policy is reset
+	MaxRestarts int
+	Window      time.Duration
+}
+
+func newRestartPolicyConfig(appConfig *app.Config) RestartPolicyConfig {
+	return RestartPolicyConfig{
+		InitialBackoff: time.Duration(appConfig.PluginLocalRestartInitialBackoff) * time.Second,
+		MaxBackoff:     time.Duration(appConfig.PluginLocalRestartMaxBackoff) * time.Second,
+		MaxRestarts:    appConfig.PluginLocalRestartMaxRestarts,
+		Window:         time.Duration(appConfig.PluginLocalRestartWindow) * time.Second,
+	}
+}
+
+// RestartPolicy decides when a failed instance of a runtime is allowed to be replaced
+// failures are launch failures and instances exited without being asked to
+type RestartPolicy struct {
+	config RestartPolicyConfig
+
+	lock sync.Mutex
+
+	// failures within the window
+	failures []time.Time
+	// failures since the last instance which kept running for a whole window
+	consecutiveFailures int
+	// total instances started to replace failed ones
+	restarts int
+	// a failed instance is waiting to be replaced
+	pendingRestart bool
+
+	nextRestartAt time.Time
+	crashLooping  bool
+}
+
+// RestartPolicyStatus is a snapshot of the restart policy of a runtime
+type RestartPolicyStatus struct {
+	RuntimeStatus       string     `json:"runtime_status"`
+	Restarts            int        `json:"restarts"`
+	RecentFailures      int        `json:"recent_failures"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	CrashLooping        bool       `json:"crash_looping"`
+	NextRestartAt       *time.Time `json:"next_restart_at"`
+	MaxRestarts         int        `json:"max_restarts"`
+	Window              string     `json:"window"`
+}
+
+func newRestartPolicy(config RestartPolicyConfig) *RestartPolicy {
+	return &RestartPolicy{config: config}
+}
+
+// recordFailure records a failed instance, `uptime` is how long it had been running
+// returns true if the runtime starts crash-looping because of it
+func (p *RestartPolicy) recordFailure(now time.Time, uptime time.Duration) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// the instance was stable for a while, it's not a part of a crash loop
+	if p.config.Window > 0 && uptime >= p.config.Window {
+		p.consecutiveFailures = 0
+	}
+	p.consecutiveFailures++
+	p.pendingRestart = true
+
+	p.failures = append(p.failures, now)
+	p.failures = slices.DeleteFunc(p.failures, func(t time.Time) bool {
+		return now.Sub(t) > p.config.Window
+	})
+
+	p.nextRestartAt = now.Add(p.backoff())
+
+	if !p.crashLooping && p.config.MaxRestarts > 0 && len(p.failures) > p.config.MaxRestarts {
+		p.crashLooping = true
+		return true
+	}
+
+	return false
+}
+
+// backoff returns the delay before the next restart
+// NOTE: lock must be held
+func (p *RestartPolicy) backoff() time.Duration {
+	backoff := p.config.InitialBackoff
+	for i := 1; i < p.consecutiveFailures && backoff < p.config.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, p.config.MaxBackoff)
+}
+
+// allowStart returns true if a new instance is allowed to be started now
+// it counts a restart if the new instance replaces a failed one
+func (p *RestartPolicy) allowStart(now time.Time) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.crashLooping || now.Before(p.nextRestartAt) {
+		return false
+	}
+
+	if p.pendingRestart {
+		p.pendingRestart = false
+		p.restarts++
+	}
+
+	return true
+}
+
+// Reset clears failures and leaves the crash-looping state, restarts are kept for the record
+func (p *RestartPolicy) Reset() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.failures = nil
+	p.consecutiveFailures = 0
+	p.nextRestartAt = time.Time{}
+	p.crashLooping = false
+}
+
+func (p *RestartPolicy) Restarts() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.restarts
+}
+
+func (p *RestartPolicy) CrashLooping() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.crashLooping
+}
+
+func (p *RestartPolicy) Status() RestartPolicyStatus {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	status := RestartPolicyStatus{
+		Restarts:            p.restarts,
+		RecentFailures:      len(p.failures),
+		ConsecutiveFailures: p.consecutiveFailures,
+		CrashLooping:        p.crashLooping,
+		MaxRestarts:         p.config.MaxRestarts,
+		Window:              p.config.Window.String(),
+	}
+	if !p.nextRestartAt.IsZero() && !p.crashLooping {
+		nextRestartAt := p.nextRestartAt
+		status.NextRestartAt = &nextRestartAt
+	}
+
+	return status
+}
+
+// allowInstanceStart asks the restart policy if a new instance is allowed to be started
+func (r *LocalPluginRuntime) allowInstanceStart() bool {
+	if !r.restartPolicy.allowStart(time.Now()) {
+		return false
+	}
+
+	r.State.Restarts = r.restartPolicy.Restarts()
+	return true
+}
+
+// recordInstanceFailure records a failed instance, respawning stops once the runtime is crash-looping
+func (r *LocalPluginRuntime) recordInstanceFailure(uptime time.Duration) {
+	if r.restartPolicy.recordFailure(time.Now(), uptime) {
+		r.SetCrashLooping()
+		log.Error(
+			"plugin %s is crash-looping, more than %d failures in %s, no more instances will be started until it's reset",
+			r.Config.Identity(), r.restartPolicy.config.MaxRestarts, r.restartPolicy.config.Window,
+		)
+	}
+}
+
+// onInstanceCrashed handles an instance which exited after it was ready without being asked to
+// it's a failure to the restart policy, the replacement waits for the backoff
+func (r *LocalPluginRuntime) onInstanceCrashed(instance *PluginInstance) {
+	r.recordInstanceFailure(time.Since(instance.readyAt))
+
+	err := instance.crashError()
+	r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
+		notifier.OnInstanceCrashed(instance, err)
+	})
+}
+
+// ResetRestartPolicy clears failures of the runtime and resumes respawning instances
+func (r *LocalPluginRuntime) ResetRestartPolicy() {
+	r.restartPolicy.Reset()
+	if r.State.Status == plugin_entities.PLUGIN_RUNTIME_STATUS_CRASH_LOOPING {
+		r.SetRestarting()
+	}
+}
+
+// RestartPolicyStatus returns the restart policy state of the runtime
+func (r *LocalPluginRuntime) RestartPolicyStatus() RestartPolicyStatus {
+	status := r.restartPolicy.Status()
+	status.RuntimeStatus = r.State.Status
+	return status
+}
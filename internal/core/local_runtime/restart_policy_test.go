@@ -0,0 +1,103 @@
+package local_runtime
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRestartPolicy() *RestartPolicy {
+	return newRestartPolicy(RestartPolicyConfig{
+		InitialBackoff: 5 * time.Second,
+		MaxBackoff:     30 * time.Second,
+		MaxRestarts:    3,
+		Window:         10 * time.Minute,
+	})
+}
+
+func TestRestartPolicyBackoff(t *testing.T) {
+	policy := newTestRestartPolicy()
+	now := time.Now()
+
+	assert.True(t, policy.allowStart(now))
+
+	// 5s, 10s, 20s and capped by 30s
+	for _, backoff := range []time.Duration{5, 10, 20} {
+		policy.recordFailure(now, 0)
+		assert.False(t, policy.allowStart(now.Add(backoff*time.Second-time.Millisecond)))
+		assert.True(t, policy.allowStart(now.Add(backoff*time.Second)))
+	}
+	assert.Equal(t, 3, policy.Restarts())
+
+	policy.config.MaxRestarts = 100
+	policy.recordFailure(now, 0)
+	policy.recordFailure(now, 0)
+	assert.True(t, policy.allowStart(now.Add(30*time.Second)))
+
+	// a stable instance resets the backoff
+	policy.recordFailure(now, time.Hour)
+	assert.True(t, policy.allowStart(now.Add(5*time.Second)))
+}
+
+func TestRestartPolicyCrashLooping(t *testing.T) {
+	policy := newTestRestartPolicy()
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		assert.False(t, policy.recordFailure(now, 0))
+	}
+	assert.True(t, policy.recordFailure(now, 0))
+	assert.True(t, policy.CrashLooping())
+	assert.False(t, policy.allowStart(now.Add(time.Hour)))
+
+	policy.Reset()
+	assert.False(t, policy.CrashLooping())
+	assert.True(t, policy.allowStart(now))
+	assert.Equal(t, 1, policy.Restarts())
+}
+
+func TestRestartPolicyWindow(t *testing.T) {
+	policy := newTestRestartPolicy()
+	now := time.Now()
+
+	// failures out of the window are forgotten
+	for i := 0; i < 10; i++ {
+		assert.False(t, policy.recordFailure(now.Add(time.Duration(i)*6*time.Minute), 0))
+	}
+	assert.Equal(t, 2, policy.Status().RecentFailures)
+}
+
+func TestCrashedReadyInstanceStopsRespawning(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding)
+	runtime.notifierLock = &sync.Mutex{}
+	runtime.restartPolicy = newRestartPolicy(RestartPolicyConfig{
+		MaxRestarts: 3,
+		Window:      10 * time.Minute,
+	})
+
+	crashed := 0
+	runtime.AddNotifier(&PluginRuntimeNotifierTemplate{
+		OnInstanceCrashedImpl: func(*PluginInstance, error) {
+			crashed++
+		},
+	})
+
+	// the instance gets ready and exits shortly after, again and again
+	for i := 0; i < 4; i++ {
+		if !assert.True(t, runtime.allowInstanceStart()) {
+			return
+		}
+		instance := newTestInstance("crashing", 0)
+		instance.readyAt = time.Now()
+		runtime.onInstanceCrashed(instance)
+	}
+
+	assert.Equal(t, 4, crashed)
+	assert.Equal(t, 3, runtime.State.Restarts)
+	assert.True(t, runtime.restartPolicy.CrashLooping())
+	assert.Equal(t, plugin_entities.PLUGIN_RUNTIME_STATUS_CRASH_LOOPING, runtime.State.Status)
+	assert.False(t, runtime.allowInstanceStart())
+}
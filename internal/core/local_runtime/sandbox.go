@@ -0,0 +1,165 @@
+package local_runtime
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+)
+
+// SandboxProfile describes how strict a local plugin instance is isolated
+type SandboxProfile string
+
+const (
+	// no isolation, the plugin runs as an ordinary child process
+	SandboxProfileNone SandboxProfile = "none"
+
+	// mount, pid and ipc namespaces, everything is read-only except
+	// the working path of the plugin and a private temp directory
+	SandboxProfileStandard SandboxProfile = "standard"
+
+	// standard profile with a seccomp filter which denies dangerous syscalls
+	SandboxProfileStrict SandboxProfile = "strict"
+)
+
+var (
+	ErrSandboxNotSupported = errors.New("sandbox is not supported on this platform")
+)
+
+func parseSandboxProfile(profile string) SandboxProfile {
+	switch SandboxProfile(profile) {
+	case SandboxProfileNone, SandboxProfileStandard, SandboxProfileStrict:
+		return SandboxProfile(profile)
+	default:
+		// fail safe, an unknown profile is considered as the strictest one
+		return SandboxProfileStrict
+	}
+}
+
+// ResolveSandboxProfile returns the sandbox profile of a plugin
+// profiles configured for the install source take precedence over the verification level
+func ResolveSandboxProfile(config *app.Config, source string, verified bool) SandboxProfile {
+	if !config.PluginSandboxEnabled {
+		return SandboxProfileNone
+	}
+
+	if profile, ok := config.PluginSandboxSourceProfiles[source]; ok && source != "" {
+		return parseSandboxProfile(profile)
+	}
+
+	if verified {
+		return parseSandboxProfile(config.PluginSandboxVerifiedProfile)
+	}
+
+	return parseSandboxProfile(config.PluginSandboxUnverifiedProfile)
+}
+
+// SetSandboxProfile sets the sandbox profile, it only takes effect on new instances
+func (r *LocalPluginRuntime) SetSandboxProfile(profile SandboxProfile) {
+	r.sandboxProfile = profile
+}
+
+// SandboxProfile returns the sandbox profile of the runtime
+func (r *LocalPluginRuntime) SandboxProfile() SandboxProfile {
+	if r.sandboxProfile == "" {
+		return SandboxProfileNone
+	}
+	return r.sandboxProfile
+}
+
+// applySandbox wraps the command with bubblewrap according to the sandbox profile
+// returns a cleanup function which should be called once the command started
+func (r *LocalPluginRuntime) applySandbox(cmd *exec.Cmd) (func(), error) {
+	profile := r.SandboxProfile()
+	if profile == SandboxProfileNone {
+		return func() {}, nil
+	}
+
+	bwrapPath, err := exec.LookPath(r.appConfig.PluginSandboxBwrapPath)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox profile %s requires bubblewrap: %w", profile, err)
+	}
+
+	workingPath, err := filepath.Abs(r.State.WorkingPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute working path: %w", err)
+	}
+
+	args := []string{
+		bwrapPath,
+		"--die-with-parent",
+		"--unshare-pid",
+		"--unshare-ipc",
+		// read-only view of the host
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		// private temp directory
+		"--tmpfs", "/tmp",
+		// the only writable directory
+		"--bind", workingPath, workingPath,
+		"--chdir", workingPath,
+		"--setenv", "TMPDIR", "/tmp",
+	}
+
+	cleanup := func() {}
+
+	if profile == SandboxProfileStrict {
+		program, err := seccompProgram()
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := os.CreateTemp("", "dify-plugin-seccomp-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create seccomp program file: %w", err)
+		}
+		// unlink it immediately, the file descriptor is still valid
+		os.Remove(file.Name())
+
+		if _, err := file.Write(program); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to write seccomp program: %w", err)
+		}
+		if _, err := file.Seek(0, 0); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to write seccomp program: %w", err)
+		}
+
+		// ExtraFiles[i] becomes file descriptor 3+i in the child
+		cmd.ExtraFiles = append(cmd.ExtraFiles, file)
+		args = append(args, "--seccomp", fmt.Sprintf("%d", 2+len(cmd.ExtraFiles)))
+		cleanup = func() {
+			file.Close()
+		}
+	}
+
+	args = append(args, "--", cmd.Path)
+	args = append(args, cmd.Args[1:]...)
+
+	cmd.Path = bwrapPath
+	cmd.Args = args
+
+	return cleanup, nil
+}
+
+// sandboxExitHint explains the sandbox profile to users when an instance exited unexpectedly
+func (r *LocalPluginRuntime) sandboxExitHint() string {
+	switch r.SandboxProfile() {
+	case SandboxProfileStandard:
+		return fmt.Sprintf(
+			"plugin is running under sandbox profile `%s`, only its working directory and /tmp are writable",
+			SandboxProfileStandard,
+		)
+	case SandboxProfileStrict:
+		return fmt.Sprintf(
+			"plugin is running under sandbox profile `%s`, only its working directory and /tmp are writable "+
+				"and syscalls like ptrace, mount or unshare are denied",
+			SandboxProfileStrict,
+		)
+	}
+	return ""
+}
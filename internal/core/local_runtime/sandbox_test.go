@@ -0,0 +1,43 @@
+package local_runtime
+
+import (
+	"testing"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+)
+
+func TestResolveSandboxProfile(t *testing.T) {
+	config := &app.Config{
+		PluginSandboxEnabled:           true,
+		PluginSandboxVerifiedProfile:   "standard",
+		PluginSandboxUnverifiedProfile: "strict",
+		PluginSandboxSourceProfiles: map[string]string{
+			"marketplace": "none",
+			"github":      "unknown",
+		},
+	}
+
+	cases := []struct {
+		source   string
+		verified bool
+		expected SandboxProfile
+	}{
+		{"", true, SandboxProfileStandard},
+		{"", false, SandboxProfileStrict},
+		{"package", true, SandboxProfileStandard},
+		{"marketplace", false, SandboxProfileNone},
+		// unknown profiles fall back to the strictest one
+		{"github", true, SandboxProfileStrict},
+	}
+
+	for _, c := range cases {
+		if profile := ResolveSandboxProfile(config, c.source, c.verified); profile != c.expected {
+			t.Errorf("source %q verified %v: expected %s, got %s", c.source, c.verified, c.expected, profile)
+		}
+	}
+
+	config.PluginSandboxEnabled = false
+	if profile := ResolveSandboxProfile(config, "github", false); profile != SandboxProfileNone {
+		t.Errorf("expected %s when sandbox is disabled, got %s", SandboxProfileNone, profile)
+	}
+}
@@ -0,0 +1,113 @@
+package local_runtime
+
+import (
+	"encoding/binary"
+	"fmt"
+	"runtime"
+	"syscall"
+)
+
+// classic BPF opcodes and seccomp return values
+// https://www.kernel.org/doc/html/latest/userspace-api/seccomp_filter.html
+const (
+	bpfLdWAbs = 0x20 // BPF_LD | BPF_W | BPF_ABS
+	bpfJeqK   = 0x15 // BPF_JMP | BPF_JEQ | BPF_K
+	bpfJgeK   = 0x35 // BPF_JMP | BPF_JGE | BPF_K
+	bpfRetK   = 0x06 // BPF_RET | BPF_K
+
+	seccompRetKillProcess = 0x80000000
+	seccompRetErrno       = 0x00050000
+	seccompRetAllow       = 0x7fff0000
+
+	// offsets in struct seccomp_data
+	seccompDataNr   = 0
+	seccompDataArch = 4
+
+	auditArchX8664   = 0xc000003e
+	auditArchAarch64 = 0xc00000b7
+
+	// syscalls of x32 abi have this bit set
+	x32SyscallBit = 0x40000000
+)
+
+// syscalls which are never needed by a plugin
+var seccompDeniedSyscalls = []uintptr{
+	syscall.SYS_PTRACE,
+	syscall.SYS_MOUNT,
+	syscall.SYS_UMOUNT2,
+	syscall.SYS_PIVOT_ROOT,
+	syscall.SYS_CHROOT,
+	syscall.SYS_REBOOT,
+	syscall.SYS_SWAPON,
+	syscall.SYS_SWAPOFF,
+	syscall.SYS_INIT_MODULE,
+	syscall.SYS_DELETE_MODULE,
+	syscall.SYS_KEXEC_LOAD,
+	syscall.SYS_UNSHARE,
+	syscall.SYS_KEYCTL,
+	syscall.SYS_ADD_KEY,
+	syscall.SYS_REQUEST_KEY,
+	syscall.SYS_PERF_EVENT_OPEN,
+	syscall.SYS_ACCT,
+	syscall.SYS_SETTIMEOFDAY,
+	syscall.SYS_SETHOSTNAME,
+	syscall.SYS_SETDOMAINNAME,
+}
+
+type sockFilter struct {
+	code uint16
+	jt   uint8
+	jf   uint8
+	k    uint32
+}
+
+// seccompProgram builds a seccomp filter which denies `seccompDeniedSyscalls` with EPERM
+// the result is a binary `struct sock_filter[]` accepted by `bwrap --seccomp`
+func seccompProgram() ([]byte, error) {
+	var arch uint32
+	switch runtime.GOARCH {
+	case "amd64":
+		arch = auditArchX8664
+	case "arm64":
+		arch = auditArchAarch64
+	default:
+		return nil, fmt.Errorf("%w: seccomp filter is not available on %s", ErrSandboxNotSupported, runtime.GOARCH)
+	}
+
+	denied := len(seccompDeniedSyscalls)
+
+	program := []sockFilter{
+		// kill the process if the architecture does not match, avoid bypassing by other abis
+		{code: bpfLdWAbs, k: seccompDataArch},
+		{code: bpfJeqK, jt: 1, jf: 0, k: arch},
+		{code: bpfRetK, k: seccompRetKillProcess},
+		{code: bpfLdWAbs, k: seccompDataNr},
+	}
+
+	if runtime.GOARCH == "amd64" {
+		// deny x32 abi
+		program = append(program, sockFilter{code: bpfJgeK, jt: uint8(denied + 1), jf: 0, k: x32SyscallBit})
+	} else {
+		// keep the jump offsets the same as amd64
+		program = append(program, sockFilter{code: bpfJeqK, jt: 0, jf: 0, k: 0})
+	}
+
+	for i, nr := range seccompDeniedSyscalls {
+		// jump to the deny instruction if matches
+		program = append(program, sockFilter{code: bpfJeqK, jt: uint8(denied - i), jf: 0, k: uint32(nr)})
+	}
+
+	program = append(program,
+		sockFilter{code: bpfRetK, k: seccompRetAllow},
+		sockFilter{code: bpfRetK, k: seccompRetErrno | uint32(syscall.EPERM)},
+	)
+
+	buf := make([]byte, 0, len(program)*8)
+	for _, instruction := range program {
+		buf = binary.LittleEndian.AppendUint16(buf, instruction.code)
+		buf = append(buf, instruction.jt, instruction.jf)
+		buf = binary.LittleEndian.AppendUint32(buf, instruction.k)
+	}
+
+	return buf, nil
+}
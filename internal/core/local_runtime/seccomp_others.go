@@ -0,0 +1,7 @@
+//go:build !linux
+
+package local_runtime
+
+func seccompProgram() ([]byte, error) {
+	return nil, ErrSandboxNotSupported
+}
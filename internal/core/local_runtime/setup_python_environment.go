@@ -9,11 +9,13 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/langgenius/dify-plugin-daemon/pkg/plugin_packager/packager"
 	routinepkg "github.com/langgenius/dify-plugin-daemon/pkg/routine"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/routine"
@@ -37,7 +39,10 @@ func (p *LocalPluginRuntime) prepareUV() (string, error) {
 func (p *LocalPluginRuntime) preparePipArgs() []string {
 	args := []string{"install"}
 
-	if p.appConfig.PipMirrorUrl != "" {
+	if wheelhousePath, ok := p.getWheelhousePath(); ok {
+		// wheels vendored inside the package, install them without accessing any index
+		args = append(args, "--no-index", "--find-links", wheelhousePath)
+	} else if p.appConfig.PipMirrorUrl != "" {
 		args = append(args, "-i", p.appConfig.PipMirrorUrl)
 	}
 
@@ -57,17 +62,41 @@ func (p *LocalPluginRuntime) preparePipArgs() []string {
 	return args
 }
 
+// getWheelhousePath returns the wheelhouse vendored for the python version and the arch of the plugin
+// wheels built for other versions are not installable, dependencies are installed from the index then
+func (p *LocalPluginRuntime) getWheelhousePath() (string, bool) {
+	pythonVersion, err := p.pythonVersion()
+	if err != nil {
+		return "", false
+	}
+
+	wheelhousePath, err := filepath.Abs(path.Join(
+		p.State.WorkingPath,
+		packager.WheelhousePath(pythonVersion, runtime.GOARCH),
+	))
+	if err != nil {
+		return "", false
+	}
+
+	if info, err := os.Stat(wheelhousePath); err != nil || !info.IsDir() {
+		return "", false
+	}
+
+	return wheelhousePath, true
+}
+
 func (p *LocalPluginRuntime) installDependencies(
 	uvPath string,
+	virtualEnvPath string,
 ) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
 	args := p.preparePipArgs()
 
-	virtualEnvPath := path.Join(p.State.WorkingPath, ".venv")
 	cmd := exec.CommandContext(ctx, uvPath, args...)
 	cmd.Env = append(cmd.Env, "VIRTUAL_ENV="+virtualEnvPath, "PATH="+os.Getenv("PATH"))
+	cmd.Env = append(cmd.Env, p.pythonEnvironmentCacheEnv()...)
 	if p.appConfig.HttpProxy != "" {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("HTTP_PROXY=%s", p.appConfig.HttpProxy))
 	}
@@ -235,7 +264,12 @@ func (p *LocalPluginRuntime) deleteVirtualEnvironment() error {
 func (p *LocalPluginRuntime) createVirtualEnvironment(
 	uvPath string,
 ) (*PythonVirtualEnvironment, error) {
-	cmd := exec.Command(uvPath, "venv", envPath, "--python", "3.12")
+	interpreter, err := p.provisionPythonInterpreter(uvPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(uvPath, "venv", envPath, "--python", interpreter)
 	cmd.Dir = p.State.WorkingPath
 	b := bytes.NewBuffer(nil)
 	cmd.Stdout = b
@@ -269,13 +303,17 @@ func (p *LocalPluginRuntime) getRequirementsPath() string {
 }
 
 func (p *LocalPluginRuntime) markVirtualEnvironmentAsValid() error {
-	// pluginIdentityPath is a file that contains the timestamp of the virtual environment
-	// which is used to mark the virtual environment as valid (All dependencies were installed)
+	return p.markEnvironmentAsValid(envValidFlagFile)
+}
 
-	pluginJsonPath := path.Join(p.State.WorkingPath, envValidFlagFile)
+// markEnvironmentAsValid writes a flag file into the working path
+// it's used to mark the environment as valid (All dependencies were installed)
+func (p *LocalPluginRuntime) markEnvironmentAsValid(flagFile string) error {
+	// pluginIdentityPath is a file that contains the timestamp of the environment
+	pluginJsonPath := path.Join(p.State.WorkingPath, flagFile)
 
 	if err := os.MkdirAll(path.Dir(pluginJsonPath), 0755); err != nil {
-		return fmt.Errorf("failed to create %s/dify directory: %s", envPath, err)
+		return fmt.Errorf("failed to create %s directory: %s", path.Dir(flagFile), err)
 	}
 
 	// write plugin.json
@@ -47,7 +47,7 @@ type PluginInstanceNotifier interface {
 	OnInstanceHeartbeat(*PluginInstance)
 
 	// on instance log
-	OnInstanceLog(*PluginInstance, string)
+	OnInstanceLog(*PluginInstance, PluginLogEntry)
 
 	// on instance error
 	OnInstanceErrorLog(*PluginInstance, error)
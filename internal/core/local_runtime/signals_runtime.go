@@ -13,6 +13,9 @@ type PluginRuntimeNotifier interface {
 	// on instance shutdown
 	OnInstanceShutdown(*PluginInstance)
 
+	// on a ready instance exited without being requested to stop
+	OnInstanceCrashed(*PluginInstance, error)
+
 	// on instance scale up
 	OnInstanceScaleUp(int32)
 
@@ -5,6 +5,7 @@ type PluginRuntimeNotifierTemplate struct {
 	OnInstanceReadyImpl           func(*PluginInstance)
 	OnInstanceLaunchFailedImpl    func(*PluginInstance, error)
 	OnInstanceShutdownImpl        func(*PluginInstance)
+	OnInstanceCrashedImpl         func(*PluginInstance, error)
 	OnInstanceScaleUpImpl         func(int32)
 	OnInstanceScaleDownImpl       func(int32)
 	OnInstanceScaleDownFailedImpl func(error)
@@ -36,6 +37,12 @@ func (t *PluginRuntimeNotifierTemplate) OnInstanceShutdown(instance *PluginInsta
 	}
 }
 
+func (t *PluginRuntimeNotifierTemplate) OnInstanceCrashed(instance *PluginInstance, err error) {
+	if t.OnInstanceCrashedImpl != nil {
+		t.OnInstanceCrashedImpl(instance, err)
+	}
+}
+
 func (t *PluginRuntimeNotifierTemplate) OnInstanceScaleUp(instanceNums int32) {
 	if t.OnInstanceScaleUpImpl != nil {
 		t.OnInstanceScaleUpImpl(instanceNums)
@@ -72,7 +79,7 @@ type PluginInstanceNotifierTemplate struct {
 	OnInstanceLaunchFailedImpl func(*PluginInstance, error)
 	OnInstanceShutdownImpl     func(*PluginInstance)
 	OnInstanceHeartbeatImpl    func(*PluginInstance)
-	OnInstanceLogImpl          func(*PluginInstance, string)
+	OnInstanceLogImpl          func(*PluginInstance, PluginLogEntry)
 	OnInstanceErrorLogImpl     func(*PluginInstance, error)
 	OnInstanceWarningLogImpl   func(*PluginInstance, string)
 	OnInstanceStdoutImpl       func(*PluginInstance, []byte)
@@ -109,9 +116,9 @@ func (t *PluginInstanceNotifierTemplate) OnInstanceHeartbeat(instance *PluginIns
 	}
 }
 
-func (t *PluginInstanceNotifierTemplate) OnInstanceLog(instance *PluginInstance, message string) {
+func (t *PluginInstanceNotifierTemplate) OnInstanceLog(instance *PluginInstance, entry PluginLogEntry) {
 	if t.OnInstanceLogImpl != nil {
-		t.OnInstanceLogImpl(instance, message)
+		t.OnInstanceLogImpl(instance, entry)
 	}
 }
 
@@ -0,0 +1,130 @@
+package local_runtime
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	stderrLogPrefix = "stderr-"
+	stderrLogSuffix = ".log"
+)
+
+// stderrLogDir returns where stderr files of instances are placed
+func (r *LocalPluginRuntime) stderrLogDir() (string, error) {
+	if r.appConfig.PluginStderrLogPath == "" {
+		return path.Join(r.State.WorkingPath, ".logs"), nil
+	}
+
+	checksum, err := r.Checksum()
+	if err != nil {
+		return "", err
+	}
+
+	// the same layout as the working path, some platform like windows may not allow : in the path
+	return path.Join(
+		r.appConfig.PluginStderrLogPath,
+		fmt.Sprintf("%s@%s", strings.ReplaceAll(r.Config.Identity(), ":", "-"), checksum),
+	), nil
+}
+
+// setupInstanceStderrLog persists stderr of the instance to a size-rotated file
+// files of older instances exceeding `PluginStderrLogMaxInstances` are removed unless they are still alive
+func (r *LocalPluginRuntime) setupInstanceStderrLog(instance *PluginInstance) error {
+	if !r.appConfig.PluginStderrLogEnabled {
+		return nil
+	}
+
+	dir, err := r.stderrLogDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// keep room for the new instance
+	if err := pruneStderrLogs(dir, r.appConfig.PluginStderrLogMaxInstances-1, r.liveInstanceIds()); err != nil {
+		return err
+	}
+
+	instance.stderrLogPath = path.Join(dir, stderrLogPrefix+instance.instanceId+stderrLogSuffix)
+	instance.stderrLog = &lumberjack.Logger{
+		Filename:   instance.stderrLogPath,
+		MaxSize:    r.appConfig.PluginStderrLogMaxFileSize,
+		MaxBackups: r.appConfig.PluginStderrLogMaxBackups,
+	}
+
+	return nil
+}
+
+// liveInstanceIds returns ids of instances serving requests or waiting in the warm pool
+func (r *LocalPluginRuntime) liveInstanceIds() map[string]bool {
+	r.instanceLocker.RLock()
+	defer r.instanceLocker.RUnlock()
+
+	ids := make(map[string]bool, len(r.instances)+len(r.warmInstances))
+	for _, instance := range r.instances {
+		ids[instance.instanceId] = true
+	}
+	for _, instance := range r.warmInstances {
+		ids[instance.instanceId] = true
+	}
+
+	return ids
+}
+
+// pruneStderrLogs removes files of all but the latest `keep` instances in the directory
+// files of instances in `live` are still being written, they are never removed
+// rotated files are named `stderr-<instance id>-<time>.log`, instance ids are UUIDv7 which sort by time
+func pruneStderrLogs(dir string, keep int, live map[string]bool) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	// a UUID is 36 characters long
+	instanceIdLen := 36
+	filesOfInstances := map[string][]string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() ||
+			!strings.HasPrefix(name, stderrLogPrefix) ||
+			!strings.HasSuffix(name, stderrLogSuffix) ||
+			len(name) < len(stderrLogPrefix)+instanceIdLen+len(stderrLogSuffix) {
+			continue
+		}
+
+		instanceId := name[len(stderrLogPrefix) : len(stderrLogPrefix)+instanceIdLen]
+		filesOfInstances[instanceId] = append(filesOfInstances[instanceId], name)
+	}
+
+	instanceIds := make([]string, 0, len(filesOfInstances))
+	for instanceId := range filesOfInstances {
+		instanceIds = append(instanceIds, instanceId)
+	}
+	slices.Sort(instanceIds)
+
+	keep = max(keep, 0)
+	if len(instanceIds) <= keep {
+		return nil
+	}
+
+	for _, instanceId := range instanceIds[:len(instanceIds)-keep] {
+		if live[instanceId] {
+			continue
+		}
+		for _, name := range filesOfInstances[instanceId] {
+			if err := os.Remove(path.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
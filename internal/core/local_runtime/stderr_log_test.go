@@ -0,0 +1,113 @@
+package local_runtime
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneStderrLogs(t *testing.T) {
+	dir := t.TempDir()
+
+	instanceIds := []string{}
+	for range 3 {
+		instanceId, _ := uuid.NewV7()
+		instanceIds = append(instanceIds, instanceId.String())
+	}
+
+	files := []string{
+		"stderr-" + instanceIds[0] + ".log",
+		"stderr-" + instanceIds[0] + "-2025-01-01T00-00-00.000.log",
+		"stderr-" + instanceIds[1] + ".log",
+		"stderr-" + instanceIds[2] + ".log",
+		"unrelated.log",
+	}
+	for _, file := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte("x"), 0644))
+	}
+
+	assert.NoError(t, pruneStderrLogs(dir, 2, nil))
+
+	// files of the oldest instance are removed, including rotated ones
+	assert.NoFileExists(t, filepath.Join(dir, files[0]))
+	assert.NoFileExists(t, filepath.Join(dir, files[1]))
+	for _, file := range files[2:] {
+		assert.FileExists(t, filepath.Join(dir, file))
+	}
+}
+
+func TestPruneStderrLogsKeepsLiveInstances(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding)
+	runtime.State.WorkingPath = t.TempDir()
+	runtime.appConfig = &app.Config{
+		PluginStderrLogEnabled:      true,
+		PluginStderrLogMaxFileSize:  1,
+		PluginStderrLogMaxInstances: 1,
+	}
+
+	// a long running instance and an instance in the warm pool
+	running := newPluginInstance("langgenius/a:1.0.0", nil, nil, nil, nil, runtime.appConfig)
+	assert.NoError(t, runtime.setupInstanceStderrLog(running))
+	assert.NoError(t, os.WriteFile(running.stderrLogPath, []byte("x"), 0644))
+	runtime.instances = append(runtime.instances, running)
+
+	warm := newPluginInstance("langgenius/a:1.0.0", nil, nil, nil, nil, runtime.appConfig)
+	assert.NoError(t, runtime.setupInstanceStderrLog(warm))
+	assert.NoError(t, os.WriteFile(warm.stderrLogPath, []byte("x"), 0644))
+	runtime.warmInstances = append(runtime.warmInstances, warm)
+
+	// an instance which has exited
+	exited := newPluginInstance("langgenius/a:1.0.0", nil, nil, nil, nil, runtime.appConfig)
+	assert.NoError(t, runtime.setupInstanceStderrLog(exited))
+	assert.NoError(t, os.WriteFile(exited.stderrLogPath, []byte("x"), 0644))
+
+	next := newPluginInstance("langgenius/a:1.0.0", nil, nil, nil, nil, runtime.appConfig)
+	assert.NoError(t, runtime.setupInstanceStderrLog(next))
+
+	// files of live instances survive even if they exceed the limit
+	assert.FileExists(t, running.stderrLogPath)
+	assert.FileExists(t, warm.stderrLogPath)
+	assert.NoFileExists(t, exited.stderrLogPath)
+}
+
+func TestInstanceStderrCapture(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding)
+	runtime.State.WorkingPath = t.TempDir()
+	runtime.appConfig = &app.Config{
+		PluginStderrLogEnabled:      true,
+		PluginStderrLogMaxFileSize:  1,
+		PluginStderrLogMaxInstances: 2,
+		PluginStderrTailSize:        16,
+	}
+
+	errReader, errWriter := io.Pipe()
+	instance := newPluginInstance("langgenius/a:1.0.0", nil, nil, nil, errReader, runtime.appConfig)
+	if !assert.NoError(t, runtime.setupInstanceStderrLog(instance)) {
+		return
+	}
+
+	traceback := "Traceback (most recent call last):\nValueError: boom\n"
+	go func() {
+		errWriter.Write([]byte(traceback))
+		errWriter.Close()
+	}()
+	instance.StartStderr()
+
+	// the whole stderr is persisted
+	content, err := os.ReadFile(instance.stderrLogPath)
+	assert.NoError(t, err)
+	assert.Equal(t, traceback, string(content))
+	assert.Equal(t, filepath.Join(runtime.State.WorkingPath, ".logs"), filepath.Dir(instance.stderrLogPath))
+
+	// only the tail is kept in memory, reports point to the file
+	report := instance.stderrReport()
+	assert.True(t, strings.Contains(report, "Error: boom\n"))
+	assert.False(t, strings.Contains(report, "Traceback"))
+	assert.True(t, strings.Contains(report, instance.stderrLogPath))
+}
@@ -4,18 +4,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"os/exec"
 	"slices"
 	"time"
 
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities/constants"
 	routinepkg "github.com/langgenius/dify-plugin-daemon/pkg/routine"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/routine"
 )
 
 // getCmd prepares the exec.Cmd for the plugin based on its language
-func (r *LocalPluginRuntime) getInstanceCmd() (*exec.Cmd, error) {
+func (r *LocalPluginRuntime) getInstanceCmd(environment []EnvironmentVariable) (*exec.Cmd, error) {
 	var cmd *exec.Cmd
 
 	switch r.Config.Meta.Runner.Language {
@@ -26,21 +26,29 @@ func (r *LocalPluginRuntime) getInstanceCmd() (*exec.Cmd, error) {
 		}
 		cmd = exec.Command(pythonPath, "-m", r.Config.Meta.Runner.Entrypoint)
 
+	case constants.Go:
+		binaryPath, err := r.getGoBinaryPath()
+		if err != nil {
+			return nil, err
+		}
+		cmd = exec.Command(binaryPath)
+
+	case constants.NodeJS:
+		nodePath, err := exec.LookPath(r.appConfig.NodeBinaryPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find node: %w", err)
+		}
+		entrypoint, err := r.getNodeEntrypointPath()
+		if err != nil {
+			return nil, err
+		}
+		cmd = exec.Command(nodePath, "--enable-source-maps", entrypoint)
+
 	default:
 		return nil, fmt.Errorf("unsupported language: %s", r.Config.Meta.Runner.Language)
 	}
 
-	cmd.Env = cmd.Environ()
-	if r.appConfig.HttpsProxy != "" {
-		cmd.Env = append(cmd.Env, fmt.Sprintf("HTTPS_PROXY=%s", r.appConfig.HttpsProxy))
-	}
-	if r.appConfig.HttpProxy != "" {
-		cmd.Env = append(cmd.Env, fmt.Sprintf("HTTP_PROXY=%s", r.appConfig.HttpProxy))
-	}
-	if r.appConfig.NoProxy != "" {
-		cmd.Env = append(cmd.Env, fmt.Sprintf("NO_PROXY=%s", r.appConfig.NoProxy))
-	}
-	cmd.Env = append(cmd.Env, "INSTALL_METHOD=local", "PATH="+os.Getenv("PATH"))
+	cmd.Env = environmentToList(environment)
 	cmd.Dir = r.State.WorkingPath
 	return cmd, nil
 }
@@ -73,21 +81,68 @@ func (r *LocalPluginRuntime) getInstanceStdio(
 
 // startNewInstance starts a new plugin instance
 func (r *LocalPluginRuntime) startNewInstance() error {
+	return r.launchInstance(false)
+}
+
+// launchInstance starts a new plugin instance and waits for its first heartbeat
+// a warm instance is kept in the warm pool instead of accepting requests once it's ready
+func (r *LocalPluginRuntime) launchInstance(warm bool) error {
 	r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
 		notifier.OnInstanceStarting()
 	})
 
 	// get the command to start the plugin
-	e, err := r.getInstanceCmd()
+	environment := r.buildInstanceEnvironment()
+	e, err := r.getInstanceCmd(environment)
+	if err != nil {
+		r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
+			notifier.OnInstanceLaunchFailed(nil, err)
+		})
+		return err
+	}
+
+	// isolate the instance according to the sandbox profile
+	cleanupSandbox, err := r.applySandbox(e)
+	if err != nil {
+		r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
+			notifier.OnInstanceLaunchFailed(nil, err)
+		})
+		return err
+	}
+
+	// limit resources of the instance
+	cgroup, err := r.prepareInstanceCgroup()
 	if err != nil {
+		cleanupSandbox()
 		r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
 			notifier.OnInstanceLaunchFailed(nil, err)
 		})
 		return err
 	}
+	if cgroup != nil {
+		cgroup.attach(e)
+	}
+
+	// cleanup cgroup after the instance exited
+	cleanupCgroup := func() {
+		if cgroup == nil {
+			return
+		}
+		routine.Submit(routinepkg.Labels{
+			routinepkg.RoutineLabelKeyModule:      "plugin_manager",
+			routinepkg.RoutineLabelRuntimeKeyType: "local",
+			routinepkg.RoutineLabelKeyMethod:      "RemoveCgroup",
+		}, func() {
+			if err := cgroup.remove(); err != nil {
+				log.Error("%s", err.Error())
+			}
+		})
+	}
 
 	stdin, stdout, stderr, err := r.getInstanceStdio(e)
 	if err != nil {
+		cleanupSandbox()
+		cleanupCgroup()
 		r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
 			notifier.OnInstanceLaunchFailed(nil, err)
 		})
@@ -102,8 +157,12 @@ func (r *LocalPluginRuntime) startNewInstance() error {
 	}
 
 	// start plugin process,
-	if err := e.Start(); err != nil {
+	err = e.Start()
+	// resources needed by the sandbox were inherited by the process, release them
+	cleanupSandbox()
+	if err != nil {
 		cleanupIOHolders()
+		cleanupCgroup()
 		r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
 			notifier.OnInstanceLaunchFailed(nil, err)
 		})
@@ -112,17 +171,46 @@ func (r *LocalPluginRuntime) startNewInstance() error {
 
 	// setup stdio
 	instance := newPluginInstance(r.Config.Identity(), e, stdin, stdout, stderr, r.appConfig)
+	instance.cgroup = cgroup
+	instance.environment = environment
+
+	// persist stderr, the instance still works without it
+	if err := r.setupInstanceStderrLog(instance); err != nil {
+		log.Error("failed to setup stderr log of plugin %s: %s", r.Config.Identity(), err.Error())
+	}
 
 	// setup lifecycle notifier
-	launchNotifier := newNotifierLifecycleSignal([]func(){cleanupIOHolders})
+	launchNotifier := newNotifierLifecycleSignal([]func(){cleanupIOHolders, cleanupCgroup})
 	instance.AddNotifier(launchNotifier)
 
+	// capture logs of the instance
+	r.captureInstanceLogs(instance)
+
+	// keep the last error, e.g. exit errors and failed liveness probes
+	instance.AddNotifier(&PluginInstanceNotifierTemplate{
+		OnInstanceErrorLogImpl: func(pi *PluginInstance, err error) {
+			r.recordLastError(err)
+		},
+	})
+
 	launchChannel := make(chan bool)
+	// closed if the instance exited before it's ready
+	exitedChannel := make(chan bool)
 
 	// setup launch notifier
 	instance.AddNotifier(&PluginInstanceNotifierTemplate{
 		// the first heartbeat will trigger this
 		OnInstanceReadyImpl: func(pi *PluginInstance) {
+			instance.readyAt = time.Now()
+			if warm {
+				// mark the instance as started
+				instance.started = true
+				// keep it aside until the scheduler needs capacity
+				r.addWarmInstance(instance)
+				close(launchChannel)
+				return
+			}
+
 			// notify plugin started
 			r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
 				notifier.OnInstanceReady(instance)
@@ -137,22 +225,32 @@ func (r *LocalPluginRuntime) startNewInstance() error {
 			close(launchChannel)
 		},
 		OnInstanceShutdownImpl: func(pi *PluginInstance) {
-			// remove the instance from the list
+			// remove the instance from the list, it may be in the warm pool
 			r.instanceLocker.Lock()
 			r.instances = slices.DeleteFunc(r.instances, func(instance *PluginInstance) bool {
 				return instance.instanceId == pi.instanceId
 			})
+			r.warmInstances = slices.DeleteFunc(r.warmInstances, func(instance *PluginInstance) bool {
+				return instance.instanceId == pi.instanceId
+			})
 			r.instanceLocker.Unlock()
 
+			// dead instances killed by probes are considered crashed as well
+			if instance.started && !instance.stopRequested.Load() {
+				r.onInstanceCrashed(instance)
+			}
+
 			if !instance.started {
 				// if the instance is not started, it means the plugin is not ready
 				// so we need to notify the caller that the plugin is not ready
+				err := instance.exitError()
+				if hint := r.sandboxExitHint(); hint != "" {
+					err = fmt.Errorf("%w, %s", err, hint)
+				}
 				r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
-					notifier.OnInstanceLaunchFailed(
-						instance,
-						fmt.Errorf("plugin failed to start: %v", instance.Error()),
-					)
+					notifier.OnInstanceLaunchFailed(instance, err)
 				})
+				close(exitedChannel)
 			}
 		},
 	})
@@ -196,6 +294,10 @@ func (r *LocalPluginRuntime) startNewInstance() error {
 	case <-timeout.C:
 		instance.Stop()
 		return fmt.Errorf("failed to start plugin as no heartbeat received")
+	case <-exitedChannel:
+		// no need to wait for the heartbeat, the instance is gone
+		err = instance.exitError()
+		return err
 	case <-launchChannel:
 		// nop
 	}
@@ -212,16 +314,34 @@ func (r *LocalPluginRuntime) startNewInstance() error {
 		},
 	)
 
+	// probe if the instance still handles requests
+	if r.appConfig.PluginLocalLivenessProbeEnabled {
+		routine.Submit(
+			routinepkg.Labels{
+				routinepkg.RoutineLabelKeyModule:      "plugin_manager",
+				routinepkg.RoutineLabelRuntimeKeyType: "local",
+				routinepkg.RoutineLabelKeyMethod:      "probeLiveness",
+			},
+			func() {
+				instance.probeLiveness(newLivenessProbeConfig(r.appConfig))
+			},
+		)
+	}
+
 	success = true
 	return nil
 }
 
 func (r *LocalPluginRuntime) gracefullyStopLowestLoadInstance() error {
 	// get the instance with the lowest load
-	instance, err := r.pickLowestLoadInstance()
-	if err != nil {
-		return err
+	// whatever the strategy is, always stop the instance with the least in-flight sessions
+	r.instanceLocker.RLock()
+	if len(r.instances) == 0 {
+		r.instanceLocker.RUnlock()
+		return ErrNoProperInstance
 	}
+	instance := r.pickLeastOutstandingInstance()
+	r.instanceLocker.RUnlock()
 
 	// gracefully shutdown the instance
 	instance.GracefulStop(time.Duration(r.appConfig.PluginMaxExecutionTimeout) * time.Second)
@@ -1,7 +1,9 @@
 package local_runtime
 
 import (
+	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager/basic_runtime"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
@@ -32,6 +34,13 @@ type LocalPluginRuntime struct {
 	// always keep the nums of instances equal to instanceNums
 	instances []*PluginInstance
 
+	// spare instances launched ahead of time, promoted into `instances` once more capacity is needed
+	// protected by `instanceLocker` as well
+	warmInstances []*PluginInstance
+	// warm instances being launched
+	// NOTE: use atomic.AddInt32 and atomic.LoadInt32 to update and read it
+	warmLaunching int32
+
 	// instanceLocker
 	instanceLocker *sync.RWMutex
 
@@ -39,6 +48,40 @@ type LocalPluginRuntime struct {
 	// NOTE: use atomic.AddInt64 and atomic.LoadInt64 to update and read it
 	roundRobinIndex int64
 
+	// strategy used to pick an instance for a new session
+	loadBalancingStrategy LoadBalancingStrategy
+
+	// autoscaler adjusts `instanceNums` according to the load, nil if disabled
+	autoscaler *Autoscaler
+
+	// decides when failed instances are replaced
+	restartPolicy *RestartPolicy
+
+	// the last error reported by the runtime or its instances
+	lastError atomic.Pointer[RuntimeError]
+
+	// logs of instances, nil if not captured
+	logBuffer *PluginLogBuffer
+
+	// shared python environments, nil if disabled
+	pythonEnvironmentCache *PythonEnvironmentCache
+
+	// sandbox profile applied to new instances
+	sandboxProfile SandboxProfile
+
+	// environment variables defined by operators, applied to new instances
+	pluginEnvironment       map[string]string
+	installationEnvironment map[string]string
+	extraEnvironmentLock    sync.RWMutex
+
+	// moving average of first response latency in nanoseconds
+	// NOTE: use atomic.LoadInt64 and atomic.CompareAndSwapInt64 to update and read it
+	firstResponseEWMA int64
+
+	// when a session started or finished the last time in nanoseconds, used to stop idle runtimes
+	// NOTE: use atomic.LoadInt64 and atomic.StoreInt64 to update and read it
+	lastSessionAt int64
+
 	// schedule status
 	scheduleStatus int32
 
@@ -52,6 +95,11 @@ type LocalPluginRuntimeConfig struct {
 	UvPath                string
 }
 
+// pluginID returns the plugin id of the runtime, formatted as author/name
+func (r *LocalPluginRuntime) pluginID() string {
+	return fmt.Sprintf("%s/%s", r.Config.Author, r.Config.Name)
+}
+
 // Type returns the runtime type of the plugin
 func (r *LocalPluginRuntime) Type() plugin_entities.PluginRuntimeType {
 	return plugin_entities.PLUGIN_RUNTIME_TYPE_LOCAL
@@ -0,0 +1,100 @@
+package local_runtime
+
+import (
+	"sync/atomic"
+
+	routinepkg "github.com/langgenius/dify-plugin-daemon/pkg/routine"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/routine"
+)
+
+// addWarmInstance puts a ready instance into the warm pool
+// an instance getting ready after the runtime stopped is shutdown instead
+// checks are done under `instanceLocker`, the same lock taken by `stopWarmInstances` and shutdown notifiers,
+// so that the instance is either stopped with the pool or never added
+func (r *LocalPluginRuntime) addWarmInstance(instance *PluginInstance) {
+	r.instanceLocker.Lock()
+	defer r.instanceLocker.Unlock()
+
+	// it exited before getting here, shutdown notifiers found nothing to remove
+	if instance.shutdown.Load() {
+		return
+	}
+
+	// the schedule loop is stopped before `stopWarmInstances`, the pool is stopped or being stopped
+	if atomic.LoadInt32(&r.scheduleStatus) != ScheduleStatusRunning {
+		instance.Stop()
+		return
+	}
+
+	r.warmInstances = append(r.warmInstances, instance)
+}
+
+// promoteWarmInstance moves a warm instance into `instances`, returns false if the pool is empty
+func (r *LocalPluginRuntime) promoteWarmInstance() bool {
+	r.instanceLocker.Lock()
+	if len(r.warmInstances) == 0 {
+		r.instanceLocker.Unlock()
+		return false
+	}
+	instance := r.warmInstances[0]
+	r.warmInstances = r.warmInstances[1:]
+	r.instances = append(r.instances, instance)
+	r.instanceLocker.Unlock()
+
+	// from the view of callers, the instance is ready now
+	r.WalkNotifiers(func(notifier PluginRuntimeNotifier) {
+		notifier.OnInstanceReady(instance)
+	})
+	return true
+}
+
+// refillWarmPool launches a spare instance in background if the pool is not full
+// spare instances count toward the replica limit and follow the restart policy like others
+func (r *LocalPluginRuntime) refillWarmPool() {
+	size := r.appConfig.PluginLocalWarmPoolSize
+	if size <= 0 {
+		return
+	}
+
+	r.instanceLocker.RLock()
+	warm := len(r.warmInstances) + int(atomic.LoadInt32(&r.warmLaunching))
+	total := len(r.instances) + warm
+	r.instanceLocker.RUnlock()
+
+	if warm >= size {
+		return
+	}
+	if r.autoscaler != nil && total >= int(r.autoscaler.config.MaxReplicas) {
+		return
+	}
+	if !r.allowInstanceStart() {
+		return
+	}
+
+	atomic.AddInt32(&r.warmLaunching, 1)
+	routine.Submit(routinepkg.Labels{
+		routinepkg.RoutineLabelKeyModule: "local_runtime",
+		routinepkg.RoutineLabelKeyMethod: "refillWarmPool",
+	}, func() {
+		defer atomic.AddInt32(&r.warmLaunching, -1)
+		if err := r.launchInstance(true); err != nil {
+			r.recordInstanceFailure(0)
+			r.recordLastError(err)
+			log.Warn("failed to launch a warm instance of plugin %s: %s", r.Config.Identity(), err.Error())
+		}
+	})
+}
+
+// stopWarmInstances shuts down all instances in the warm pool
+// instances getting ready later are stopped by `addWarmInstance` as the schedule loop is stopped
+func (r *LocalPluginRuntime) stopWarmInstances() {
+	r.instanceLocker.Lock()
+	instances := r.warmInstances
+	r.warmInstances = nil
+	r.instanceLocker.Unlock()
+
+	for _, instance := range instances {
+		instance.Stop()
+	}
+}
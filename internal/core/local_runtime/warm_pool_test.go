@@ -0,0 +1,77 @@
+package local_runtime
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromoteWarmInstance(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding, newTestInstance("active", 0))
+	runtime.notifierLock = &sync.Mutex{}
+
+	ready := []string{}
+	runtime.AddNotifier(&PluginRuntimeNotifierTemplate{
+		OnInstanceReadyImpl: func(instance *PluginInstance) {
+			ready = append(ready, instance.instanceId)
+		},
+	})
+
+	assert.False(t, runtime.promoteWarmInstance())
+
+	runtime.warmInstances = []*PluginInstance{newTestInstance("warm", 0)}
+	assert.True(t, runtime.promoteWarmInstance())
+	assert.Empty(t, runtime.warmInstances)
+	assert.Len(t, runtime.instances, 2)
+	// callers see the instance getting ready when it's promoted
+	assert.Equal(t, []string{"warm"}, ready)
+
+	instance, err := runtime.pickLowestLoadInstance()
+	assert.NoError(t, err)
+	assert.Contains(t, []string{"active", "warm"}, instance.instanceId)
+}
+
+func TestRefillWarmPoolLimits(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding, newTestInstance("a", 0), newTestInstance("b", 0))
+	runtime.appConfig = &app.Config{PluginLocalWarmPoolSize: 1}
+
+	// the pool is full
+	runtime.warmInstances = []*PluginInstance{newTestInstance("warm", 0)}
+	runtime.refillWarmPool()
+	assert.Equal(t, int32(0), runtime.warmLaunching)
+
+	// spare instances count toward max replicas
+	runtime.warmInstances = nil
+	runtime.autoscaler = newAutoscaler(AutoscalerConfig{MinReplicas: 1, MaxReplicas: 2})
+	runtime.refillWarmPool()
+	assert.Equal(t, int32(0), runtime.warmLaunching)
+}
+
+func TestWarmInstanceAfterRuntimeStopped(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding)
+	runtime.scheduleStatus = ScheduleStatusStopped
+
+	instance := newProbedInstance(func(*PluginInstance, string) {})
+	runtime.addWarmInstance(instance)
+
+	assert.Empty(t, runtime.warmInstances)
+	assert.True(t, instance.stopRequested.Load())
+}
+
+func TestWarmInstanceAfterInstanceShutdown(t *testing.T) {
+	runtime := newTestRuntime(LoadBalancingStrategyLeastOutstanding)
+	runtime.scheduleStatus = ScheduleStatusRunning
+
+	// the instance exited before getting ready, nothing would remove it from the pool
+	instance := newProbedInstance(func(*PluginInstance, string) {})
+	instance.shutdown.Store(true)
+	runtime.addWarmInstance(instance)
+	assert.Empty(t, runtime.warmInstances)
+
+	instance = newProbedInstance(func(*PluginInstance, string) {})
+	runtime.addWarmInstance(instance)
+	assert.Len(t, runtime.warmInstances, 1)
+	assert.False(t, instance.stopRequested.Load())
+}
@@ -67,3 +67,11 @@ func (t *ClusterTunnel) OnLocalRuntimeStopped(
 ) {
 	// NOP
 }
+
+func (t *ClusterTunnel) OnLocalRuntimeInstanceCrashed(
+	runtime *local_runtime.LocalPluginRuntime,
+	instance *local_runtime.PluginInstance,
+	err error,
+) {
+	// NOP
+}
@@ -0,0 +1,117 @@
+package plugin_manager
+
+import (
+	"github.com/langgenius/dify-plugin-daemon/internal/core/debugging_runtime"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/internal/events"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+)
+
+// implement events publisher for interface `controlpanel.ControlPanelNotifier`
+type EventPublisher struct{}
+
+func (p *EventPublisher) OnLocalRuntimeStarting(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) {
+	events.Publish(events.Event{
+		Type:                   events.EVENT_TYPE_LOCAL_RUNTIME_STARTING,
+		PluginUniqueIdentifier: pluginUniqueIdentifier.String(),
+	})
+}
+
+func (p *EventPublisher) OnLocalRuntimeReady(
+	runtime *local_runtime.LocalPluginRuntime,
+) {
+	p.publishLocalRuntimeEvent(events.EVENT_TYPE_LOCAL_RUNTIME_READY, runtime, nil)
+}
+
+func (p *EventPublisher) OnLocalRuntimeStartFailed(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	err error,
+) {
+	events.Publish(events.Event{
+		Type:                   events.EVENT_TYPE_LOCAL_RUNTIME_START_FAILED,
+		PluginUniqueIdentifier: pluginUniqueIdentifier.String(),
+		Data: map[string]any{
+			"error": err.Error(),
+		},
+	})
+}
+
+func (p *EventPublisher) OnLocalRuntimeStop(
+	runtime *local_runtime.LocalPluginRuntime,
+) {
+	p.publishLocalRuntimeEvent(events.EVENT_TYPE_LOCAL_RUNTIME_STOPPING, runtime, nil)
+}
+
+func (p *EventPublisher) OnLocalRuntimeStopped(
+	runtime *local_runtime.LocalPluginRuntime,
+) {
+	p.publishLocalRuntimeEvent(events.EVENT_TYPE_LOCAL_RUNTIME_STOPPED, runtime, nil)
+}
+
+func (p *EventPublisher) OnLocalRuntimeScaleUp(
+	runtime *local_runtime.LocalPluginRuntime,
+	instanceNums int32,
+) {
+	p.publishLocalRuntimeEvent(events.EVENT_TYPE_LOCAL_RUNTIME_SCALED_UP, runtime, map[string]any{
+		"instance_nums": instanceNums,
+	})
+}
+
+func (p *EventPublisher) OnLocalRuntimeScaleDown(
+	runtime *local_runtime.LocalPluginRuntime,
+	instanceNums int32,
+) {
+	p.publishLocalRuntimeEvent(events.EVENT_TYPE_LOCAL_RUNTIME_SCALED_DOWN, runtime, map[string]any{
+		"instance_nums": instanceNums,
+	})
+}
+
+func (p *EventPublisher) OnLocalRuntimeInstanceCrashed(
+	runtime *local_runtime.LocalPluginRuntime,
+	instance *local_runtime.PluginInstance,
+	err error,
+) {
+	p.publishLocalRuntimeEvent(events.EVENT_TYPE_LOCAL_RUNTIME_INSTANCE_CRASHED, runtime, map[string]any{
+		"instance_id": instance.ID(),
+		"error":       err.Error(),
+	})
+}
+
+func (p *EventPublisher) OnDebuggingRuntimeConnected(
+	runtime *debugging_runtime.RemotePluginRuntime,
+) {
+	p.publishDebuggingRuntimeEvent(events.EVENT_TYPE_DEBUGGING_RUNTIME_CONNECTED, runtime)
+}
+
+func (p *EventPublisher) OnDebuggingRuntimeDisconnected(
+	runtime *debugging_runtime.RemotePluginRuntime,
+) {
+	p.publishDebuggingRuntimeEvent(events.EVENT_TYPE_DEBUGGING_RUNTIME_DISCONNECTED, runtime)
+}
+
+func (p *EventPublisher) publishLocalRuntimeEvent(
+	eventType events.EventType,
+	runtime *local_runtime.LocalPluginRuntime,
+	data map[string]any,
+) {
+	identity, _ := runtime.Identity()
+	events.Publish(events.Event{
+		Type:                   eventType,
+		PluginUniqueIdentifier: identity.String(),
+		Data:                   data,
+	})
+}
+
+func (p *EventPublisher) publishDebuggingRuntimeEvent(
+	eventType events.EventType,
+	runtime *debugging_runtime.RemotePluginRuntime,
+) {
+	identity, _ := runtime.Identity()
+	events.Publish(events.Event{
+		Type:                   eventType,
+		TenantID:               runtime.TenantId(),
+		PluginUniqueIdentifier: identity.String(),
+	})
+}
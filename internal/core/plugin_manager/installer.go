@@ -317,7 +317,7 @@ func (p *PluginManager) installLocal(
 		}
 
 		ticker := time.NewTicker(5 * time.Second)
-		timeout := time.Duration(p.config.PythonEnvInitTimeout) * time.Second
+		timeout := runtime.EnvironmentInitTimeout()
 		timer := time.NewTimer(timeout)
 
 		for {
@@ -1,7 +1,9 @@
 package plugin_manager
 
 import (
+	"errors"
 	"fmt"
+	"maps"
 	"strings"
 
 	lru "github.com/hashicorp/golang-lru/v2"
@@ -11,7 +13,9 @@ import (
 	"github.com/langgenius/dify-plugin-daemon/internal/core/dify_invocation/calldify"
 	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager/media_transport"
 	serverless "github.com/langgenius/dify-plugin-daemon/internal/core/serverless_connector"
+	"github.com/langgenius/dify-plugin-daemon/internal/db"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
 	"github.com/langgenius/dify-plugin-daemon/pkg/plugin_packager/decoder"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/cache"
@@ -85,6 +89,77 @@ func InitGlobalManager(oss oss.OSS, config *app.Config) *PluginManager {
 
 	// mount logger to control panel
 	manager.controlPanel.AddNotifier(&controlpanel.StandardLogger{})
+	// publish lifecycle events to webhook subscriptions
+	manager.controlPanel.AddNotifier(&EventPublisher{})
+
+	// install source of a plugin is persisted when it's installed the first time
+	manager.controlPanel.SetLocalPluginSourceResolver(func(
+		pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	) (string, error) {
+		plugin, err := db.GetOne[models.Plugin](
+			db.Equal("plugin_unique_identifier", pluginUniqueIdentifier.String()),
+		)
+		if err != nil {
+			return "", err
+		}
+		return plugin.Source, nil
+	})
+
+	// environment variables of local plugins are defined by operators
+	manager.controlPanel.SetLocalPluginEnvironmentResolver(func(
+		pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	) (map[string]string, map[string]string, error) {
+		environments, err := db.GetAll[models.PluginEnvironment](
+			db.Equal("plugin_id", pluginUniqueIdentifier.PluginID()),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		plugin := map[string]string{}
+		installation := map[string]string{}
+		for _, environment := range environments {
+			switch environment.PluginUniqueIdentifier {
+			case "":
+				maps.Copy(plugin, environment.Variables)
+			case pluginUniqueIdentifier.String():
+				maps.Copy(installation, environment.Variables)
+			}
+		}
+		return plugin, installation, nil
+	})
+
+	// quarantines applied to the whole cluster are persisted, a node started later respects them as well
+	manager.controlPanel.SetLocalPluginQuarantineResolver(func(
+		pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	) (*controlpanel.LocalPluginQuarantine, error) {
+		quarantine, err := db.GetOne[models.PluginQuarantine](
+			db.Equal("plugin_unique_identifier", pluginUniqueIdentifier.String()),
+		)
+		if errors.Is(err, db.ErrDatabaseNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &controlpanel.LocalPluginQuarantine{
+			Reason:        quarantine.Reason,
+			QuarantinedAt: quarantine.CreatedAt,
+		}, nil
+	})
+
+	// pinned plugins are always running when plugins are launched on demand
+	manager.controlPanel.SetLocalPluginPinResolver(func() ([]string, error) {
+		pins, err := db.GetAll[models.PluginPin]()
+		if err != nil {
+			return nil, err
+		}
+		pluginIDs := make([]string, 0, len(pins))
+		for _, pin := range pins {
+			pluginIDs = append(pluginIDs, pin.PluginID)
+		}
+		return pluginIDs, nil
+	})
 
 	return manager
 }
@@ -156,6 +231,7 @@ func (p *PluginManager) BackwardsInvocation() dify_invocation.BackwardsInvocatio
 }
 
 // check if the plugin is already running on this node
+// in on demand mode, a local plugin which is not running is launched here and the caller waits for it
 func (c *PluginManager) NeedRedirecting(
 	identity plugin_entities.PluginUniqueIdentifier,
 ) (bool, error) {
@@ -174,6 +250,13 @@ func (c *PluginManager) NeedRedirecting(
 	} else if c.config.Platform == app.PLATFORM_LOCAL {
 		// under local mode, check if the plugin is already running on this node
 		_, err := c.controlPanel.GetPluginRuntime(identity)
+		if err != nil && c.controlPanel.LocalPluginOnDemand() {
+			// plugins are not running until they are dispatched, launch it on this node
+			if err := c.controlPanel.LaunchLocalPluginOnDemand(identity); err != nil {
+				return true, err
+			}
+			return false, nil
+		}
 		if err != nil {
 			// not found on this node, need to redirecting
 			return true, err
@@ -2,6 +2,7 @@ package media_transport
 
 import (
 	"path"
+	"strings"
 
 	"github.com/langgenius/dify-cloud-kit/oss"
 )
@@ -30,3 +31,30 @@ func (m *PackageBucket) Delete(name string) error {
 	// delete from storage
 	return m.oss.Delete(path.Join(m.packagePath, name))
 }
+
+// List lists names of all the packages in the package bucket
+func (m *PackageBucket) List() ([]string, error) {
+	paths, err := m.oss.List(m.packagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if p.IsDir {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(p.Path, m.packagePath), "/")
+		// skip hidden files
+		if name == "" || strings.HasPrefix(path.Base(name), ".") {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// State returns size and last modified time of a package
+func (m *PackageBucket) State(name string) (oss.OSSState, error) {
+	return m.oss.State(path.Join(m.packagePath, name))
+}
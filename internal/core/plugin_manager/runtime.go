@@ -3,8 +3,11 @@ package plugin_manager
 import (
 	"errors"
 
+	controlpanel "github.com/langgenius/dify-plugin-daemon/internal/core/control_panel"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/stream"
 )
 
 // automatically fetching a correct runtime according to the platform
@@ -49,3 +52,151 @@ func (p *PluginManager) ShutdownLocalPluginGracefully(
 ) (<-chan error, error) {
 	return p.controlPanel.ShutdownLocalPluginGracefully(pluginUniqueIdentifier)
 }
+
+// DrainLocalPlugin shuts down a local plugin replaced by `successor` once its in-flight sessions are finished
+// new sessions are dispatched to the successor meanwhile
+func (p *PluginManager) DrainLocalPlugin(
+	original plugin_entities.PluginUniqueIdentifier,
+	successor plugin_entities.PluginUniqueIdentifier,
+	onProgress func(inFlightSessions int),
+) (<-chan error, error) {
+	return p.controlPanel.DrainLocalPlugin(original, successor, onProgress)
+}
+
+// SetLocalPluginSource records where a plugin is installed from
+// it decides the sandbox profile of the plugin on local platform
+func (p *PluginManager) SetLocalPluginSource(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	source string,
+) {
+	p.controlPanel.SetLocalPluginSource(pluginUniqueIdentifier, source)
+}
+
+// ReloadLocalPluginEnvironment applies environment variables of a plugin to its running runtimes
+// only new instances use the new variables
+func (p *PluginManager) ReloadLocalPluginEnvironment(pluginID string) {
+	p.controlPanel.ReloadLocalPluginEnvironment(pluginID)
+}
+
+// LocalPluginInstanceEnvironments returns the effective environment of all instances of a local plugin
+func (p *PluginManager) LocalPluginInstanceEnvironments(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) ([]local_runtime.InstanceEnvironment, error) {
+	return p.controlPanel.LocalPluginInstanceEnvironments(pluginUniqueIdentifier)
+}
+
+// LocalPluginRuntimeState returns the runtime state of a local plugin on this node
+func (p *PluginManager) LocalPluginRuntimeState(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) (plugin_entities.PluginRuntimeState, error) {
+	return p.controlPanel.LocalPluginRuntimeState(pluginUniqueIdentifier)
+}
+
+// LocalPluginRestartPolicyStatus returns the restart policy state of a local plugin
+func (p *PluginManager) LocalPluginRestartPolicyStatus(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) (local_runtime.RestartPolicyStatus, error) {
+	return p.controlPanel.LocalPluginRestartPolicyStatus(pluginUniqueIdentifier)
+}
+
+// ResetLocalPluginRestartPolicy resumes respawning instances of a crash-looping local plugin
+func (p *PluginManager) ResetLocalPluginRestartPolicy(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) error {
+	return p.controlPanel.ResetLocalPluginRestartPolicy(pluginUniqueIdentifier)
+}
+
+// QueryLocalPluginLogs returns buffered logs of a local plugin on this node
+func (p *PluginManager) QueryLocalPluginLogs(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	query local_runtime.PluginLogQuery,
+) ([]local_runtime.PluginLogEntry, error) {
+	return p.controlPanel.QueryLocalPluginLogs(pluginUniqueIdentifier, query)
+}
+
+// TailLocalPluginLogs streams buffered and new logs of a local plugin on this node
+func (p *PluginManager) TailLocalPluginLogs(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	query local_runtime.PluginLogQuery,
+) (*stream.Stream[local_runtime.PluginLogEntry], error) {
+	return p.controlPanel.TailLocalPluginLogs(pluginUniqueIdentifier, query)
+}
+
+// RestartLocalPlugin gracefully shuts down a local plugin on this node and launches it again
+func (p *PluginManager) RestartLocalPlugin(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) (<-chan error, error) {
+	return p.controlPanel.RestartLocalPlugin(pluginUniqueIdentifier)
+}
+
+// StopLocalPlugin gracefully shuts down a local plugin on this node, it's not launched again until started
+func (p *PluginManager) StopLocalPlugin(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) (<-chan error, error) {
+	return p.controlPanel.StopLocalPlugin(pluginUniqueIdentifier)
+}
+
+// StartLocalPlugin launches a stopped local plugin on this node
+func (p *PluginManager) StartLocalPlugin(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) (<-chan error, error) {
+	return p.controlPanel.StartLocalPlugin(pluginUniqueIdentifier)
+}
+
+// ScaleLocalPlugin sets replicas of a local plugin on this node
+func (p *PluginManager) ScaleLocalPlugin(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	replicas int32,
+) error {
+	return p.controlPanel.ScaleLocalPlugin(pluginUniqueIdentifier, replicas)
+}
+
+// QuarantineLocalPlugin stops a local plugin on this node and prevents it from being launched until released
+func (p *PluginManager) QuarantineLocalPlugin(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	reason string,
+) (<-chan error, error) {
+	return p.controlPanel.QuarantineLocalPlugin(pluginUniqueIdentifier, reason)
+}
+
+// ReleaseLocalPlugin lifts the quarantine of a local plugin on this node and launches it
+func (p *PluginManager) ReleaseLocalPlugin(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) (<-chan error, error) {
+	return p.controlPanel.ReleaseLocalPlugin(pluginUniqueIdentifier)
+}
+
+// RuntimeInventory lists plugin runtimes of this node
+// serverless runtimes are not running on any node, they are listed from the database on serverless platform
+func (p *PluginManager) RuntimeInventory() ([]controlpanel.RuntimeInventory, error) {
+	inventories := p.controlPanel.RuntimeInventory()
+	if p.config.Platform != app.PLATFORM_SERVERLESS {
+		return inventories, nil
+	}
+
+	serverlessInventories, err := p.serverlessRuntimeInventory()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(inventories, serverlessInventories...), nil
+}
+
+// CollectDisk removes working paths, stderr logs and packages of plugins no longer installed on this node
+// nothing is removed by a dry run
+func (p *PluginManager) CollectDisk(dryRun bool) (controlpanel.DiskGCReport, error) {
+	return p.controlPanel.CollectDisk(dryRun)
+}
+
+// DebuggingSessions lists debugging plugins connected to this node, all tenants are listed if `tenantId` is empty
+func (p *PluginManager) DebuggingSessions(tenantId string) []controlpanel.DebuggingSession {
+	return p.controlPanel.DebuggingSessions(tenantId)
+}
+
+// KickDebuggingRuntime disconnects a debugging plugin of a tenant from this node
+func (p *PluginManager) KickDebuggingRuntime(
+	tenantId string,
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) error {
+	return p.controlPanel.KickDebuggingRuntime(tenantId, pluginUniqueIdentifier)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"time"
 
+	controlpanel "github.com/langgenius/dify-plugin-daemon/internal/core/control_panel"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
 	"github.com/langgenius/dify-plugin-daemon/internal/core/serverless_runtime"
 	"github.com/langgenius/dify-plugin-daemon/internal/db"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
@@ -93,3 +95,28 @@ func (p *PluginManager) clearServerlessRuntimeCache(
 	_, err := cache.Del(p.getServerlessRuntimeCacheKey(identity))
 	return err
 }
+
+func (p *PluginManager) serverlessRuntimeInventory() ([]controlpanel.RuntimeInventory, error) {
+	runtimes, err := db.GetAll[models.ServerlessRuntime]()
+	if err != nil {
+		return nil, err
+	}
+
+	inventories := make([]controlpanel.RuntimeInventory, 0, len(runtimes))
+	for _, model := range runtimes {
+		identifier, err := plugin_entities.NewPluginUniqueIdentifier(model.PluginUniqueIdentifier)
+		if err != nil {
+			continue
+		}
+		inventories = append(inventories, controlpanel.RuntimeInventory{
+			PluginUniqueIdentifier: identifier,
+			RuntimeType:            plugin_entities.PLUGIN_RUNTIME_TYPE_SERVERLESS,
+			Status:                 plugin_entities.PLUGIN_RUNTIME_STATUS_ACTIVE,
+			Instances:              []local_runtime.InstanceInventory{},
+			FunctionName:           model.FunctionName,
+			FunctionURL:            model.FunctionURL,
+		})
+	}
+
+	return inventories, nil
+}
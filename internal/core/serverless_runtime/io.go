@@ -121,7 +121,7 @@ func (r *ServerlessPluginRuntime) Write(
 						}),
 					})
 				},
-				func(message string) {},
+				func(sessionId string, event plugin_entities.PluginLogEvent) {},
 			)
 		}
 
@@ -23,6 +23,11 @@ func autoMigrate() error {
 		models.AgentStrategyInstallation{},
 		models.TriggerInstallation{},
 		models.PluginReadme{},
+		models.PluginEnvironment{},
+		models.PluginRuntimeAudit{},
+		models.PluginQuarantine{},
+		models.PluginPin{},
+		models.EventSubscription{},
 	)
 
 	if err != nil {
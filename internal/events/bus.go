@@ -0,0 +1,161 @@
+package events
+
+import (
+	"errors"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/cache"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/parser"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrEventBusFull = errors.New("event bus is full, event dropped")
+)
+
+// Message is an event received from the bus, it must be acknowledged once it's handled
+type Message struct {
+	ID    string
+	Event Event
+}
+
+// Bus carries events from publishers to the dispatcher
+type Bus interface {
+	// Publish appends an event to the bus, it never blocks for long
+	Publish(event Event) error
+	// Receive waits for events until `timeout` is reached, no events is not an error
+	Receive(timeout time.Duration) ([]Message, error)
+	// Ack marks messages as handled, they are not received again
+	Ack(messages ...Message) error
+}
+
+// memoryBus keeps events of this node in a buffered channel, they are lost once the node exits
+type memoryBus struct {
+	events chan Message
+}
+
+func newMemoryBus(bufferSize int) *memoryBus {
+	return &memoryBus{
+		events: make(chan Message, bufferSize),
+	}
+}
+
+func (b *memoryBus) Publish(event Event) error {
+	select {
+	case b.events <- Message{ID: event.ID, Event: event}:
+		return nil
+	default:
+		return ErrEventBusFull
+	}
+}
+
+func (b *memoryBus) Receive(timeout time.Duration) ([]Message, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case message := <-b.events:
+		messages := []Message{message}
+		// take what's already there without waiting
+		for len(messages) < maxReceiveCount {
+			select {
+			case message := <-b.events:
+				messages = append(messages, message)
+			default:
+				return messages, nil
+			}
+		}
+		return messages, nil
+	case <-timer.C:
+		return nil, nil
+	}
+}
+
+func (b *memoryBus) Ack(messages ...Message) error {
+	return nil
+}
+
+const (
+	redisStreamKey   = "events"
+	redisStreamGroup = "webhook"
+	maxReceiveCount  = 64
+)
+
+// redisBus shares a redis stream across the cluster, all nodes consume it as a group
+// so that each event is delivered once, events held by a gone node are taken over by others
+type redisBus struct {
+	consumer    string
+	maxLen      int64
+	claimIdle   time.Duration
+	initialized bool
+}
+
+func newRedisBus(consumer string, maxLen int64, claimIdle time.Duration) *redisBus {
+	return &redisBus{
+		consumer:  consumer,
+		maxLen:    maxLen,
+		claimIdle: claimIdle,
+	}
+}
+
+func (b *redisBus) Publish(event Event) error {
+	_, err := cache.StreamAdd(redisStreamKey, map[string]any{
+		"event": parser.MarshalJson(event),
+	}, b.maxLen)
+	return err
+}
+
+func (b *redisBus) Receive(timeout time.Duration) ([]Message, error) {
+	if !b.initialized {
+		if err := cache.StreamCreateGroup(redisStreamKey, redisStreamGroup); err != nil {
+			return nil, err
+		}
+		b.initialized = true
+	}
+
+	claimed, err := cache.StreamAutoClaim(
+		redisStreamKey, redisStreamGroup, b.consumer, b.claimIdle, maxReceiveCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(claimed) > 0 {
+		return b.parseMessages(claimed), nil
+	}
+
+	received, err := cache.StreamReadGroup(
+		redisStreamKey, redisStreamGroup, b.consumer, maxReceiveCount, timeout,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.parseMessages(received), nil
+}
+
+func (b *redisBus) parseMessages(received []redis.XMessage) []Message {
+	messages := make([]Message, 0, len(received))
+	for _, message := range received {
+		content, _ := message.Values["event"].(string)
+		event, err := parser.UnmarshalJson[Event](content)
+		if err != nil {
+			// a malformed event will never be handled, acknowledge it to drop it
+			_ = cache.StreamAck(redisStreamKey, redisStreamGroup, []string{message.ID})
+			continue
+		}
+		messages = append(messages, Message{ID: message.ID, Event: event})
+	}
+	return messages
+}
+
+func (b *redisBus) Ack(messages ...Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return cache.StreamAck(redisStreamKey, redisStreamGroup, ids)
+}
@@ -0,0 +1,157 @@
+package events
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/db"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
+	routinepkg "github.com/langgenius/dify-plugin-daemon/pkg/routine"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/routine"
+)
+
+const (
+	receiveTimeout      = 5 * time.Second
+	receiveErrorBackoff = 5 * time.Second
+)
+
+// Dispatcher receives events from the bus and delivers them to matching webhook subscriptions
+// events are delivered concurrently, receivers must not rely on their order
+type Dispatcher struct {
+	bus            Bus
+	client         *http.Client
+	timeout        time.Duration
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	semaphore      chan struct{}
+	subscriptions  func() ([]models.EventSubscription, error)
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewDispatcher(
+	bus Bus,
+	timeout time.Duration,
+	maxAttempts int,
+	initialBackoff time.Duration,
+	maxBackoff time.Duration,
+	concurrency int,
+) *Dispatcher {
+	return &Dispatcher{
+		bus:            bus,
+		client:         &http.Client{},
+		timeout:        timeout,
+		maxAttempts:    max(maxAttempts, 1),
+		initialBackoff: initialBackoff,
+		maxBackoff:     maxBackoff,
+		semaphore:      make(chan struct{}, max(concurrency, 1)),
+		subscriptions:  enabledSubscriptions,
+		stop:           make(chan struct{}),
+	}
+}
+
+func enabledSubscriptions() ([]models.EventSubscription, error) {
+	return db.GetAll[models.EventSubscription](
+		db.Equal("enabled", true),
+	)
+}
+
+// Launch starts consuming the bus in background until `Stop` is called
+func (d *Dispatcher) Launch() {
+	routine.Submit(routinepkg.Labels{
+		routinepkg.RoutineLabelKeyModule: "events",
+		routinepkg.RoutineLabelKeyMethod: "Dispatcher.Launch",
+	}, d.loop)
+}
+
+// Stop stops consuming the bus and waits for deliveries in progress
+// pending retries are abandoned, with the redis bus they are taken over by other nodes
+func (d *Dispatcher) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.stop)
+	})
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) loop() {
+	for {
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+
+		messages, err := d.bus.Receive(receiveTimeout)
+		if err != nil {
+			log.Error("failed to receive events: %s", err.Error())
+			select {
+			case <-time.After(receiveErrorBackoff):
+			case <-d.stop:
+				return
+			}
+			continue
+		}
+
+		for _, message := range messages {
+			select {
+			case d.semaphore <- struct{}{}:
+			case <-d.stop:
+				return
+			}
+
+			d.wg.Add(1)
+			routine.Submit(routinepkg.Labels{
+				routinepkg.RoutineLabelKeyModule: "events",
+				routinepkg.RoutineLabelKeyMethod: "Dispatcher.dispatch",
+			}, func() {
+				defer func() {
+					<-d.semaphore
+					d.wg.Done()
+				}()
+				d.dispatch(message)
+			})
+		}
+	}
+}
+
+// dispatch delivers an event to all matching subscriptions and acknowledges it
+// a failed delivery is logged and dropped, it doesn't block the event from being acknowledged
+func (d *Dispatcher) dispatch(message Message) {
+	subscriptions, err := d.subscriptions()
+	if err != nil {
+		// leave it unacknowledged, with the redis bus it's taken over once it's idle for long
+		log.Error("failed to load event subscriptions: %s", err.Error())
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	for _, subscription := range subscriptions {
+		if !MatchEventType(subscription.EventTypes, message.Event.Type) {
+			continue
+		}
+
+		wg.Add(1)
+		routine.Submit(routinepkg.Labels{
+			routinepkg.RoutineLabelKeyModule: "events",
+			routinepkg.RoutineLabelKeyMethod: "Dispatcher.deliver",
+		}, func() {
+			defer wg.Done()
+			if err := d.deliver(subscription, message.Event); err != nil {
+				log.Warn(
+					"failed to deliver event %s to subscription %s: %s",
+					message.Event.ID, subscription.ID, err.Error(),
+				)
+			}
+		})
+	}
+	wg.Wait()
+
+	if err := d.bus.Ack(message); err != nil {
+		log.Error("failed to acknowledge event %s: %s", message.Event.ID, err.Error())
+	}
+}
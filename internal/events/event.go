@@ -0,0 +1,61 @@
+package events
+
+import (
+	"strings"
+	"time"
+)
+
+type EventType string
+
+const (
+	EVENT_TYPE_LOCAL_RUNTIME_STARTING         EventType = "plugin.local_runtime.starting"
+	EVENT_TYPE_LOCAL_RUNTIME_READY            EventType = "plugin.local_runtime.ready"
+	EVENT_TYPE_LOCAL_RUNTIME_START_FAILED     EventType = "plugin.local_runtime.start_failed"
+	EVENT_TYPE_LOCAL_RUNTIME_STOPPING         EventType = "plugin.local_runtime.stopping"
+	EVENT_TYPE_LOCAL_RUNTIME_STOPPED          EventType = "plugin.local_runtime.stopped"
+	EVENT_TYPE_LOCAL_RUNTIME_SCALED_UP        EventType = "plugin.local_runtime.scaled_up"
+	EVENT_TYPE_LOCAL_RUNTIME_SCALED_DOWN      EventType = "plugin.local_runtime.scaled_down"
+	EVENT_TYPE_LOCAL_RUNTIME_INSTANCE_CRASHED EventType = "plugin.local_runtime.instance_crashed"
+
+	EVENT_TYPE_DEBUGGING_RUNTIME_CONNECTED    EventType = "plugin.debugging_runtime.connected"
+	EVENT_TYPE_DEBUGGING_RUNTIME_DISCONNECTED EventType = "plugin.debugging_runtime.disconnected"
+
+	EVENT_TYPE_INSTALL_TASK_STATUS_CHANGED EventType = "install_task.status_changed"
+
+	EVENT_TYPE_ENDPOINT_CREATED  EventType = "endpoint.created"
+	EVENT_TYPE_ENDPOINT_UPDATED  EventType = "endpoint.updated"
+	EVENT_TYPE_ENDPOINT_ENABLED  EventType = "endpoint.enabled"
+	EVENT_TYPE_ENDPOINT_DISABLED EventType = "endpoint.disabled"
+	EVENT_TYPE_ENDPOINT_REMOVED  EventType = "endpoint.removed"
+)
+
+// Event is a lifecycle event delivered to webhook subscriptions
+type Event struct {
+	ID                     string         `json:"id"`
+	Type                   EventType      `json:"type"`
+	NodeID                 string         `json:"node_id"`
+	OccurredAt             time.Time      `json:"occurred_at"`
+	TenantID               string         `json:"tenant_id,omitempty"`
+	PluginUniqueIdentifier string         `json:"plugin_unique_identifier,omitempty"`
+	Data                   map[string]any `json:"data,omitempty"`
+}
+
+// MatchEventType checks if an event type is matched by patterns of a subscription
+// a pattern is an exact type or a prefix ending with `*`, no patterns match all events
+func MatchEventType(patterns []string, eventType EventType) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(string(eventType), prefix) {
+				return true
+			}
+		} else if pattern == string(eventType) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,67 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+)
+
+var (
+	bus        Bus
+	dispatcher *Dispatcher
+	nodeID     string
+)
+
+// Init creates the event bus and launches the dispatcher of webhook subscriptions
+// events published before it's called are dropped
+func Init(config *app.Config, node string) {
+	switch config.EventBusType {
+	case app.EVENT_BUS_TYPE_MEMORY:
+		bus = newMemoryBus(config.EventBusMemoryBufferSize)
+	case app.EVENT_BUS_TYPE_REDIS:
+		bus = newRedisBus(
+			node,
+			config.EventBusRedisStreamMaxLen,
+			time.Duration(config.EventBusRedisClaimIdleTimeout)*time.Second,
+		)
+	default:
+		return
+	}
+
+	nodeID = node
+	dispatcher = NewDispatcher(
+		bus,
+		time.Duration(config.EventWebhookTimeout)*time.Second,
+		config.EventWebhookMaxAttempts,
+		time.Duration(config.EventWebhookInitialBackoff)*time.Second,
+		time.Duration(config.EventWebhookMaxBackoff)*time.Second,
+		config.EventWebhookConcurrency,
+	)
+	dispatcher.Launch()
+}
+
+// Publish stamps an event with an id, this node and the current time, and appends it to the bus
+// it never fails the caller, events which can't be published are logged and dropped
+func Publish(event Event) {
+	if bus == nil {
+		return
+	}
+
+	event.ID = uuid.New().String()
+	event.NodeID = nodeID
+	event.OccurredAt = time.Now()
+
+	if err := bus.Publish(event); err != nil {
+		log.Warn("failed to publish event %s: %s", event.Type, err.Error())
+	}
+}
+
+// Stop stops the dispatcher, deliveries in progress are finished first
+func Stop() error {
+	if dispatcher != nil {
+		dispatcher.Stop()
+	}
+	return nil
+}
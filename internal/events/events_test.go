@@ -0,0 +1,126 @@
+package events
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/parser"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/routine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchEventType(t *testing.T) {
+	assert.True(t, MatchEventType(nil, EVENT_TYPE_ENDPOINT_CREATED))
+	assert.True(t, MatchEventType([]string{"endpoint.created"}, EVENT_TYPE_ENDPOINT_CREATED))
+	assert.False(t, MatchEventType([]string{"endpoint.created"}, EVENT_TYPE_ENDPOINT_REMOVED))
+	assert.True(t, MatchEventType([]string{"plugin.local_runtime.*"}, EVENT_TYPE_LOCAL_RUNTIME_INSTANCE_CRASHED))
+	assert.False(t, MatchEventType([]string{"plugin.local_runtime.*"}, EVENT_TYPE_DEBUGGING_RUNTIME_CONNECTED))
+	assert.True(t, MatchEventType([]string{"*"}, EVENT_TYPE_INSTALL_TASK_STATUS_CHANGED))
+}
+
+func TestMemoryBus(t *testing.T) {
+	bus := newMemoryBus(2)
+
+	assert.NoError(t, bus.Publish(Event{ID: "1", Type: EVENT_TYPE_ENDPOINT_CREATED}))
+	assert.NoError(t, bus.Publish(Event{ID: "2", Type: EVENT_TYPE_ENDPOINT_REMOVED}))
+	assert.ErrorIs(t, bus.Publish(Event{ID: "3"}), ErrEventBusFull)
+
+	messages, err := bus.Receive(time.Second)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, messages, 2) {
+		return
+	}
+	assert.Equal(t, "1", messages[0].Event.ID)
+	assert.Equal(t, "2", messages[1].Event.ID)
+
+	messages, err = bus.Receive(time.Millisecond * 10)
+	assert.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{"id":"1"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(
+		t,
+		"sha256=086f6aff7bd084c98679825129c5a64dbad88c760016d6d2c0fb123f27951d54",
+		Sign("secret", 1700000000, []byte(`{"id":"1"}`)),
+	)
+}
+
+func TestDispatcherDeliversWithRetry(t *testing.T) {
+	routine.InitPool(1024)
+
+	attempts := atomic.Int32{}
+	received := make(chan Event, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HEADER_EVENT_TIMESTAMP), 10, 64)
+		if r.Header.Get(HEADER_EVENT_SIGNATURE) != Sign("secret", timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		event, _ := parser.UnmarshalJsonBytes[Event](body)
+		received <- event
+	}))
+	defer server.Close()
+
+	bus := newMemoryBus(16)
+	dispatcher := NewDispatcher(bus, time.Second, 5, time.Millisecond*10, time.Millisecond*50, 4)
+	dispatcher.subscriptions = func() ([]models.EventSubscription, error) {
+		return []models.EventSubscription{
+			{URL: server.URL, Secret: "secret", Enabled: true, EventTypes: []string{"plugin.*"}},
+			{URL: server.URL, Secret: "secret", Enabled: true, EventTypes: []string{"endpoint.*"}},
+		}, nil
+	}
+	dispatcher.Launch()
+	defer dispatcher.Stop()
+
+	assert.NoError(t, bus.Publish(Event{ID: "1", Type: EVENT_TYPE_LOCAL_RUNTIME_INSTANCE_CRASHED}))
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "1", event.ID)
+		assert.Equal(t, EVENT_TYPE_LOCAL_RUNTIME_INSTANCE_CRASHED, event.Type)
+	case <-time.After(time.Second * 5):
+		t.Fatal("event was not delivered")
+	}
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestDispatcherGivesUpOnClientErrors(t *testing.T) {
+	attempts := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	dispatcher := NewDispatcher(newMemoryBus(1), time.Second, 5, time.Millisecond, time.Millisecond, 1)
+	err := dispatcher.deliver(models.EventSubscription{URL: server.URL}, Event{ID: "1"})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestBackoff(t *testing.T) {
+	dispatcher := NewDispatcher(newMemoryBus(1), time.Second, 8, time.Second*2, time.Second*60, 1)
+	assert.Equal(t, time.Second*2, dispatcher.backoff(1))
+	assert.Equal(t, time.Second*4, dispatcher.backoff(2))
+	assert.Equal(t, time.Second*32, dispatcher.backoff(5))
+	assert.Equal(t, time.Second*60, dispatcher.backoff(6))
+	assert.Equal(t, time.Second*60, dispatcher.backoff(20))
+}
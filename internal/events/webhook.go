@@ -0,0 +1,117 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/parser"
+)
+
+const (
+	HEADER_EVENT_ID        = "X-Dify-Event-Id"
+	HEADER_EVENT_TYPE      = "X-Dify-Event-Type"
+	HEADER_EVENT_TIMESTAMP = "X-Dify-Event-Timestamp"
+	HEADER_EVENT_SIGNATURE = "X-Dify-Event-Signature"
+)
+
+// Sign returns the signature of a delivery, receivers verify it by computing the same value
+// `sha256=` followed by hex of HMAC-SHA256 of `<timestamp>.<body>` keyed by the subscription secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookError is a failed delivery, retryable ones are attempted again after a backoff
+type webhookError struct {
+	err       error
+	retryable bool
+}
+
+func (e *webhookError) Error() string {
+	return e.err.Error()
+}
+
+// deliver sends an event to a subscription, retrying failed attempts with exponential backoff
+// the same event id and signature scheme are used for each attempt, receivers should dedupe by id
+func (d *Dispatcher) deliver(subscription models.EventSubscription, event Event) error {
+	body := []byte(parser.MarshalJson(event))
+
+	var err error
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		err = d.send(subscription, event, body)
+		if err == nil {
+			return nil
+		}
+
+		if e, ok := err.(*webhookError); ok && !e.retryable {
+			return err
+		}
+
+		if attempt < d.maxAttempts {
+			select {
+			case <-time.After(d.backoff(attempt)):
+			case <-d.stop:
+				return err
+			}
+		}
+	}
+
+	return fmt.Errorf("gave up after %d attempts: %w", d.maxAttempts, err)
+}
+
+// backoff returns the delay before the next attempt, doubled for each failed attempt
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.initialBackoff
+	for i := 1; i < attempt && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.maxBackoff)
+}
+
+func (d *Dispatcher) send(subscription models.EventSubscription, event Event, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return &webhookError{err: err, retryable: false}
+	}
+
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HEADER_EVENT_ID, event.ID)
+	request.Header.Set(HEADER_EVENT_TYPE, string(event.Type))
+	request.Header.Set(HEADER_EVENT_TIMESTAMP, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(HEADER_EVENT_SIGNATURE, Sign(subscription.Secret, timestamp, body))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return &webhookError{err: err, retryable: true}
+	}
+	defer response.Body.Close()
+	// drain the body to reuse the connection
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+
+	return &webhookError{
+		err: fmt.Errorf("webhook responded with status %d", response.StatusCode),
+		retryable: response.StatusCode >= 500 ||
+			response.StatusCode == http.StatusRequestTimeout ||
+			response.StatusCode == http.StatusTooManyRequests,
+	}
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/langgenius/dify-plugin-daemon/internal/service"
+)
+
+func CreateEventSubscription(c *gin.Context) {
+	BindRequest(c, func(request struct {
+		URL         string   `json:"url" validate:"required,http_url,max=1023"`
+		Secret      string   `json:"secret" validate:"omitempty,min=16,max=127"`
+		EventTypes  []string `json:"event_types" validate:"dive,required,max=127"`
+		Description string   `json:"description" validate:"max=255"`
+	}) {
+		c.JSON(http.StatusOK, service.CreateEventSubscription(
+			request.URL, request.Secret, request.EventTypes, request.Description,
+		))
+	})
+}
+
+func ListEventSubscriptions(c *gin.Context) {
+	BindRequest(c, func(request struct {
+		Page     int `form:"page" validate:"required,min=1"`
+		PageSize int `form:"page_size" validate:"required,min=1,max=256"`
+	}) {
+		c.JSON(http.StatusOK, service.ListEventSubscriptions(request.Page, request.PageSize))
+	})
+}
+
+func DeleteEventSubscription(c *gin.Context) {
+	BindRequest(c, func(request struct {
+		ID string `uri:"id" validate:"required"`
+	}) {
+		c.JSON(http.StatusOK, service.DeleteEventSubscription(request.ID))
+	})
+}
@@ -4,12 +4,16 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/langgenius/dify-plugin-daemon/internal/cluster"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
 	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager"
 	"github.com/langgenius/dify-plugin-daemon/internal/service"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/exception"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities/constants"
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
 )
@@ -311,3 +315,221 @@ func ExtractPluginAsset(c *gin.Context) {
 		c.Data(http.StatusOK, "application/octet-stream", asset)
 	})
 }
+
+func ListPluginEnvironments(c *gin.Context) {
+	BindRequest(c, func(request struct {
+		PluginID string `form:"plugin_id" validate:"required"`
+	}) {
+		c.JSON(http.StatusOK, service.ListPluginEnvironments(request.PluginID))
+	})
+}
+
+func UpdatePluginEnvironment(c *gin.Context) {
+	BindRequest(c, func(request struct {
+		PluginID               string            `json:"plugin_id" validate:"required"`
+		PluginUniqueIdentifier string            `json:"plugin_unique_identifier"`
+		Variables              map[string]string `json:"variables"`
+	}) {
+		c.JSON(http.StatusOK, service.UpdatePluginEnvironment(
+			request.PluginID, request.PluginUniqueIdentifier, request.Variables,
+		))
+	})
+}
+
+func ListPluginPins(c *gin.Context) {
+	c.JSON(http.StatusOK, service.ListPluginPins())
+}
+
+func PinPlugin(c *gin.Context) {
+	BindRequest(c, func(request struct {
+		PluginID string `json:"plugin_id" validate:"required,max=255"`
+		Operator string `json:"operator" validate:"max=255"`
+	}) {
+		c.JSON(http.StatusOK, service.PinPlugin(request.PluginID, request.Operator))
+	})
+}
+
+func UnpinPlugin(c *gin.Context) {
+	BindRequest(c, func(request struct {
+		PluginID string `json:"plugin_id" validate:"required,max=255"`
+	}) {
+		c.JSON(http.StatusOK, service.UnpinPlugin(request.PluginID))
+	})
+}
+
+func FetchPluginInstanceEnvironments(c *gin.Context) {
+	BindRequest(c, func(request struct {
+		PluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier `form:"plugin_unique_identifier" validate:"required,plugin_unique_identifier"`
+	}) {
+		c.JSON(http.StatusOK, service.FetchPluginInstanceEnvironments(request.PluginUniqueIdentifier))
+	})
+}
+
+func FetchPluginRuntimeState(c *gin.Context) {
+	BindRequest(c, func(request struct {
+		PluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier `form:"plugin_unique_identifier" validate:"required,plugin_unique_identifier"`
+	}) {
+		c.JSON(http.StatusOK, service.FetchPluginRuntimeState(request.PluginUniqueIdentifier))
+	})
+}
+
+// ListPluginRuntimes lists runtimes of this node, or of all nodes with `scope=cluster`
+func ListPluginRuntimes(cluster *cluster.Cluster) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		BindRequest(c, func(request struct {
+			Scope string `form:"scope" validate:"omitempty,oneof=node cluster"`
+		}) {
+			if request.Scope == service.PLUGIN_RUNTIME_INVENTORY_SCOPE_CLUSTER {
+				c.JSON(http.StatusOK, service.ListClusterPluginRuntimes(cluster, c.Request))
+			} else {
+				c.JSON(http.StatusOK, service.ListPluginRuntimes(cluster.ID()))
+			}
+		})
+	}
+}
+
+func FetchPluginRestartPolicy(c *gin.Context) {
+	BindRequest(c, func(request struct {
+		PluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier `form:"plugin_unique_identifier" validate:"required,plugin_unique_identifier"`
+	}) {
+		c.JSON(http.StatusOK, service.FetchPluginRestartPolicy(request.PluginUniqueIdentifier))
+	})
+}
+
+func ResetPluginRestartPolicy(c *gin.Context) {
+	BindRequest(c, func(request struct {
+		PluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier `json:"plugin_unique_identifier" validate:"required,plugin_unique_identifier"`
+	}) {
+		c.JSON(http.StatusOK, service.ResetPluginRestartPolicy(request.PluginUniqueIdentifier))
+	})
+}
+
+// pluginLogsRequest filters logs of a local plugin, `follow` streams new logs as well
+type pluginLogsRequest struct {
+	PluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier `form:"plugin_unique_identifier" validate:"required,plugin_unique_identifier"`
+	Level                  string                                 `form:"level" validate:"omitempty,oneof=debug info warn error"`
+	Since                  string                                 `form:"since" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Until                  string                                 `form:"until" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Limit                  int                                    `form:"limit" validate:"omitempty,min=1,max=10000"`
+	Follow                 bool                                   `form:"follow"`
+}
+
+func (r *pluginLogsRequest) query() local_runtime.PluginLogQuery {
+	query := local_runtime.PluginLogQuery{
+		Level: r.Level,
+		Limit: r.Limit,
+	}
+	// validated by `BindRequest`
+	if r.Since != "" {
+		query.Since, _ = time.Parse(time.RFC3339, r.Since)
+	}
+	if r.Until != "" {
+		query.Until, _ = time.Parse(time.RFC3339, r.Until)
+	}
+	return query
+}
+
+// FetchPluginLogs returns logs of sessions of the tenant, logs of other tenants and instances are excluded
+func FetchPluginLogs(config *app.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		BindRequest(c, func(request struct {
+			TenantID string `uri:"tenant_id" validate:"required"`
+			pluginLogsRequest
+		}) {
+			if request.Follow {
+				service.TailPluginLogs(
+					c, request.TenantID, request.PluginUniqueIdentifier, request.query(), config.PluginLogTailTimeout,
+				)
+				return
+			}
+
+			c.JSON(http.StatusOK, service.FetchPluginLogs(request.TenantID, request.PluginUniqueIdentifier, request.query()))
+		})
+	}
+}
+
+// FetchAllPluginLogs returns logs of all tenants and instances of a local plugin, it's for operators
+func FetchAllPluginLogs(config *app.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		BindRequest(c, func(request pluginLogsRequest) {
+			if request.Follow {
+				service.TailAllPluginLogs(c, request.PluginUniqueIdentifier, request.query(), config.PluginLogTailTimeout)
+				return
+			}
+
+			c.JSON(http.StatusOK, service.FetchAllPluginLogs(request.PluginUniqueIdentifier, request.query()))
+		})
+	}
+}
+
+// OperatePluginRuntime applies an operation to a local plugin on this node, or on all nodes with `scope=cluster`
+func OperatePluginRuntime(
+	cluster *cluster.Cluster,
+	config *app.Config,
+	action models.PluginRuntimeAction,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		BindRequest(c, func(request struct {
+			PluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier `json:"plugin_unique_identifier" validate:"required,plugin_unique_identifier"`
+			Scope                  string                                 `json:"scope" validate:"omitempty,oneof=node cluster"`
+			Replicas               int32                                  `json:"replicas" validate:"omitempty,min=1"`
+			Reason                 string                                 `json:"reason" validate:"max=1024"`
+			Operator               string                                 `json:"operator" validate:"max=255"`
+			OperationID            string                                 `json:"operation_id" validate:"omitempty,uuid"`
+		}) {
+			operation := service.PluginRuntimeOperation{
+				Action:                 action,
+				PluginUniqueIdentifier: request.PluginUniqueIdentifier,
+				Scope:                  request.Scope,
+				Replicas:               request.Replicas,
+				Reason:                 request.Reason,
+				Operator:               request.Operator,
+				OperationID:            request.OperationID,
+			}
+
+			if request.Scope == service.PLUGIN_RUNTIME_INVENTORY_SCOPE_CLUSTER {
+				c.JSON(http.StatusOK, service.ApplyClusterPluginRuntimeOperation(cluster, config, c.Request, operation))
+			} else {
+				c.JSON(http.StatusOK, service.ApplyPluginRuntimeOperation(config, cluster.ID(), operation))
+			}
+		})
+	}
+}
+
+func ListPluginRuntimeAudits(c *gin.Context) {
+	BindRequest(c, func(request struct {
+		PluginUniqueIdentifier string `form:"plugin_unique_identifier"`
+		OperationID            string `form:"operation_id"`
+		Page                   int    `form:"page" validate:"required,min=1"`
+		PageSize               int    `form:"page_size" validate:"required,min=1,max=256"`
+	}) {
+		c.JSON(http.StatusOK, service.ListPluginRuntimeAudits(
+			request.PluginUniqueIdentifier, request.OperationID, request.Page, request.PageSize,
+		))
+	})
+}
+
+// FetchPluginDiskGCReport reports what disk garbage collection would remove on this node
+// or on all nodes with `scope=cluster`, nothing is removed
+func FetchPluginDiskGCReport(cluster *cluster.Cluster, config *app.Config) gin.HandlerFunc {
+	return collectPluginDisk(cluster, config, true)
+}
+
+// CollectPluginDisk runs disk garbage collection on this node, or on all nodes with `scope=cluster`
+func CollectPluginDisk(cluster *cluster.Cluster, config *app.Config) gin.HandlerFunc {
+	return collectPluginDisk(cluster, config, false)
+}
+
+func collectPluginDisk(cluster *cluster.Cluster, config *app.Config, dryRun bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		BindRequest(c, func(request struct {
+			Scope string `form:"scope" validate:"omitempty,oneof=node cluster"`
+		}) {
+			if request.Scope == service.PLUGIN_RUNTIME_INVENTORY_SCOPE_CLUSTER {
+				c.JSON(http.StatusOK, service.CollectClusterPluginDisk(cluster, config, c.Request, dryRun))
+			} else {
+				c.JSON(http.StatusOK, service.CollectPluginDisk(config, cluster.ID(), dryRun))
+			}
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package controllers
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/langgenius/dify-plugin-daemon/internal/cluster"
 	"github.com/langgenius/dify-plugin-daemon/internal/service"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities/requests"
 )
 
@@ -13,3 +17,68 @@ func GetRemoteDebuggingKey(c *gin.Context) {
 		},
 	)
 }
+
+func CreateRemoteDebuggingKey(c *gin.Context) {
+	BindRequest(
+		c, func(request requests.RequestCreateRemoteDebuggingKey) {
+			c.JSON(200, service.CreateRemoteDebuggingKey(
+				request.TenantID, request.UserID, request.Label, request.ExpiresIn,
+			))
+		},
+	)
+}
+
+func ListRemoteDebuggingKeys(c *gin.Context) {
+	BindRequest(
+		c, func(request requests.RequestListRemoteDebuggingKeys) {
+			c.JSON(200, service.ListRemoteDebuggingKeys(request.TenantID, request.UserID))
+		},
+	)
+}
+
+func RevokeRemoteDebuggingKey(c *gin.Context) {
+	BindRequest(
+		c, func(request requests.RequestRevokeRemoteDebuggingKey) {
+			c.JSON(200, service.RevokeRemoteDebuggingKey(request.TenantID, request.KeyID, request.UserID))
+		},
+	)
+}
+
+// ListDebuggingSessions lists debugging plugins connected to this node, or to all nodes with `scope=cluster`
+func ListDebuggingSessions(cluster *cluster.Cluster) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		BindRequest(c, func(request struct {
+			TenantID string `form:"tenant_id"`
+			Scope    string `form:"scope" validate:"omitempty,oneof=node cluster"`
+		}) {
+			if request.Scope == service.PLUGIN_RUNTIME_INVENTORY_SCOPE_CLUSTER {
+				c.JSON(http.StatusOK, service.ListClusterDebuggingSessions(cluster, c.Request, request.TenantID))
+			} else {
+				c.JSON(http.StatusOK, service.ListDebuggingSessions(cluster.ID(), request.TenantID))
+			}
+		})
+	}
+}
+
+// KickDebuggingRuntime disconnects a debugging plugin from this node, or from all nodes with `scope=cluster`
+func KickDebuggingRuntime(cluster *cluster.Cluster) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		BindRequest(c, func(request struct {
+			TenantID               string                                 `json:"tenant_id" validate:"required"`
+			PluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier `json:"plugin_unique_identifier" validate:"required,plugin_unique_identifier"`
+			Scope                  string                                 `json:"scope" validate:"omitempty,oneof=node cluster"`
+		}) {
+			kick := service.DebuggingRuntimeKick{
+				TenantID:               request.TenantID,
+				PluginUniqueIdentifier: request.PluginUniqueIdentifier,
+				Scope:                  request.Scope,
+			}
+
+			if request.Scope == service.PLUGIN_RUNTIME_INVENTORY_SCOPE_CLUSTER {
+				c.JSON(http.StatusOK, service.KickClusterDebuggingRuntime(cluster, c.Request, kick))
+			} else {
+				c.JSON(http.StatusOK, service.KickDebuggingRuntime(cluster.ID(), kick))
+			}
+		})
+	}
+}
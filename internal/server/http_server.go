@@ -11,6 +11,7 @@ import (
 	"github.com/langgenius/dify-plugin-daemon/internal/server/controllers"
 	"github.com/langgenius/dify-plugin-daemon/internal/service"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
 
 	sentrygin "github.com/getsentry/sentry-go/gin"
@@ -108,6 +109,9 @@ func (app *App) pluginDispatchGroup(group *gin.RouterGroup, config *app.Config)
 func (app *App) remoteDebuggingGroup(group *gin.RouterGroup, config *app.Config) {
 	if config.PluginRemoteInstallingEnabled {
 		group.POST("/key", CheckingKey(config.ServerKey), controllers.GetRemoteDebuggingKey)
+		group.GET("/keys", CheckingKey(config.ServerKey), controllers.ListRemoteDebuggingKeys)
+		group.POST("/keys", CheckingKey(config.ServerKey), controllers.CreateRemoteDebuggingKey)
+		group.POST("/keys/:key_id/revoke", CheckingKey(config.ServerKey), controllers.RevokeRemoteDebuggingKey)
 	}
 }
 
@@ -172,10 +176,36 @@ func (app *App) pluginManagementGroup(group *gin.RouterGroup, config *app.Config
 	group.GET("/agent_strategy", controllers.GetAgentStrategy)
 	group.GET("/datasources", controllers.ListDatasources)
 	group.GET("/datasource", controllers.GetDatasource)
+	group.GET("/logs", controllers.FetchPluginLogs(config))
 }
 
 func (app *App) adminGroup(group *gin.RouterGroup, config *app.Config) {
 	group.POST("/plugin/serverless/reinstall", controllers.ReinstallPluginFromIdentifier(config))
+	group.GET("/plugin/environment", controllers.ListPluginEnvironments)
+	group.POST("/plugin/environment", controllers.UpdatePluginEnvironment)
+	group.GET("/plugin/environment/instances", controllers.FetchPluginInstanceEnvironments)
+	group.GET("/plugin/runtime/state", controllers.FetchPluginRuntimeState)
+	group.GET("/plugin/runtimes", controllers.ListPluginRuntimes(app.cluster))
+	group.GET("/plugin/runtime/restart_policy", controllers.FetchPluginRestartPolicy)
+	group.GET("/plugin/logs", controllers.FetchAllPluginLogs(config))
+	group.POST("/plugin/runtime/restart_policy/reset", controllers.ResetPluginRestartPolicy)
+	group.POST("/plugin/runtime/restart", controllers.OperatePluginRuntime(app.cluster, config, models.PLUGIN_RUNTIME_ACTION_RESTART))
+	group.POST("/plugin/runtime/stop", controllers.OperatePluginRuntime(app.cluster, config, models.PLUGIN_RUNTIME_ACTION_STOP))
+	group.POST("/plugin/runtime/start", controllers.OperatePluginRuntime(app.cluster, config, models.PLUGIN_RUNTIME_ACTION_START))
+	group.POST("/plugin/runtime/scale", controllers.OperatePluginRuntime(app.cluster, config, models.PLUGIN_RUNTIME_ACTION_SCALE))
+	group.POST("/plugin/runtime/quarantine", controllers.OperatePluginRuntime(app.cluster, config, models.PLUGIN_RUNTIME_ACTION_QUARANTINE))
+	group.POST("/plugin/runtime/release", controllers.OperatePluginRuntime(app.cluster, config, models.PLUGIN_RUNTIME_ACTION_RELEASE))
+	group.GET("/plugin/runtime/audits", controllers.ListPluginRuntimeAudits)
+	group.GET("/plugin/runtime/pins", controllers.ListPluginPins)
+	group.POST("/plugin/runtime/pin", controllers.PinPlugin)
+	group.POST("/plugin/runtime/unpin", controllers.UnpinPlugin)
+	group.GET("/plugin/disk/gc/report", controllers.FetchPluginDiskGCReport(app.cluster, config))
+	group.POST("/plugin/disk/gc", controllers.CollectPluginDisk(app.cluster, config))
+	group.GET("/plugin/debugging/sessions", controllers.ListDebuggingSessions(app.cluster))
+	group.POST("/plugin/debugging/kick", controllers.KickDebuggingRuntime(app.cluster))
+	group.GET("/event/subscriptions", controllers.ListEventSubscriptions)
+	group.POST("/event/subscriptions", controllers.CreateEventSubscription)
+	group.POST("/event/subscriptions/:id/delete", controllers.DeleteEventSubscription)
 }
 
 func (app *App) pluginAssetGroup(group *gin.RouterGroup) {
@@ -8,6 +8,7 @@ import (
 	"github.com/langgenius/dify-plugin-daemon/internal/core/persistence"
 	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager"
 	"github.com/langgenius/dify-plugin-daemon/internal/db"
+	"github.com/langgenius/dify-plugin-daemon/internal/events"
 	"github.com/langgenius/dify-plugin-daemon/internal/tasks"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/cache"
@@ -104,6 +105,9 @@ func (app *App) Run(config *app.Config) {
 	// create cluster
 	app.cluster = cluster.NewCluster(config)
 
+	// init lifecycle events, published by the manager once it's launched
+	events.Init(config, app.cluster.ID())
+
 	// init manager
 	app.pluginManager.Launch(config)
 
@@ -117,6 +121,7 @@ func (app *App) Run(config *app.Config) {
 	tasks.SetupSignalHandler()
 	tasks.RegisterFinalizers(tasks.RecycleTasks)
 	tasks.RegisterFinalizers(cache.ReleaseAllLocks)
+	tasks.RegisterFinalizers(events.Stop)
 
 	// start http server
 	app.server(config)
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/cluster"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities"
+	routinepkg "github.com/langgenius/dify-plugin-daemon/pkg/routine"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/parser"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/routine"
+)
+
+const (
+	// nodes which don't answer in time are reported with an error
+	clusterFanOutTimeout = 10 * time.Second
+)
+
+// nodeResult is the answer of a node to a request fanned out to the cluster
+type nodeResult[T any] struct {
+	nodeID string
+	data   T
+	err    error
+}
+
+// fanOutToCluster calls `local` on this node and sends a request built by `newRequest` to each other node
+// requests are built for each node as a body can't be sent twice, they must be bound to `ctx`
+// it waits until all nodes answered, a failed node doesn't affect others, results are sorted by node id
+func fanOutToCluster[T any](
+	ctx context.Context,
+	c *cluster.Cluster,
+	method string,
+	newRequest func(ctx context.Context) (*http.Request, error),
+	local func(nodeID string) (T, error),
+) ([]nodeResult[T], error) {
+	nodes, err := c.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, clusterFanOutTimeout)
+	defer cancel()
+
+	results := make([]nodeResult[T], 0, len(nodes))
+	lock := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for nodeID := range nodes {
+		wg.Add(1)
+		routine.Submit(routinepkg.Labels{
+			routinepkg.RoutineLabelKeyModule: "service",
+			routinepkg.RoutineLabelKeyMethod: method,
+		}, func() {
+			defer wg.Done()
+
+			result := nodeResult[T]{nodeID: nodeID}
+			if nodeID == c.ID() {
+				result.data, result.err = local(nodeID)
+			} else {
+				request, err := newRequest(ctx)
+				if err != nil {
+					result.err = err
+				} else {
+					result.data, result.err = fetchNodeResponse[T](c, nodeID, request)
+				}
+			}
+
+			lock.Lock()
+			results = append(results, result)
+			lock.Unlock()
+		})
+	}
+
+	wg.Wait()
+
+	slices.SortFunc(results, func(a, b nodeResult[T]) int {
+		return strings.Compare(a.nodeID, b.nodeID)
+	})
+
+	return results, nil
+}
+
+// fetchNodeResponse redirects the request to a node and parses data of its response
+func fetchNodeResponse[T any](
+	c *cluster.Cluster,
+	nodeID string,
+	request *http.Request,
+) (T, error) {
+	var data T
+
+	statusCode, _, body, err := c.RedirectRequest(nodeID, request)
+	if err != nil {
+		return data, err
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return data, err
+	}
+	if statusCode != http.StatusOK {
+		return data, fmt.Errorf("node responded with status %d: %s", statusCode, string(content))
+	}
+
+	response, err := parser.UnmarshalJsonBytes[entities.GenericResponse[T]](content)
+	if err != nil {
+		return data, err
+	}
+	if response.Code != 0 {
+		return data, errors.New(response.Message)
+	}
+
+	return response.Data, nil
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"errors"
+	"time"
+
 	"github.com/langgenius/dify-plugin-daemon/internal/service/debugging_service"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/exception"
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities"
@@ -23,3 +26,44 @@ func GetRemoteDebuggingKey(tenant_id string) *entities.Response {
 		Key: key,
 	})
 }
+
+// CreateRemoteDebuggingKey creates a debugging key for a user of a tenant, the key is only returned once
+func CreateRemoteDebuggingKey(tenantId string, userId string, label string, expiresIn int) *entities.Response {
+	expireTime := debugging_service.CONNECTION_KEY_EXPIRE_TIME
+	if expiresIn > 0 {
+		expireTime = time.Duration(expiresIn) * time.Second
+	}
+
+	key, err := debugging_service.CreateConnectionKey(tenantId, userId, label, expireTime)
+	if errors.Is(err, debugging_service.ErrConnectionKeyExpireTime) {
+		return exception.BadRequestError(err).ToResponse()
+	} else if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(key)
+}
+
+// ListRemoteDebuggingKeys lists unexpired debugging keys of a tenant without keys themselves
+func ListRemoteDebuggingKeys(tenantId string, userId string) *entities.Response {
+	keys, err := debugging_service.ListConnectionKeys(tenantId, userId)
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(keys)
+}
+
+// RevokeRemoteDebuggingKey revokes a debugging key, plugins connected with it are disconnected from all nodes
+func RevokeRemoteDebuggingKey(tenantId string, keyId string, userId string) *entities.Response {
+	key, err := debugging_service.RevokeConnectionKey(tenantId, keyId, userId)
+	if errors.Is(err, debugging_service.ErrConnectionKeyNotFound) {
+		return exception.NotFoundError(err).ToResponse()
+	} else if errors.Is(err, debugging_service.ErrConnectionKeyNotOwned) {
+		return exception.PermissionDeniedError(err.Error()).ToResponse()
+	} else if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(key)
+}
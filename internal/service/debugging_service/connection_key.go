@@ -22,10 +22,16 @@ import (
 
 type ConnectionInfo struct {
 	TenantId string `json:"tenant_id" validate:"required"`
+	// user who created the key, empty for the default key of a tenant
+	UserId string `json:"user_id,omitempty"`
+	// id of the key, see `ConnectionKeyInfo`
+	KeyId string `json:"key_id,omitempty"`
 }
 
 type Key struct {
 	Key string `json:"key" validate:"required"`
+	// empty for keys created before keys were managed
+	ID string `json:"id,omitempty"`
 }
 
 const (
@@ -47,9 +53,10 @@ func GetConnectionKey(info ConnectionInfo) (string, error) {
 	if err == cache.ErrNotFound {
 		err := cache.Transaction(func(p redis.Pipeliner) error {
 			k := uuid.New().String()
+			info.KeyId = uuid.New().String()
 			_, err = cache.SetNX(
 				strings.Join([]string{CONNECTION_KEY_MANAGER_ID2KEY_PREFIX, info.TenantId}, ":"),
-				Key{Key: k},
+				Key{Key: k, ID: info.KeyId},
 				CONNECTION_KEY_EXPIRE_TIME,
 				p,
 			)
@@ -67,7 +74,27 @@ func GetConnectionKey(info ConnectionInfo) (string, error) {
 				return err
 			}
 
-			key = &Key{Key: k}
+			// listed and revoked along with keys created by users
+			now := time.Now()
+			err = cache.SetMapOneField(
+				tenantConnectionKeysKey(info.TenantId),
+				info.KeyId,
+				ConnectionKeyInfo{
+					ID:        info.KeyId,
+					TenantId:  info.TenantId,
+					Key:       k,
+					CreatedBy: info.UserId,
+					Label:     DEFAULT_CONNECTION_KEY_LABEL,
+					CreatedAt: now,
+					ExpiresAt: now.Add(CONNECTION_KEY_EXPIRE_TIME),
+				},
+				p,
+			)
+			if err != nil {
+				return err
+			}
+
+			key = &Key{Key: k, ID: info.KeyId}
 
 			return nil
 		})
@@ -89,6 +116,12 @@ func GetConnectionKey(info ConnectionInfo) (string, error) {
 		if err != nil {
 			log.Error("failed to update connection key expire time: %s", err.Error())
 		}
+
+		if key.ID != "" {
+			if err := refreshConnectionKeyExpiry(info.TenantId, key.ID, CONNECTION_KEY_EXPIRE_TIME); err != nil {
+				log.Error("failed to update connection key expire time: %s", err.Error())
+			}
+		}
 	}
 
 	return key.Key, nil
@@ -119,5 +152,8 @@ func ClearConnectionKey(tenant_id string) error {
 
 	cache.Del(strings.Join([]string{CONNECTION_KEY_MANAGER_KEY2ID_PREFIX, key.Key}, ":"))
 	cache.Del(strings.Join([]string{CONNECTION_KEY_MANAGER_ID2KEY_PREFIX, tenant_id}, ":"))
+	if key.ID != "" {
+		cache.DelMapField(tenantConnectionKeysKey(tenant_id), key.ID)
+	}
 	return nil
 }
@@ -0,0 +1,195 @@
+package debugging_service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/cache"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+	"github.com/redis/go-redis/v9"
+)
+
+/*
+ * Besides the default key of a tenant, users may create their own keys with a label and an expiry.
+ * Metadata of all keys of a tenant is stored in a map, so that keys can be listed and revoked.
+ *
+ * $tenant_id => { $key_id => $metadata }
+ *
+ * Keys are removed from the map lazily once expired, revoked keys are published to all nodes
+ * to disconnect plugins connected with them.
+ * */
+
+const (
+	CONNECTION_KEY_MANAGER_TENANT_KEYS_PREFIX = "{remote:key:manager}:tenant_keys"
+	CONNECTION_KEY_REVOKED_CHANNEL            = "remote_connection_key_revoked"
+
+	// label of the key returned by `GetConnectionKey`
+	DEFAULT_CONNECTION_KEY_LABEL = "default"
+
+	CONNECTION_KEY_MAX_EXPIRE_TIME = time.Hour * 24 * 30 // 30 days
+)
+
+var (
+	ErrConnectionKeyNotFound   = errors.New("connection key not found")
+	ErrConnectionKeyNotOwned   = errors.New("connection key is not created by the user")
+	ErrConnectionKeyExpireTime = errors.New("expire time of a connection key must be positive and at most 30 days")
+)
+
+// ConnectionKeyInfo is the metadata of a connection key
+type ConnectionKeyInfo struct {
+	ID       string `json:"id"`
+	TenantId string `json:"tenant_id"`
+	// only returned once the key is created
+	Key       string    `json:"key,omitempty"`
+	CreatedBy string    `json:"created_by"`
+	Label     string    `json:"label"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// RevokedConnectionKey is published to all nodes once a key is revoked
+type RevokedConnectionKey struct {
+	TenantId string `json:"tenant_id"`
+	KeyId    string `json:"key_id"`
+}
+
+func tenantConnectionKeysKey(tenantId string) string {
+	return strings.Join([]string{CONNECTION_KEY_MANAGER_TENANT_KEYS_PREFIX, tenantId}, ":")
+}
+
+// CreateConnectionKey creates a new key for a user of a tenant, it's no longer valid after `expireTime`
+func CreateConnectionKey(
+	tenantId string,
+	userId string,
+	label string,
+	expireTime time.Duration,
+) (*ConnectionKeyInfo, error) {
+	if expireTime <= 0 || expireTime > CONNECTION_KEY_MAX_EXPIRE_TIME {
+		return nil, ErrConnectionKeyExpireTime
+	}
+
+	now := time.Now()
+	info := ConnectionKeyInfo{
+		ID:        uuid.New().String(),
+		TenantId:  tenantId,
+		Key:       uuid.New().String(),
+		CreatedBy: userId,
+		Label:     label,
+		CreatedAt: now,
+		ExpiresAt: now.Add(expireTime),
+	}
+
+	err := cache.Transaction(func(p redis.Pipeliner) error {
+		_, err := cache.SetNX(
+			strings.Join([]string{CONNECTION_KEY_MANAGER_KEY2ID_PREFIX, info.Key}, ":"),
+			ConnectionInfo{TenantId: tenantId, UserId: userId, KeyId: info.ID},
+			expireTime,
+			p,
+		)
+		if err != nil {
+			return err
+		}
+
+		return cache.SetMapOneField(tenantConnectionKeysKey(tenantId), info.ID, info, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// ListConnectionKeys lists unexpired keys of a tenant, only keys created by `userId` are listed if it's set
+// keys themselves are not returned
+func ListConnectionKeys(tenantId string, userId string) ([]ConnectionKeyInfo, error) {
+	keys, err := cache.GetMap[ConnectionKeyInfo](tenantConnectionKeysKey(tenantId))
+	if err != nil && err != cache.ErrNotFound {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := []ConnectionKeyInfo{}
+	for id, key := range keys {
+		if now.After(key.ExpiresAt) {
+			// the key itself is expired by redis, remove its metadata as well
+			if err := cache.DelMapField(tenantConnectionKeysKey(tenantId), id); err != nil {
+				log.Warn("failed to remove expired connection key %s: %s", id, err.Error())
+			}
+			continue
+		}
+		if userId != "" && key.CreatedBy != userId {
+			continue
+		}
+
+		key.Key = ""
+		result = append(result, key)
+	}
+
+	slices.SortFunc(result, func(a, b ConnectionKeyInfo) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return result, nil
+}
+
+// RevokeConnectionKey revokes a key of a tenant, new connections with it are rejected
+// and plugins connected with it are disconnected by all nodes
+// the key must be created by `userId` if it's set
+func RevokeConnectionKey(tenantId string, keyId string, userId string) (*ConnectionKeyInfo, error) {
+	info, err := cache.GetMapField[ConnectionKeyInfo](tenantConnectionKeysKey(tenantId), keyId)
+	if err == cache.ErrNotFound {
+		return nil, ErrConnectionKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	if userId != "" && info.CreatedBy != userId {
+		return nil, ErrConnectionKeyNotOwned
+	}
+
+	if _, err := cache.Del(strings.Join([]string{CONNECTION_KEY_MANAGER_KEY2ID_PREFIX, info.Key}, ":")); err != nil {
+		return nil, err
+	}
+
+	// the default key of the tenant, a new one is created by the next `GetConnectionKey`
+	if defaultKey, err := cache.Get[Key](
+		strings.Join([]string{CONNECTION_KEY_MANAGER_ID2KEY_PREFIX, tenantId}, ":"),
+	); err == nil && defaultKey.ID == keyId {
+		if _, err := cache.Del(strings.Join([]string{CONNECTION_KEY_MANAGER_ID2KEY_PREFIX, tenantId}, ":")); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cache.DelMapField(tenantConnectionKeysKey(tenantId), keyId); err != nil {
+		return nil, err
+	}
+
+	if err := cache.Publish(CONNECTION_KEY_REVOKED_CHANNEL, RevokedConnectionKey{
+		TenantId: tenantId,
+		KeyId:    keyId,
+	}); err != nil {
+		log.Error("failed to publish revoked connection key %s: %s", keyId, err.Error())
+	}
+
+	info.Key = ""
+	return info, nil
+}
+
+// SubscribeRevokedConnectionKeys receives keys revoked by any node
+func SubscribeRevokedConnectionKeys() (<-chan RevokedConnectionKey, func()) {
+	return cache.Subscribe[RevokedConnectionKey](CONNECTION_KEY_REVOKED_CHANNEL)
+}
+
+// refreshConnectionKeyExpiry updates the expiry of a key in its metadata
+func refreshConnectionKeyExpiry(tenantId string, keyId string, expireTime time.Duration) error {
+	info, err := cache.GetMapField[ConnectionKeyInfo](tenantConnectionKeysKey(tenantId), keyId)
+	if err != nil {
+		return err
+	}
+
+	info.ExpiresAt = time.Now().Add(expireTime)
+	return cache.SetMapOneField(tenantConnectionKeysKey(tenantId), keyId, info)
+}
@@ -0,0 +1,126 @@
+package debugging_service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/cache"
+)
+
+func TestManagedConnectionKey(t *testing.T) {
+	err := cache.InitRedisClient("0.0.0.0:6379", "", "difyai123456", false, 0)
+	if err != nil {
+		t.Errorf("init redis client failed: %v", err)
+		return
+	}
+	defer cache.Close()
+
+	tenantId := "managed_key_tenant"
+	defer cache.Del(tenantConnectionKeysKey(tenantId))
+
+	if _, err := CreateConnectionKey(tenantId, "user", "laptop", 0); err != ErrConnectionKeyExpireTime {
+		t.Errorf("expected invalid expire time, got: %v", err)
+		return
+	}
+
+	key, err := CreateConnectionKey(tenantId, "user", "laptop", time.Minute)
+	if err != nil {
+		t.Errorf("create connection key failed: %v", err)
+		return
+	}
+
+	info, err := GetConnectionInfo(key.Key)
+	if err != nil {
+		t.Errorf("get connection info failed: %v", err)
+		return
+	}
+	if info.TenantId != tenantId || info.UserId != "user" || info.KeyId != key.ID {
+		t.Errorf("connection info is not the same: %v", info)
+		return
+	}
+
+	keys, err := ListConnectionKeys(tenantId, "user")
+	if err != nil {
+		t.Errorf("list connection keys failed: %v", err)
+		return
+	}
+	if len(keys) != 1 || keys[0].ID != key.ID || keys[0].Key != "" || keys[0].Label != "laptop" {
+		t.Errorf("unexpected connection keys: %v", keys)
+		return
+	}
+
+	// keys are scoped to users who created them
+	keys, err = ListConnectionKeys(tenantId, "another_user")
+	if err != nil || len(keys) != 0 {
+		t.Errorf("unexpected connection keys of another user: %v, %v", keys, err)
+		return
+	}
+	if _, err := RevokeConnectionKey(tenantId, key.ID, "another_user"); err != ErrConnectionKeyNotOwned {
+		t.Errorf("expected key not owned, got: %v", err)
+		return
+	}
+
+	if _, err := RevokeConnectionKey(tenantId, key.ID, "user"); err != nil {
+		t.Errorf("revoke connection key failed: %v", err)
+		return
+	}
+	if _, err := GetConnectionInfo(key.Key); err != cache.ErrNotFound {
+		t.Errorf("revoked key is still valid: %v", err)
+		return
+	}
+	if _, err := RevokeConnectionKey(tenantId, key.ID, "user"); err != ErrConnectionKeyNotFound {
+		t.Errorf("expected key not found, got: %v", err)
+		return
+	}
+}
+
+func TestRevokeDefaultConnectionKey(t *testing.T) {
+	err := cache.InitRedisClient("0.0.0.0:6379", "", "difyai123456", false, 0)
+	if err != nil {
+		t.Errorf("init redis client failed: %v", err)
+		return
+	}
+	defer cache.Close()
+
+	tenantId := "default_key_tenant"
+	defer ClearConnectionKey(tenantId)
+	defer cache.Del(tenantConnectionKeysKey(tenantId))
+
+	key, err := GetConnectionKey(ConnectionInfo{TenantId: tenantId})
+	if err != nil {
+		t.Errorf("get connection key failed: %v", err)
+		return
+	}
+
+	info, err := GetConnectionInfo(key)
+	if err != nil || info.KeyId == "" {
+		t.Errorf("default key has no id: %v, %v", info, err)
+		return
+	}
+
+	keys, err := ListConnectionKeys(tenantId, "")
+	if err != nil {
+		t.Errorf("list connection keys failed: %v", err)
+		return
+	}
+	if len(keys) != 1 || keys[0].ID != info.KeyId || keys[0].Label != DEFAULT_CONNECTION_KEY_LABEL {
+		t.Errorf("default key is not listed: %v", keys)
+		return
+	}
+
+	if _, err := RevokeConnectionKey(tenantId, info.KeyId, ""); err != nil {
+		t.Errorf("revoke default key failed: %v", err)
+		return
+	}
+	if _, err := GetConnectionInfo(key); err != cache.ErrNotFound {
+		t.Errorf("revoked default key is still valid: %v", err)
+		return
+	}
+
+	// a new default key is created after revocation
+	newKey, err := GetConnectionKey(ConnectionInfo{TenantId: tenantId})
+	if err != nil || newKey == key {
+		t.Errorf("default key is not renewed: %s, %v", newKey, err)
+		return
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/cluster"
+	controlpanel "github.com/langgenius/dify-plugin-daemon/internal/core/control_panel"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/exception"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+)
+
+// NodeDebuggingSessions lists debugging plugins connected to a node
+type NodeDebuggingSessions struct {
+	NodeID   string                          `json:"node_id"`
+	Sessions []controlpanel.DebuggingSession `json:"sessions"`
+	// set if the node failed to answer
+	Error string `json:"error,omitempty"`
+}
+
+// DebuggingRuntimeKick is a request to disconnect a debugging plugin
+type DebuggingRuntimeKick struct {
+	TenantID               string                                 `json:"tenant_id"`
+	PluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier `json:"plugin_unique_identifier"`
+	Scope                  string                                 `json:"scope"`
+}
+
+// NodeDebuggingRuntimeKick is the result of a kick on a node
+type NodeDebuggingRuntimeKick struct {
+	NodeID string `json:"node_id"`
+	// false if the plugin is not connected to the node
+	Kicked bool `json:"kicked"`
+	// set if the node failed to answer
+	Error string `json:"error,omitempty"`
+}
+
+func nodeDebuggingSessions(tenantId string) func(nodeID string) (NodeDebuggingSessions, error) {
+	return func(nodeID string) (NodeDebuggingSessions, error) {
+		manager := plugin_manager.Manager()
+		if manager == nil {
+			return NodeDebuggingSessions{}, errors.New("plugin manager is not initialized")
+		}
+
+		return NodeDebuggingSessions{NodeID: nodeID, Sessions: manager.DebuggingSessions(tenantId)}, nil
+	}
+}
+
+// ListDebuggingSessions lists debugging plugins connected to this node, all tenants are listed if `tenantId` is empty
+func ListDebuggingSessions(nodeID string, tenantId string) *entities.Response {
+	sessions, err := nodeDebuggingSessions(tenantId)(nodeID)
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(sessions)
+}
+
+// ListClusterDebuggingSessions lists debugging plugins connected to all nodes in the cluster
+// the request is redirected to other nodes with `scope=node`, a failed node doesn't fail the whole list
+func ListClusterDebuggingSessions(c *cluster.Cluster, request *http.Request, tenantId string) *entities.Response {
+	results, err := fanOutToCluster(
+		request.Context(),
+		c,
+		"ListClusterDebuggingSessions",
+		func(ctx context.Context) (*http.Request, error) {
+			nodeRequest := request.Clone(ctx)
+			query := nodeRequest.URL.Query()
+			query.Set("scope", PLUGIN_RUNTIME_INVENTORY_SCOPE_NODE)
+			nodeRequest.URL.RawQuery = query.Encode()
+			return nodeRequest, nil
+		},
+		nodeDebuggingSessions(tenantId),
+	)
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	sessions := make([]NodeDebuggingSessions, 0, len(results))
+	for _, result := range results {
+		nodeSessions := result.data
+		if result.err != nil {
+			nodeSessions = NodeDebuggingSessions{
+				Sessions: []controlpanel.DebuggingSession{},
+				Error:    result.err.Error(),
+			}
+		}
+		// the node id is decided by the cluster, not the node itself
+		nodeSessions.NodeID = result.nodeID
+		sessions = append(sessions, nodeSessions)
+	}
+
+	return entities.NewSuccessResponse(sessions)
+}
+
+func kickDebuggingRuntime(nodeID string, kick DebuggingRuntimeKick) (NodeDebuggingRuntimeKick, error) {
+	manager := plugin_manager.Manager()
+	if manager == nil {
+		return NodeDebuggingRuntimeKick{}, errors.New("plugin manager is not initialized")
+	}
+
+	err := manager.KickDebuggingRuntime(kick.TenantID, kick.PluginUniqueIdentifier)
+	if errors.Is(err, controlpanel.ErrDebuggingRuntimeNotFound) {
+		return NodeDebuggingRuntimeKick{NodeID: nodeID, Kicked: false}, nil
+	} else if err != nil {
+		return NodeDebuggingRuntimeKick{}, err
+	}
+
+	return NodeDebuggingRuntimeKick{NodeID: nodeID, Kicked: true}, nil
+}
+
+// KickDebuggingRuntime disconnects a debugging plugin of a tenant from this node
+func KickDebuggingRuntime(nodeID string, kick DebuggingRuntimeKick) *entities.Response {
+	result, err := kickDebuggingRuntime(nodeID, kick)
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(result)
+}
+
+// KickClusterDebuggingRuntime disconnects a debugging plugin of a tenant from all nodes in the cluster
+// the request is redirected to other nodes with `scope=node`
+func KickClusterDebuggingRuntime(
+	c *cluster.Cluster,
+	request *http.Request,
+	kick DebuggingRuntimeKick,
+) *entities.Response {
+	nodeKick := kick
+	nodeKick.Scope = PLUGIN_RUNTIME_INVENTORY_SCOPE_NODE
+	body, err := json.Marshal(nodeKick)
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	results, err := fanOutToCluster(
+		request.Context(),
+		c,
+		"KickClusterDebuggingRuntime",
+		func(ctx context.Context) (*http.Request, error) {
+			nodeRequest, err := http.NewRequestWithContext(ctx, request.Method, request.URL.String(), bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			nodeRequest.Header = request.Header.Clone()
+			return nodeRequest, nil
+		},
+		func(nodeID string) (NodeDebuggingRuntimeKick, error) {
+			return kickDebuggingRuntime(nodeID, nodeKick)
+		},
+	)
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	kicks := make([]NodeDebuggingRuntimeKick, 0, len(results))
+	for _, result := range results {
+		nodeKick := result.data
+		if result.err != nil {
+			nodeKick = NodeDebuggingRuntimeKick{Error: result.err.Error()}
+		}
+		// the node id is decided by the cluster, not the node itself
+		nodeKick.NodeID = result.nodeID
+		kicks = append(kicks, nodeKick)
+	}
+
+	return entities.NewSuccessResponse(kicks)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager"
 	"github.com/langgenius/dify-plugin-daemon/internal/core/session_manager"
 	"github.com/langgenius/dify-plugin-daemon/internal/db"
+	"github.com/langgenius/dify-plugin-daemon/internal/events"
 	"github.com/langgenius/dify-plugin-daemon/internal/service/install_service"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/exception"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
@@ -228,6 +229,7 @@ func EnableEndpoint(endpointID string, tenantID string) *entities.Response {
 	}
 
 	invalidateEndpointCache(endpoint.HookID)
+	publishEndpointEvent(events.EVENT_TYPE_ENDPOINT_ENABLED, endpoint)
 
 	return entities.NewSuccessResponse(true)
 }
@@ -239,6 +241,7 @@ func DisableEndpoint(endpointID string, tenantID string) *entities.Response {
 	}
 
 	invalidateEndpointCache(endpoint.HookID)
+	publishEndpointEvent(events.EVENT_TYPE_ENDPOINT_DISABLED, endpoint)
 
 	return entities.NewSuccessResponse(true)
 }
@@ -253,6 +256,21 @@ func handleEndpointStateError(err error, action string) *entities.Response {
 	return exception.InternalServerError(fmt.Errorf("failed to %s endpoint: %w", action, err)).ToResponse()
 }
 
+// publishEndpointEvent publishes a state change of an endpoint to lifecycle event subscriptions
+func publishEndpointEvent(eventType events.EventType, endpoint *models.Endpoint) {
+	events.Publish(events.Event{
+		Type:     eventType,
+		TenantID: endpoint.TenantID,
+		Data: map[string]any{
+			"endpoint_id": endpoint.ID,
+			"hook_id":     endpoint.HookID,
+			"plugin_id":   endpoint.PluginID,
+			"name":        endpoint.Name,
+			"enabled":     endpoint.Enabled,
+		},
+	})
+}
+
 func invalidateEndpointCache(hookID string) {
 	endpointCacheKey := helper.EndpointCacheKey(hookID)
 	if _, err := cache.AutoDelete[models.Endpoint](endpointCacheKey); err != nil {
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/db"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/exception"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities"
+)
+
+// CreatedEventSubscription is the only response carrying the secret of a subscription
+type CreatedEventSubscription struct {
+	models.EventSubscription
+	Secret string `json:"secret"`
+}
+
+// CreateEventSubscription registers a webhook receiving lifecycle events
+// a random secret is generated if it's empty, it's returned once and never listed
+func CreateEventSubscription(
+	url string,
+	secret string,
+	eventTypes []string,
+	description string,
+) *entities.Response {
+	if secret == "" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return exception.InternalServerError(err).ToResponse()
+		}
+		secret = hex.EncodeToString(key)
+	}
+
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	subscription := models.EventSubscription{
+		URL:         url,
+		Secret:      secret,
+		EventTypes:  eventTypes,
+		Enabled:     true,
+		Description: description,
+	}
+	if err := db.Create(&subscription); err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(CreatedEventSubscription{
+		EventSubscription: subscription,
+		Secret:            secret,
+	})
+}
+
+func ListEventSubscriptions(page int, pageSize int) *entities.Response {
+	subscriptions, err := db.GetAll[models.EventSubscription](
+		db.OrderBy("created_at", true),
+		db.Page(page, pageSize),
+	)
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(subscriptions)
+}
+
+func DeleteEventSubscription(id string) *entities.Response {
+	_, err := db.GetOne[models.EventSubscription](
+		db.Equal("id", id),
+	)
+	if errors.Is(err, db.ErrDatabaseNotFound) {
+		return exception.NotFoundError(errors.New("event subscription not found")).ToResponse()
+	}
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	if err := db.DeleteByCondition(models.EventSubscription{
+		Model: models.Model{ID: id},
+	}); err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(true)
+}
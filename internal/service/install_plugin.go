@@ -290,12 +290,16 @@ func UpgradePlugin(
 			return exception.InternalServerError(err).ToResponse()
 		}
 
+		tasks.InvalidatePluginInstallationCache(tenantId, newPluginUniqueIdentifier)
+
 		// call RemovePluginIfNeeded in a new goroutine
 		routine.Submit(routinepkg.Labels{
 			routinepkg.RoutineLabelKeyModule: "service",
 			routinepkg.RoutineLabelKeyMethod: "UpgradePlugin.RemovePluginIfNeeded",
 		}, func() {
-			if err := tasks.RemovePluginIfNeeded(manager, originalPluginUniqueIdentifier, response); err != nil {
+			if err := tasks.RemovePluginIfNeeded(
+				manager, originalPluginUniqueIdentifier, newPluginUniqueIdentifier, response, nil,
+			); err != nil {
 				log.Error("failed to remove uninstalled plugin: %v", err)
 			}
 		})
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/cluster"
+	controlpanel "github.com/langgenius/dify-plugin-daemon/internal/core/control_panel"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/exception"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities"
+)
+
+// NodeDiskGCReport is the disk garbage collection report of a node
+type NodeDiskGCReport struct {
+	NodeID string                     `json:"node_id"`
+	Report *controlpanel.DiskGCReport `json:"report,omitempty"`
+	// set if the node failed to collect
+	Error string `json:"error,omitempty"`
+}
+
+func nodeDiskGC(dryRun bool) func(nodeID string) (NodeDiskGCReport, error) {
+	return func(nodeID string) (NodeDiskGCReport, error) {
+		manager := plugin_manager.Manager()
+		if manager == nil {
+			return NodeDiskGCReport{}, errors.New("plugin manager is not initialized")
+		}
+
+		report, err := manager.CollectDisk(dryRun)
+		if err != nil {
+			return NodeDiskGCReport{}, err
+		}
+
+		return NodeDiskGCReport{NodeID: nodeID, Report: &report}, nil
+	}
+}
+
+func validatePluginDiskGC(config *app.Config) error {
+	if config.Platform != app.PLATFORM_LOCAL {
+		return errors.New("disk garbage collection is only available on local platform")
+	}
+	return nil
+}
+
+// CollectPluginDisk removes orphaned working paths, stderr logs and packages on this node
+// nothing is removed by a dry run, the report shows what would be removed
+func CollectPluginDisk(config *app.Config, nodeID string, dryRun bool) *entities.Response {
+	if err := validatePluginDiskGC(config); err != nil {
+		return exception.BadRequestError(err).ToResponse()
+	}
+
+	report, err := nodeDiskGC(dryRun)(nodeID)
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(report)
+}
+
+// CollectClusterPluginDisk runs disk garbage collection on all nodes in the cluster
+// the request is redirected to other nodes with `scope=node`, a failed node doesn't fail others
+func CollectClusterPluginDisk(
+	c *cluster.Cluster,
+	config *app.Config,
+	request *http.Request,
+	dryRun bool,
+) *entities.Response {
+	if err := validatePluginDiskGC(config); err != nil {
+		return exception.BadRequestError(err).ToResponse()
+	}
+
+	results, err := fanOutToCluster(
+		request.Context(),
+		c,
+		"CollectClusterPluginDisk",
+		func(ctx context.Context) (*http.Request, error) {
+			nodeRequest := request.Clone(ctx)
+			query := nodeRequest.URL.Query()
+			query.Set("scope", PLUGIN_RUNTIME_INVENTORY_SCOPE_NODE)
+			nodeRequest.URL.RawQuery = query.Encode()
+			return nodeRequest, nil
+		},
+		nodeDiskGC(dryRun),
+	)
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	reports := make([]NodeDiskGCReport, 0, len(results))
+	for _, result := range results {
+		report := result.data
+		if result.err != nil {
+			report = NodeDiskGCReport{Error: result.err.Error()}
+		}
+		// the node id is decided by the cluster, not the node itself
+		report.NodeID = result.nodeID
+		reports = append(reports, report)
+	}
+
+	return entities.NewSuccessResponse(reports)
+}
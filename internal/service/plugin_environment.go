@@ -0,0 +1,122 @@
+package service
+
+import (
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager"
+	"github.com/langgenius/dify-plugin-daemon/internal/db"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/exception"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+)
+
+// ListPluginEnvironments lists environment variables defined for a plugin, values are redacted
+func ListPluginEnvironments(pluginID string) *entities.Response {
+	type environment struct {
+		PluginID               string   `json:"plugin_id"`
+		PluginUniqueIdentifier string   `json:"plugin_unique_identifier"`
+		Variables              []string `json:"variables"`
+	}
+
+	environments, err := db.GetAll[models.PluginEnvironment](
+		db.Equal("plugin_id", pluginID),
+	)
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	data := make([]environment, 0, len(environments))
+	for _, e := range environments {
+		// only names are exposed, values may contain credentials
+		names := slices.Sorted(maps.Keys(e.Variables))
+		data = append(data, environment{
+			PluginID:               e.PluginID,
+			PluginUniqueIdentifier: e.PluginUniqueIdentifier,
+			Variables:              names,
+		})
+	}
+
+	return entities.NewSuccessResponse(data)
+}
+
+// UpdatePluginEnvironment replaces environment variables of a plugin
+// an empty `pluginUniqueIdentifier` means the variables apply to all versions of the plugin
+// empty variables remove the record
+func UpdatePluginEnvironment(
+	pluginID string,
+	pluginUniqueIdentifier string,
+	variables map[string]string,
+) *entities.Response {
+	if pluginUniqueIdentifier != "" {
+		identifier, err := plugin_entities.NewPluginUniqueIdentifier(pluginUniqueIdentifier)
+		if err != nil {
+			return exception.UniqueIdentifierError(err).ToResponse()
+		}
+		if identifier.PluginID() != pluginID {
+			return exception.BadRequestError(
+				errors.New("plugin unique identifier does not belong to the plugin"),
+			).ToResponse()
+		}
+	}
+
+	if err := local_runtime.ValidateExtraEnvironment(variables); err != nil {
+		return exception.BadRequestError(err).ToResponse()
+	}
+
+	environment, err := db.GetOne[models.PluginEnvironment](
+		db.Equal("plugin_id", pluginID),
+		db.Equal("plugin_unique_identifier", pluginUniqueIdentifier),
+	)
+	if err != nil && !errors.Is(err, db.ErrDatabaseNotFound) {
+		return exception.InternalServerError(err).ToResponse()
+	}
+	exists := err == nil
+
+	switch {
+	case len(variables) == 0 && exists:
+		err = db.Delete(&environment)
+	case len(variables) == 0:
+		err = nil
+	case exists:
+		environment.Variables = variables
+		err = db.Update(&environment)
+	default:
+		err = db.Create(&models.PluginEnvironment{
+			PluginID:               pluginID,
+			PluginUniqueIdentifier: pluginUniqueIdentifier,
+			Variables:              variables,
+		})
+	}
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	// apply to runtimes on this node, others pick it up on their next launch
+	if manager := plugin_manager.Manager(); manager != nil {
+		manager.ReloadLocalPluginEnvironment(pluginID)
+	}
+
+	return entities.NewSuccessResponse(true)
+}
+
+// FetchPluginInstanceEnvironments returns the effective environment of running instances
+// of a local plugin on this node, values are redacted
+func FetchPluginInstanceEnvironments(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) *entities.Response {
+	manager := plugin_manager.Manager()
+	if manager == nil {
+		return exception.InternalServerError(errors.New("plugin manager is not initialized")).ToResponse()
+	}
+
+	environments, err := manager.LocalPluginInstanceEnvironments(pluginUniqueIdentifier)
+	if err != nil {
+		return exception.NotFoundError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(environments)
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager"
+	"github.com/langgenius/dify-plugin-daemon/internal/db"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/exception"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/stream"
+)
+
+// checkPluginLogsAccess makes sure the plugin is installed by the tenant
+// a runtime is shared by all tenants installed the plugin, logs must be filtered by the tenant as well
+func checkPluginLogsAccess(
+	tenantId string,
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) *entities.Response {
+	_, err := db.GetOne[models.PluginInstallation](
+		db.Equal("tenant_id", tenantId),
+		db.Equal("plugin_unique_identifier", pluginUniqueIdentifier.String()),
+	)
+	if err == db.ErrDatabaseNotFound {
+		return exception.NotFoundError(errors.New("plugin installation not found for this tenant")).ToResponse()
+	} else if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return nil
+}
+
+// FetchPluginLogs returns buffered logs of sessions of the tenant of a local plugin on this node
+func FetchPluginLogs(
+	tenantId string,
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	query local_runtime.PluginLogQuery,
+) *entities.Response {
+	if response := checkPluginLogsAccess(tenantId, pluginUniqueIdentifier); response != nil {
+		return response
+	}
+
+	query.TenantId = tenantId
+	return FetchAllPluginLogs(pluginUniqueIdentifier, query)
+}
+
+// FetchAllPluginLogs returns buffered logs of a local plugin on this node for operators
+// including logs of all tenants and logs of instances, e.g. startup and crash output
+func FetchAllPluginLogs(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	query local_runtime.PluginLogQuery,
+) *entities.Response {
+	manager := plugin_manager.Manager()
+	if manager == nil {
+		return exception.InternalServerError(errors.New("plugin manager is not initialized")).ToResponse()
+	}
+
+	logs, err := manager.QueryLocalPluginLogs(pluginUniqueIdentifier, query)
+	if err != nil {
+		return exception.NotFoundError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(logs)
+}
+
+// TailPluginLogs streams buffered and new logs of sessions of the tenant of a local plugin on this node
+// as server-sent events
+func TailPluginLogs(
+	ctx *gin.Context,
+	tenantId string,
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	query local_runtime.PluginLogQuery,
+	maxTimeoutSeconds int,
+) {
+	if response := checkPluginLogsAccess(tenantId, pluginUniqueIdentifier); response != nil {
+		ctx.JSON(http.StatusOK, response)
+		return
+	}
+
+	query.TenantId = tenantId
+	TailAllPluginLogs(ctx, pluginUniqueIdentifier, query, maxTimeoutSeconds)
+}
+
+// TailAllPluginLogs streams buffered and new logs of a local plugin on this node for operators
+func TailAllPluginLogs(
+	ctx *gin.Context,
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	query local_runtime.PluginLogQuery,
+	maxTimeoutSeconds int,
+) {
+	manager := plugin_manager.Manager()
+	if manager == nil {
+		ctx.JSON(http.StatusOK, exception.InternalServerError(errors.New("plugin manager is not initialized")).ToResponse())
+		return
+	}
+
+	logs, err := manager.TailLocalPluginLogs(pluginUniqueIdentifier, query)
+	if err != nil {
+		ctx.JSON(http.StatusOK, exception.NotFoundError(err).ToResponse())
+		return
+	}
+
+	baseSSEService(func() (*stream.Stream[local_runtime.PluginLogEntry], error) {
+		return logs, nil
+	}, ctx, maxTimeoutSeconds)
+}
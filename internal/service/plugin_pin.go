@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/db"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/exception"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities"
+)
+
+func ListPluginPins() *entities.Response {
+	pins, err := db.GetAll[models.PluginPin](
+		db.OrderBy("created_at", true),
+	)
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(pins)
+}
+
+// PinPlugin keeps all versions of a plugin running when local plugins are launched on demand
+// nodes launch it on their next sync, pinning a pinned plugin again only updates the operator
+func PinPlugin(pluginID string, operator string) *entities.Response {
+	pin, err := db.GetOne[models.PluginPin](
+		db.Equal("plugin_id", pluginID),
+	)
+	if errors.Is(err, db.ErrDatabaseNotFound) {
+		pin = models.PluginPin{
+			PluginID: pluginID,
+			Operator: operator,
+		}
+		if err := db.Create(&pin); err != nil {
+			return exception.InternalServerError(err).ToResponse()
+		}
+		return entities.NewSuccessResponse(pin)
+	}
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	pin.Operator = operator
+	if err := db.Update(&pin); err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(pin)
+}
+
+// UnpinPlugin allows a plugin to be stopped once it's idle, pins by `PLUGIN_LOCAL_PINNED_PLUGINS` are kept
+func UnpinPlugin(pluginID string) *entities.Response {
+	if err := db.DeleteByCondition(models.PluginPin{
+		PluginID: pluginID,
+	}); err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(true)
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/exception"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+)
+
+// FetchPluginRestartPolicy returns restarts and the crash-looping state of a local plugin on this node
+func FetchPluginRestartPolicy(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) *entities.Response {
+	manager := plugin_manager.Manager()
+	if manager == nil {
+		return exception.InternalServerError(errors.New("plugin manager is not initialized")).ToResponse()
+	}
+
+	status, err := manager.LocalPluginRestartPolicyStatus(pluginUniqueIdentifier)
+	if err != nil {
+		return exception.NotFoundError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(status)
+}
+
+// ResetPluginRestartPolicy clears failures of a local plugin on this node
+// a crash-looping plugin starts respawning instances again
+func ResetPluginRestartPolicy(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) *entities.Response {
+	manager := plugin_manager.Manager()
+	if manager == nil {
+		return exception.InternalServerError(errors.New("plugin manager is not initialized")).ToResponse()
+	}
+
+	if err := manager.ResetLocalPluginRestartPolicy(pluginUniqueIdentifier); err != nil {
+		return exception.NotFoundError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(true)
+}
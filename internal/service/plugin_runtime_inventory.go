@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/cluster"
+	controlpanel "github.com/langgenius/dify-plugin-daemon/internal/core/control_panel"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/exception"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities"
+)
+
+const (
+	PLUGIN_RUNTIME_INVENTORY_SCOPE_NODE    = "node"
+	PLUGIN_RUNTIME_INVENTORY_SCOPE_CLUSTER = "cluster"
+)
+
+// NodeRuntimeInventory lists plugin runtimes of a node
+type NodeRuntimeInventory struct {
+	NodeID   string                          `json:"node_id"`
+	Runtimes []controlpanel.RuntimeInventory `json:"runtimes"`
+	// set if the node failed to answer
+	Error string `json:"error,omitempty"`
+}
+
+func nodeRuntimeInventory(nodeID string) (NodeRuntimeInventory, error) {
+	manager := plugin_manager.Manager()
+	if manager == nil {
+		return NodeRuntimeInventory{}, errors.New("plugin manager is not initialized")
+	}
+
+	runtimes, err := manager.RuntimeInventory()
+	if err != nil {
+		return NodeRuntimeInventory{}, err
+	}
+
+	return NodeRuntimeInventory{NodeID: nodeID, Runtimes: runtimes}, nil
+}
+
+// ListPluginRuntimes lists local, debugging and serverless runtimes of this node
+func ListPluginRuntimes(nodeID string) *entities.Response {
+	inventory, err := nodeRuntimeInventory(nodeID)
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(inventory)
+}
+
+// ListClusterPluginRuntimes lists runtimes of all nodes in the cluster
+// the request is redirected to other nodes with `scope=node`, a failed node doesn't fail the whole list
+func ListClusterPluginRuntimes(c *cluster.Cluster, request *http.Request) *entities.Response {
+	results, err := fanOutToCluster(
+		request.Context(),
+		c,
+		"ListClusterPluginRuntimes",
+		func(ctx context.Context) (*http.Request, error) {
+			// ask other nodes for their own runtimes only
+			nodeRequest := request.Clone(ctx)
+			query := nodeRequest.URL.Query()
+			query.Set("scope", PLUGIN_RUNTIME_INVENTORY_SCOPE_NODE)
+			nodeRequest.URL.RawQuery = query.Encode()
+			return nodeRequest, nil
+		},
+		nodeRuntimeInventory,
+	)
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	inventories := make([]NodeRuntimeInventory, 0, len(results))
+	for _, result := range results {
+		inventory := result.data
+		if result.err != nil {
+			inventory = NodeRuntimeInventory{
+				Runtimes: []controlpanel.RuntimeInventory{},
+				Error:    result.err.Error(),
+			}
+		}
+		// the node id is decided by the cluster, not the node itself
+		inventory.NodeID = result.nodeID
+		inventories = append(inventories, inventory)
+	}
+
+	return entities.NewSuccessResponse(inventories)
+}
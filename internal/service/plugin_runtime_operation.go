@@ -0,0 +1,324 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/langgenius/dify-plugin-daemon/internal/cluster"
+	controlpanel "github.com/langgenius/dify-plugin-daemon/internal/core/control_panel"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/local_runtime"
+	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager"
+	"github.com/langgenius/dify-plugin-daemon/internal/db"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/app"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/exception"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	routinepkg "github.com/langgenius/dify-plugin-daemon/pkg/routine"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/routine"
+)
+
+// PluginRuntimeOperation is an operation applied to a local plugin runtime by operators
+type PluginRuntimeOperation struct {
+	Action                 models.PluginRuntimeAction             `json:"-"`
+	PluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier `json:"plugin_unique_identifier"`
+	Scope                  string                                 `json:"scope"`
+	Replicas               int32                                  `json:"replicas"`
+	Reason                 string                                 `json:"reason"`
+	Operator               string                                 `json:"operator"`
+	// shared by all records of an operation applied to the whole cluster
+	OperationID string `json:"operation_id"`
+}
+
+// NodePluginRuntimeOperation is the result of an operation applied to a node
+type NodePluginRuntimeOperation struct {
+	NodeID string                     `json:"node_id"`
+	Audit  *models.PluginRuntimeAudit `json:"audit,omitempty"`
+	// set if the node failed to apply the operation
+	Error string `json:"error,omitempty"`
+}
+
+// ClusterPluginRuntimeOperation is the result of an operation applied to the whole cluster
+type ClusterPluginRuntimeOperation struct {
+	Audit models.PluginRuntimeAudit    `json:"audit"`
+	Nodes []NodePluginRuntimeOperation `json:"nodes"`
+}
+
+func validatePluginRuntimeOperation(config *app.Config, operation PluginRuntimeOperation) error {
+	if config.Platform != app.PLATFORM_LOCAL {
+		return errors.New("plugin runtime operations are only available on local platform")
+	}
+	if operation.Action == models.PLUGIN_RUNTIME_ACTION_SCALE && operation.Replicas < 1 {
+		return errors.New("replicas must be at least 1")
+	}
+	if operation.Action == models.PLUGIN_RUNTIME_ACTION_QUARANTINE && operation.Reason == "" {
+		return errors.New("reason is required to quarantine a plugin")
+	}
+	return nil
+}
+
+// ApplyPluginRuntimeOperation applies an operation to a local plugin on this node
+// the operation is recorded before it's applied, the record is finished once the operation is done
+func ApplyPluginRuntimeOperation(
+	config *app.Config,
+	nodeID string,
+	operation PluginRuntimeOperation,
+) *entities.Response {
+	if err := validatePluginRuntimeOperation(config, operation); err != nil {
+		return exception.BadRequestError(err).ToResponse()
+	}
+
+	audit, err := applyPluginRuntimeOperation(nodeID, operation)
+	if audit == nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+	if err != nil {
+		return pluginRuntimeOperationErrorResponse(err)
+	}
+
+	return entities.NewSuccessResponse(audit)
+}
+
+func applyPluginRuntimeOperation(
+	nodeID string,
+	operation PluginRuntimeOperation,
+) (*models.PluginRuntimeAudit, error) {
+	manager := plugin_manager.Manager()
+	if manager == nil {
+		return nil, errors.New("plugin manager is not initialized")
+	}
+
+	if operation.OperationID == "" {
+		operation.OperationID = uuid.New().String()
+	}
+
+	audit := newPluginRuntimeAudit(operation, PLUGIN_RUNTIME_INVENTORY_SCOPE_NODE, nodeID)
+	if err := db.Create(audit); err != nil {
+		return nil, errors.Join(errors.New("failed to record the operation"), err)
+	}
+
+	var ch <-chan error
+	var err error
+	switch operation.Action {
+	case models.PLUGIN_RUNTIME_ACTION_RESTART:
+		ch, err = manager.RestartLocalPlugin(operation.PluginUniqueIdentifier)
+	case models.PLUGIN_RUNTIME_ACTION_STOP:
+		ch, err = manager.StopLocalPlugin(operation.PluginUniqueIdentifier)
+	case models.PLUGIN_RUNTIME_ACTION_START:
+		ch, err = manager.StartLocalPlugin(operation.PluginUniqueIdentifier)
+	case models.PLUGIN_RUNTIME_ACTION_SCALE:
+		err = manager.ScaleLocalPlugin(operation.PluginUniqueIdentifier, operation.Replicas)
+	case models.PLUGIN_RUNTIME_ACTION_QUARANTINE:
+		ch, err = manager.QuarantineLocalPlugin(operation.PluginUniqueIdentifier, operation.Reason)
+	case models.PLUGIN_RUNTIME_ACTION_RELEASE:
+		ch, err = manager.ReleaseLocalPlugin(operation.PluginUniqueIdentifier)
+	default:
+		err = fmt.Errorf("unknown action %s", operation.Action)
+	}
+
+	if err != nil || ch == nil {
+		finishPluginRuntimeAudit(audit, err)
+		result := *audit
+		return &result, err
+	}
+
+	// the record is updated in background, return a copy
+	result := *audit
+	routine.Submit(routinepkg.Labels{
+		routinepkg.RoutineLabelKeyModule: "service",
+		routinepkg.RoutineLabelKeyMethod: "ApplyPluginRuntimeOperation",
+	}, func() {
+		finishPluginRuntimeAudit(audit, <-ch)
+	})
+
+	return &result, nil
+}
+
+// ApplyClusterPluginRuntimeOperation applies an operation to a local plugin on all nodes in the cluster
+// quarantines are persisted, nodes started later respect them as well
+//
+// the operation is redirected to other nodes with `scope=node`, each node records its own result,
+// the record of the cluster is finished once all nodes accepted the operation
+func ApplyClusterPluginRuntimeOperation(
+	c *cluster.Cluster,
+	config *app.Config,
+	request *http.Request,
+	operation PluginRuntimeOperation,
+) *entities.Response {
+	if err := validatePluginRuntimeOperation(config, operation); err != nil {
+		return exception.BadRequestError(err).ToResponse()
+	}
+
+	operation.OperationID = uuid.New().String()
+	audit := newPluginRuntimeAudit(operation, PLUGIN_RUNTIME_INVENTORY_SCOPE_CLUSTER, "")
+	if err := db.Create(audit); err != nil {
+		return exception.InternalServerError(errors.Join(errors.New("failed to record the operation"), err)).ToResponse()
+	}
+
+	// persist it before nodes are notified, a node launching the plugin meanwhile respects it
+	if err := persistPluginQuarantine(operation); err != nil {
+		finishPluginRuntimeAudit(audit, err)
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	nodeOperation := operation
+	nodeOperation.Scope = PLUGIN_RUNTIME_INVENTORY_SCOPE_NODE
+	body, err := json.Marshal(nodeOperation)
+	if err != nil {
+		finishPluginRuntimeAudit(audit, err)
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	results, err := fanOutToCluster(
+		request.Context(),
+		c,
+		"ApplyClusterPluginRuntimeOperation",
+		func(ctx context.Context) (*http.Request, error) {
+			nodeRequest, err := http.NewRequestWithContext(ctx, request.Method, request.URL.String(), bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			nodeRequest.Header = request.Header.Clone()
+			return nodeRequest, nil
+		},
+		func(nodeID string) (models.PluginRuntimeAudit, error) {
+			audit, err := applyPluginRuntimeOperation(nodeID, nodeOperation)
+			if audit == nil {
+				return models.PluginRuntimeAudit{}, err
+			}
+			return *audit, err
+		},
+	)
+	if err != nil {
+		finishPluginRuntimeAudit(audit, err)
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	nodes := make([]NodePluginRuntimeOperation, 0, len(results))
+	failures := []string{}
+	for _, result := range results {
+		node := NodePluginRuntimeOperation{NodeID: result.nodeID}
+		if result.err != nil {
+			node.Error = result.err.Error()
+			failures = append(failures, fmt.Sprintf("%s: %s", result.nodeID, result.err.Error()))
+		} else {
+			node.Audit = &result.data
+		}
+		nodes = append(nodes, node)
+	}
+
+	if len(failures) > 0 {
+		finishPluginRuntimeAudit(audit, errors.New(strings.Join(failures, "; ")))
+	} else {
+		finishPluginRuntimeAudit(audit, nil)
+	}
+
+	return entities.NewSuccessResponse(ClusterPluginRuntimeOperation{
+		Audit: *audit,
+		Nodes: nodes,
+	})
+}
+
+// persistPluginQuarantine stores or removes the quarantine of a plugin applied to the whole cluster
+func persistPluginQuarantine(operation PluginRuntimeOperation) error {
+	switch operation.Action {
+	case models.PLUGIN_RUNTIME_ACTION_QUARANTINE:
+		quarantine, err := db.GetOne[models.PluginQuarantine](
+			db.Equal("plugin_unique_identifier", operation.PluginUniqueIdentifier.String()),
+		)
+		if errors.Is(err, db.ErrDatabaseNotFound) {
+			return db.Create(&models.PluginQuarantine{
+				PluginUniqueIdentifier: operation.PluginUniqueIdentifier.String(),
+				Reason:                 operation.Reason,
+				Operator:               operation.Operator,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		quarantine.Reason = operation.Reason
+		quarantine.Operator = operation.Operator
+		return db.Update(&quarantine)
+	case models.PLUGIN_RUNTIME_ACTION_RELEASE:
+		return db.DeleteByCondition(models.PluginQuarantine{
+			PluginUniqueIdentifier: operation.PluginUniqueIdentifier.String(),
+		})
+	}
+	return nil
+}
+
+func newPluginRuntimeAudit(
+	operation PluginRuntimeOperation,
+	scope string,
+	nodeID string,
+) *models.PluginRuntimeAudit {
+	return &models.PluginRuntimeAudit{
+		OperationID:            operation.OperationID,
+		Action:                 operation.Action,
+		PluginUniqueIdentifier: operation.PluginUniqueIdentifier.String(),
+		Scope:                  scope,
+		NodeID:                 nodeID,
+		Replicas:               operation.Replicas,
+		Reason:                 operation.Reason,
+		Operator:               operation.Operator,
+		Status:                 models.PluginRuntimeAuditStatusRunning,
+	}
+}
+
+func finishPluginRuntimeAudit(audit *models.PluginRuntimeAudit, err error) {
+	audit.Status = models.PluginRuntimeAuditStatusSucceeded
+	if err != nil {
+		audit.Status = models.PluginRuntimeAuditStatusFailed
+		audit.Error = err.Error()
+	}
+
+	if err := db.Update(audit); err != nil {
+		log.Error("failed to update audit %s of plugin %s: %s", audit.ID, audit.PluginUniqueIdentifier, err.Error())
+	}
+}
+
+func pluginRuntimeOperationErrorResponse(err error) *entities.Response {
+	switch {
+	case errors.Is(err, controlpanel.ErrLocalPluginRuntimeNotFound):
+		return exception.NotFoundError(err).ToResponse()
+	case errors.Is(err, controlpanel.ErrLocalPluginQuarantined),
+		errors.Is(err, controlpanel.ErrLocalPluginNotQuarantined),
+		errors.Is(err, local_runtime.ErrInvalidReplicas),
+		errors.Is(err, local_runtime.ErrReplicasManagedByAutoscaler):
+		return exception.BadRequestError(err).ToResponse()
+	default:
+		return exception.InternalServerError(err).ToResponse()
+	}
+}
+
+// ListPluginRuntimeAudits lists operations applied to plugin runtimes, the newest first
+// empty `pluginUniqueIdentifier` or `operationID` means no filter
+func ListPluginRuntimeAudits(
+	pluginUniqueIdentifier string,
+	operationID string,
+	page int,
+	pageSize int,
+) *entities.Response {
+	queries := []db.GenericQuery{}
+	if pluginUniqueIdentifier != "" {
+		queries = append(queries, db.Equal("plugin_unique_identifier", pluginUniqueIdentifier))
+	}
+	if operationID != "" {
+		queries = append(queries, db.Equal("operation_id", operationID))
+	}
+	queries = append(queries, db.OrderBy("created_at", true), db.Page(page, pageSize))
+
+	audits, err := db.GetAll[models.PluginRuntimeAudit](queries...)
+	if err != nil {
+		return exception.InternalServerError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(audits)
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager"
+	"github.com/langgenius/dify-plugin-daemon/internal/types/exception"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+)
+
+// FetchPluginRuntimeState returns the runtime state of a local plugin on this node
+// e.g. status, working path and patches applied to its sdk
+func FetchPluginRuntimeState(
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) *entities.Response {
+	manager := plugin_manager.Manager()
+	if manager == nil {
+		return exception.InternalServerError(errors.New("plugin manager is not initialized")).ToResponse()
+	}
+
+	state, err := manager.LocalPluginRuntimeState(pluginUniqueIdentifier)
+	if err != nil {
+		return exception.NotFoundError(err).ToResponse()
+	}
+
+	return entities.NewSuccessResponse(state)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/langgenius/dify-plugin-daemon/internal/core/dify_invocation"
 	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager"
 	"github.com/langgenius/dify-plugin-daemon/internal/db"
+	"github.com/langgenius/dify-plugin-daemon/internal/events"
 	"github.com/langgenius/dify-plugin-daemon/internal/service/install_service"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/exception"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
@@ -99,6 +100,8 @@ func SetupEndpoint(
 		return exception.InternalServerError(fmt.Errorf("failed to update endpoint: %v", err)).ToResponse()
 	}
 
+	publishEndpointEvent(events.EVENT_TYPE_ENDPOINT_CREATED, endpoint)
+
 	return entities.NewSuccessResponse(true)
 }
 
@@ -112,6 +115,8 @@ func RemoveEndpoint(endpoint_id string, tenant_id string) *entities.Response {
 	endpointCacheKey := helper.EndpointCacheKey(endpoint.HookID)
 	_, _ = cache.AutoDelete[models.Endpoint](endpointCacheKey)
 
+	publishEndpointEvent(events.EVENT_TYPE_ENDPOINT_REMOVED, endpoint)
+
 	manager := plugin_manager.Manager()
 	if manager == nil {
 		return exception.InternalServerError(errors.New("failed to get plugin manager")).ToResponse()
@@ -258,6 +263,8 @@ func UpdateEndpoint(endpoint_id string, tenant_id string, user_id string, name s
 	endpointCacheKey := helper.EndpointCacheKey(endpoint.HookID)
 	_, _ = cache.AutoDelete[models.Endpoint](endpointCacheKey)
 
+	publishEndpointEvent(events.EVENT_TYPE_ENDPOINT_UPDATED, &endpoint)
+
 	// clear credentials cache
 	if _, err := manager.BackwardsInvocation().InvokeEncrypt(&dify_invocation.InvokeEncryptRequest{
 		BaseInvokeDifyRequest: dify_invocation.BaseInvokeDifyRequest{
@@ -52,6 +52,7 @@ func ProcessInstallJob(
 	SetTaskStatusForOnePlugin(taskIDs, job.Identifier, models.InstallTaskStatusRunning, "starting")
 
 	// start installation process
+	manager.SetLocalPluginSource(job.Identifier, source)
 	installationStream, err := manager.Install(job.Identifier)
 	if err != nil {
 		SetTaskStatusForOnePlugin(taskIDs, job.Identifier, models.InstallTaskStatusFailed, fmt.Sprintf("failed to start installation: %v", err))
@@ -101,12 +102,16 @@ func ProcessUpgradeJob(
 	SetTaskStatusForOnePlugin(taskIDs, job.NewIdentifier, models.InstallTaskStatusRunning, "starting")
 
 	// start installation process
+	manager.SetLocalPluginSource(job.NewIdentifier, source)
 	installationStream, err := manager.Install(job.NewIdentifier)
 	if err != nil {
 		SetTaskStatusForOnePlugin(taskIDs, job.NewIdentifier, models.InstallTaskStatusFailed, fmt.Sprintf("failed to start installation: %v", err))
 		return
 	}
 
+	// blue/green upgrade, the original plugin keeps serving until the new one is ready
+	SetTaskMessageForOnePlugin(taskIDs, job.NewIdentifier, "waiting for the new version to be ready")
+
 	err = installationStream.Process(func(resp installation_entities.PluginInstallResponse) {
 		switch resp.Event {
 		case installation_entities.PluginInstallEventInfo:
@@ -114,6 +119,9 @@ func ProcessUpgradeJob(
 		case installation_entities.PluginInstallEventError:
 			SetTaskStatusForOnePlugin(taskIDs, job.NewIdentifier, models.InstallTaskStatusFailed, resp.Data)
 		case installation_entities.PluginInstallEventDone:
+			SetTaskMessageForOnePlugin(taskIDs, job.NewIdentifier, "switching traffic to the new version")
+
+			responses := make([]*curd.UpgradePluginResponse, 0, len(tenants))
 			for _, tenantID := range tenants {
 				response, err := curd.UpgradePlugin(
 					tenantID,
@@ -130,7 +138,18 @@ func ProcessUpgradeJob(
 					return
 				}
 
-				if err := RemovePluginIfNeeded(manager, job.OriginalIdentifier, response); err != nil {
+				InvalidatePluginInstallationCache(tenantID, job.NewIdentifier)
+				responses = append(responses, response)
+			}
+
+			// the original plugin is kept alive until its in-flight sessions are finished
+			onDraining := func(inFlightSessions int) {
+				SetTaskMessageForOnePlugin(taskIDs, job.NewIdentifier, fmt.Sprintf(
+					"draining the old version, %d sessions in flight", inFlightSessions,
+				))
+			}
+			for _, response := range responses {
+				if err := RemovePluginIfNeeded(manager, job.OriginalIdentifier, job.NewIdentifier, response, onDraining); err != nil {
 					log.Error("failed to remove uninstalled plugin: %v", err)
 				}
 			}
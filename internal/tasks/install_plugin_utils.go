@@ -4,11 +4,15 @@ import (
 	"errors"
 	"time"
 
+	controlpanel "github.com/langgenius/dify-plugin-daemon/internal/core/control_panel"
 	"github.com/langgenius/dify-plugin-daemon/internal/core/plugin_manager"
 	"github.com/langgenius/dify-plugin-daemon/internal/db"
+	"github.com/langgenius/dify-plugin-daemon/internal/events"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/models"
 	"github.com/langgenius/dify-plugin-daemon/internal/types/models/curd"
 	"github.com/langgenius/dify-plugin-daemon/pkg/entities/plugin_entities"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/cache"
+	"github.com/langgenius/dify-plugin-daemon/pkg/utils/cache/helper"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
 	"gorm.io/gorm"
 )
@@ -72,8 +76,10 @@ func SetTaskStatusForOnePlugin(
 	message = truncateMessage(message)
 
 	for _, taskID := range taskIDs {
+		var tenantID string
+		var previousStatus, taskStatus models.InstallTaskStatus
 		if err := UpdateTaskStatus(taskID, pluginUniqueIdentifier, func(task *models.InstallTask, plugin *models.InstallTaskPluginStatus) {
-			previousStatus := plugin.Status
+			previousStatus = plugin.Status
 			plugin.Status = status
 			plugin.Message = message
 			if status == models.InstallTaskStatusSuccess && previousStatus != models.InstallTaskStatusSuccess {
@@ -85,8 +91,26 @@ func SetTaskStatusForOnePlugin(
 					task.CompletedPlugins--
 				}
 			}
+			tenantID = task.TenantID
+			taskStatus = task.Status
 		}); err != nil {
 			log.Error("failed to update task status for %s: %v", pluginUniqueIdentifier.String(), err)
+			continue
+		}
+
+		if previousStatus != status {
+			events.Publish(events.Event{
+				Type:                   events.EVENT_TYPE_INSTALL_TASK_STATUS_CHANGED,
+				TenantID:               tenantID,
+				PluginUniqueIdentifier: pluginUniqueIdentifier.String(),
+				Data: map[string]any{
+					"task_id":         taskID,
+					"status":          status,
+					"previous_status": previousStatus,
+					"task_status":     taskStatus,
+					"message":         message,
+				},
+			})
 		}
 	}
 }
@@ -155,10 +179,15 @@ func DeleteTask(taskId string) error {
 	})
 }
 
+// RemovePluginIfNeeded removes the original plugin once no tenant uses it after an upgrade
+// a local one keeps serving its in-flight sessions until they are finished, new sessions go to
+// the new plugin meanwhile, `onDraining` is called with the number of sessions in flight
 func RemovePluginIfNeeded(
 	manager *plugin_manager.PluginManager,
 	originalPluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+	newPluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
 	response *curd.UpgradePluginResponse,
+	onDraining func(inFlightSessions int),
 ) error {
 	if response.IsOriginalPluginDeleted && response.DeletedPlugin != nil && response.DeletedPlugin.InstallType == plugin_entities.PLUGIN_RUNTIME_TYPE_LOCAL {
 		// uninstall plugin from local install bucket
@@ -166,11 +195,28 @@ func RemovePluginIfNeeded(
 			return errors.Join(err, errors.New("failed to remove plugin from local install bucket"))
 		}
 
-		// shutdown it gracefully
-		_, err := manager.ShutdownLocalPluginGracefully(originalPluginUniqueIdentifier)
-		if err != nil {
-			return errors.Join(err, errors.New("failed to shutdown plugin gracefully"))
+		// drain it and wait until it's shutdown
+		drained, err := manager.DrainLocalPlugin(originalPluginUniqueIdentifier, newPluginUniqueIdentifier, onDraining)
+		if err == controlpanel.ErrLocalPluginRuntimeNotFound {
+			// not running on this node
+			return nil
+		} else if err != nil {
+			return errors.Join(err, errors.New("failed to drain plugin"))
 		}
+
+		<-drained
 	}
 	return nil
 }
+
+// InvalidatePluginInstallationCache drops the cached installation of the plugin for the tenant
+// requests are dispatched according to the latest installation afterwards
+func InvalidatePluginInstallationCache(
+	tenantID string,
+	pluginUniqueIdentifier plugin_entities.PluginUniqueIdentifier,
+) {
+	cacheKey := helper.PluginInstallationCacheKey(pluginUniqueIdentifier.PluginID(), tenantID)
+	if _, err := cache.AutoDelete[models.PluginInstallation](cacheKey); err != nil {
+		log.Error("failed to invalidate plugin installation cache of %s: %v", pluginUniqueIdentifier.String(), err)
+	}
+}
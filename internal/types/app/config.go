@@ -82,6 +82,13 @@ type Config struct {
 	PluginRemoteInstallingMaxSingleTenantConn int    `envconfig:"PLUGIN_REMOTE_INSTALLING_MAX_SINGLE_TENANT_CONN"`
 	PluginRemoteInstallServerEventLoopNums    int    `envconfig:"PLUGIN_REMOTE_INSTALL_SERVER_EVENT_LOOP_NUMS"`
 
+	// tls of the remote installing server, plaintext is only recommended for localhost
+	PluginRemoteInstallingTLSEnabled  bool   `envconfig:"PLUGIN_REMOTE_INSTALLING_TLS_ENABLED"`
+	PluginRemoteInstallingTLSCertFile string `envconfig:"PLUGIN_REMOTE_INSTALLING_TLS_CERT_FILE"`
+	PluginRemoteInstallingTLSKeyFile  string `envconfig:"PLUGIN_REMOTE_INSTALLING_TLS_KEY_FILE"`
+	// client certificates signed by it are required if set, the common name of a certificate is the tenant id
+	PluginRemoteInstallingTLSClientCAFile string `envconfig:"PLUGIN_REMOTE_INSTALLING_TLS_CLIENT_CA_FILE"`
+
 	// plugin endpoint
 	PluginEndpointEnabled bool `envconfig:"PLUGIN_ENDPOINT_ENABLED" default:"true"`
 
@@ -97,6 +104,94 @@ type Config struct {
 	// local launching max concurrent
 	PluginLocalLaunchingConcurrent int `envconfig:"PLUGIN_LOCAL_LAUNCHING_CONCURRENT" validate:"required"`
 
+	// strategy to dispatch sessions across instances of a local plugin
+	PluginLocalLoadBalancingStrategy string `envconfig:"PLUGIN_LOCAL_LOAD_BALANCING_STRATEGY" default:"least_outstanding" validate:"omitempty,oneof=round_robin least_outstanding power_of_two_choices"`
+	// per plugin strategy, format: `author/name:strategy,author/name:strategy`
+	PluginLocalLoadBalancingStrategyOverrides map[string]string `envconfig:"PLUGIN_LOCAL_LOAD_BALANCING_STRATEGY_OVERRIDES"`
+
+	// autoscaling of local plugin instances
+	PluginLocalAutoscaleEnabled                bool `envconfig:"PLUGIN_LOCAL_AUTOSCALE_ENABLED" default:"false"`
+	PluginLocalAutoscaleMinReplicas            int  `envconfig:"PLUGIN_LOCAL_AUTOSCALE_MIN_REPLICAS"`
+	PluginLocalAutoscaleMaxReplicas            int  `envconfig:"PLUGIN_LOCAL_AUTOSCALE_MAX_REPLICAS"`
+	PluginLocalAutoscaleTargetInFlight         int  `envconfig:"PLUGIN_LOCAL_AUTOSCALE_TARGET_IN_FLIGHT"`                         // sessions per instance
+	PluginLocalAutoscaleFirstResponseThreshold int  `envconfig:"PLUGIN_LOCAL_AUTOSCALE_FIRST_RESPONSE_THRESHOLD" default:"10000"` // in milliseconds, 0 to disable
+	PluginLocalAutoscaleScaleUpCooldown        int  `envconfig:"PLUGIN_LOCAL_AUTOSCALE_SCALE_UP_COOLDOWN"`                        // in seconds
+	PluginLocalAutoscaleScaleDownCooldown      int  `envconfig:"PLUGIN_LOCAL_AUTOSCALE_SCALE_DOWN_COOLDOWN"`                      // in seconds
+
+	// restart policy of failed local plugin instances, exponential backoff between restarts
+	// a plugin failing more than max restarts within the window stops respawning until it's reset
+	PluginLocalRestartInitialBackoff int `envconfig:"PLUGIN_LOCAL_RESTART_INITIAL_BACKOFF"` // in seconds
+	PluginLocalRestartMaxBackoff     int `envconfig:"PLUGIN_LOCAL_RESTART_MAX_BACKOFF"`     // in seconds
+	PluginLocalRestartMaxRestarts    int `envconfig:"PLUGIN_LOCAL_RESTART_MAX_RESTARTS"`    // within the window
+	PluginLocalRestartWindow         int `envconfig:"PLUGIN_LOCAL_RESTART_WINDOW"`          // in seconds
+
+	// ping local plugin instances through stdin, heartbeats are sent by a separate thread of the SDK
+	// and can't tell if the plugin still handles requests
+	PluginLocalLivenessProbeEnabled          bool `envconfig:"PLUGIN_LOCAL_LIVENESS_PROBE_ENABLED" default:"false"`
+	PluginLocalLivenessProbeInterval         int  `envconfig:"PLUGIN_LOCAL_LIVENESS_PROBE_INTERVAL"` // in seconds
+	PluginLocalLivenessProbeTimeout          int  `envconfig:"PLUGIN_LOCAL_LIVENESS_PROBE_TIMEOUT"`  // in seconds
+	PluginLocalLivenessProbeFailureThreshold int  `envconfig:"PLUGIN_LOCAL_LIVENESS_PROBE_FAILURE_THRESHOLD"`
+
+	// on upgrades, the old runtime keeps serving in-flight sessions while new ones go to the new runtime
+	// it's killed once all sessions are finished or the timeout is reached
+	PluginLocalUpgradeDrainTimeout int `envconfig:"PLUGIN_LOCAL_UPGRADE_DRAIN_TIMEOUT"` // in seconds
+
+	// spare instances launched ahead of time for each local plugin, promoted once more instances are needed
+	// they count toward `PLUGIN_LOCAL_AUTOSCALE_MAX_REPLICAS` and cgroup limits like other instances
+	PluginLocalWarmPoolSize int `envconfig:"PLUGIN_LOCAL_WARM_POOL_SIZE"`
+
+	// eager launches all installed local plugins, on_demand launches them on the first dispatch
+	// and stops them once they had no sessions for the idle timeout, pinned plugins are always running
+	PluginLocalLaunchMode            LocalLaunchMode `envconfig:"PLUGIN_LOCAL_LAUNCH_MODE" default:"eager"`
+	PluginLocalIdleTimeout           int             `envconfig:"PLUGIN_LOCAL_IDLE_TIMEOUT"`             // in seconds
+	PluginLocalOnDemandLaunchTimeout int             `envconfig:"PLUGIN_LOCAL_ON_DEMAND_LAUNCH_TIMEOUT"` // in seconds
+	PluginLocalPinnedPlugins         []string        `envconfig:"PLUGIN_LOCAL_PINNED_PLUGINS"`
+
+	// remove working paths, stderr logs and cached packages of plugins which are no longer installed
+	// orphans are kept for the grace period, unless disk usage of the working path exceeds the high-water mark
+	PluginDiskGCEnabled       bool `envconfig:"PLUGIN_DISK_GC_ENABLED" default:"true"`
+	PluginDiskGCInterval      int  `envconfig:"PLUGIN_DISK_GC_INTERVAL"`                     // in seconds
+	PluginDiskGCGracePeriod   int  `envconfig:"PLUGIN_DISK_GC_GRACE_PERIOD"`                 // in seconds
+	PluginDiskGCHighWaterMark int  `envconfig:"PLUGIN_DISK_GC_HIGH_WATER_MARK" default:"85"` // in percent, 0 to disable
+
+	// logs sent by local plugin instances, the latest entries of each plugin are kept in memory
+	// and optionally persisted to rotating files
+	PluginLogBufferSize         int    `envconfig:"PLUGIN_LOG_BUFFER_SIZE"` // entries per plugin
+	PluginLogPersistenceEnabled bool   `envconfig:"PLUGIN_LOG_PERSISTENCE_ENABLED" default:"false"`
+	PluginLogPath               string `envconfig:"PLUGIN_LOG_PATH"`
+	PluginLogMaxFileSize        int    `envconfig:"PLUGIN_LOG_MAX_FILE_SIZE"` // in megabytes
+	PluginLogMaxBackups         int    `envconfig:"PLUGIN_LOG_MAX_BACKUPS"`
+	PluginLogTailTimeout        int    `envconfig:"PLUGIN_LOG_TAIL_TIMEOUT"` // in seconds
+
+	// stderr of local plugin instances is written to size-rotated files, one per instance
+	// `<working path>/.logs` is used if the path is empty
+	PluginStderrLogEnabled      bool   `envconfig:"PLUGIN_STDERR_LOG_ENABLED" default:"true"`
+	PluginStderrLogPath         string `envconfig:"PLUGIN_STDERR_LOG_PATH"`
+	PluginStderrLogMaxFileSize  int    `envconfig:"PLUGIN_STDERR_LOG_MAX_FILE_SIZE"` // in megabytes
+	PluginStderrLogMaxBackups   int    `envconfig:"PLUGIN_STDERR_LOG_MAX_BACKUPS"`   // per instance
+	PluginStderrLogMaxInstances int    `envconfig:"PLUGIN_STDERR_LOG_MAX_INSTANCES"` // per plugin
+	PluginStderrTailSize        int    `envconfig:"PLUGIN_STDERR_TAIL_SIZE"`         // in bytes, kept in memory for error reports
+
+	// cgroup v2 resource limits of local plugin instances, linux only
+	// memory limit is taken from the plugin declaration and clamped by min and max
+	PluginLocalCgroupEnabled       bool   `envconfig:"PLUGIN_LOCAL_CGROUP_ENABLED" default:"false"`
+	PluginLocalCgroupRoot          string `envconfig:"PLUGIN_LOCAL_CGROUP_ROOT"`
+	PluginLocalCgroupMemoryMin     int64  `envconfig:"PLUGIN_LOCAL_CGROUP_MEMORY_MIN"` // in bytes
+	PluginLocalCgroupMemoryMax     int64  `envconfig:"PLUGIN_LOCAL_CGROUP_MEMORY_MAX"` // in bytes
+	PluginLocalCgroupCPUMillicores int64  `envconfig:"PLUGIN_LOCAL_CGROUP_CPU_MILLICORES"`
+	PluginLocalCgroupPidsMax       int64  `envconfig:"PLUGIN_LOCAL_CGROUP_PIDS_MAX"`
+
+	// sandbox local plugin instances using bubblewrap, linux only
+	// available profiles: none, standard, strict
+	PluginSandboxEnabled           bool              `envconfig:"PLUGIN_SANDBOX_ENABLED" default:"false"`
+	PluginSandboxBwrapPath         string            `envconfig:"PLUGIN_SANDBOX_BWRAP_PATH"`
+	PluginSandboxVerifiedProfile   string            `envconfig:"PLUGIN_SANDBOX_VERIFIED_PROFILE" validate:"omitempty,oneof=none standard strict"`
+	PluginSandboxUnverifiedProfile string            `envconfig:"PLUGIN_SANDBOX_UNVERIFIED_PROFILE" validate:"omitempty,oneof=none standard strict"`
+	PluginSandboxSourceProfiles    map[string]string `envconfig:"PLUGIN_SANDBOX_SOURCE_PROFILES"` // format: `source:profile,source:profile`
+
+	// names of daemon environment variables inherited by local plugin instances besides the base allowlist
+	PluginEnvAllowlist []string `envconfig:"PLUGIN_ENV_ALLOWLIST"`
+
 	// add a global reference to plugins to prevent them from being garbage collected
 	// not allowed for local mode
 	PluginAllowOrphans bool `envconfig:"PLUGIN_ALLOW_ORPHANS" default:"false"`
@@ -179,6 +274,30 @@ type Config struct {
 	PipVerbose                bool   `envconfig:"PIP_VERBOSE" default:"true"`
 	PipExtraArgs              string `envconfig:"PIP_EXTRA_ARGS"`
 
+	// share python environments across plugins with the same dependencies
+	PythonEnvCacheEnabled       bool   `envconfig:"PYTHON_ENV_CACHE_ENABLED"`
+	PythonEnvCachePath          string `envconfig:"PYTHON_ENV_CACHE_PATH"`
+	PythonEnvCacheGCGracePeriod int    `envconfig:"PYTHON_ENV_CACHE_GC_GRACE_PERIOD"`
+
+	// interpreters of python versions requested by `runner.version` of plugins, provisioned by uv
+	// interpreters in the cache or on the host are preferred, missing ones are downloaded if enabled
+	PythonInterpreterCachePath       string `envconfig:"PYTHON_INTERPRETER_CACHE_PATH"`
+	PythonInterpreterDownloadEnabled bool   `envconfig:"PYTHON_INTERPRETER_DOWNLOAD_ENABLED" default:"true"`
+
+	// patches of the python plugin sdk, declared by manifests, builtin ones are always loaded
+	// patches in the directory override builtin ones with the same name
+	PluginSdkPatchesPath string `envconfig:"PLUGIN_SDK_PATCHES_PATH"`
+	PluginSdkPatchDryRun bool   `envconfig:"PLUGIN_SDK_PATCH_DRY_RUN" default:"false"`
+
+	GoBinaryPath   string `envconfig:"GO_BINARY_PATH"`
+	GoBuildTimeout int    `envconfig:"GO_BUILD_TIMEOUT" validate:"required"`
+	GoProxy        string `envconfig:"GOPROXY"`
+
+	NodeBinaryPath     string `envconfig:"NODE_BINARY_PATH"`
+	NpmBinaryPath      string `envconfig:"NPM_BINARY_PATH"`
+	NpmRegistry        string `envconfig:"NPM_REGISTRY"`
+	NodeEnvInitTimeout int    `envconfig:"NODE_ENV_INIT_TIMEOUT" validate:"required"`
+
 	// Runtime buffer configuration (applies to both local and serverless runtimes)
 	// These are the new generic names that should be used going forward
 	PluginRuntimeBufferSize    int `envconfig:"PLUGIN_RUNTIME_BUFFER_SIZE" default:"1024"`
@@ -189,6 +308,23 @@ type Config struct {
 	PluginStdioBufferSize    int `envconfig:"PLUGIN_STDIO_BUFFER_SIZE" default:"1024"`
 	PluginStdioMaxBufferSize int `envconfig:"PLUGIN_STDIO_MAX_BUFFER_SIZE" default:"5242880"`
 
+	// Length-prefixed framing of plugin messages, negotiated by plugins at startup
+	// json lines limited by the buffer size above are kept as the fallback
+	PluginRuntimeFramingEnabled bool `envconfig:"PLUGIN_RUNTIME_FRAMING_ENABLED" default:"true"`
+	PluginRuntimeMaxFrameSize   int  `envconfig:"PLUGIN_RUNTIME_MAX_FRAME_SIZE" default:"134217728"`
+
+	// lifecycle events of plugins, install tasks and endpoints, delivered to webhook subscriptions
+	// memory keeps events of this node only, redis shares a durable stream across the cluster
+	EventBusType                  EventBusType `envconfig:"EVENT_BUS_TYPE" default:"memory"`
+	EventBusRedisStreamMaxLen     int64        `envconfig:"EVENT_BUS_REDIS_STREAM_MAX_LEN"`
+	EventBusRedisClaimIdleTimeout int          `envconfig:"EVENT_BUS_REDIS_CLAIM_IDLE_TIMEOUT"`
+	EventBusMemoryBufferSize      int          `envconfig:"EVENT_BUS_MEMORY_BUFFER_SIZE"`
+	EventWebhookTimeout           int          `envconfig:"EVENT_WEBHOOK_TIMEOUT"`
+	EventWebhookMaxAttempts       int          `envconfig:"EVENT_WEBHOOK_MAX_ATTEMPTS"`
+	EventWebhookInitialBackoff    int          `envconfig:"EVENT_WEBHOOK_INITIAL_BACKOFF"`
+	EventWebhookMaxBackoff        int          `envconfig:"EVENT_WEBHOOK_MAX_BACKOFF"`
+	EventWebhookConcurrency       int          `envconfig:"EVENT_WEBHOOK_CONCURRENCY"`
+
 	DisplayClusterLog bool `envconfig:"DISPLAY_CLUSTER_LOG"`
 
 	PPROFEnabled bool `envconfig:"PPROF_ENABLED"`
@@ -234,6 +370,13 @@ func (c *Config) Validate() error {
 		if c.PluginRemoteInstallServerEventLoopNums == 0 {
 			return fmt.Errorf("plugin remote install server event loop nums is empty")
 		}
+		if c.PluginRemoteInstallingTLSEnabled {
+			if c.PluginRemoteInstallingTLSCertFile == "" || c.PluginRemoteInstallingTLSKeyFile == "" {
+				return fmt.Errorf("plugin remote installing tls cert file and key file are required when tls is enabled")
+			}
+		} else if c.PluginRemoteInstallingTLSClientCAFile != "" {
+			return fmt.Errorf("plugin remote installing tls client ca file requires tls to be enabled")
+		}
 	}
 
 	if c.Platform == PLATFORM_SERVERLESS {
@@ -256,6 +399,23 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid platform")
 	}
 
+	if c.PluginLocalAutoscaleEnabled {
+		if c.PluginLocalAutoscaleMinReplicas > c.PluginLocalAutoscaleMaxReplicas {
+			return fmt.Errorf("plugin local autoscale min replicas is greater than max replicas")
+		}
+	}
+
+	if c.EventBusType != EVENT_BUS_TYPE_NONE &&
+		c.EventBusType != EVENT_BUS_TYPE_MEMORY &&
+		c.EventBusType != EVENT_BUS_TYPE_REDIS {
+		return fmt.Errorf("invalid event bus type")
+	}
+
+	if c.PluginLocalLaunchMode != LOCAL_LAUNCH_MODE_EAGER &&
+		c.PluginLocalLaunchMode != LOCAL_LAUNCH_MODE_ON_DEMAND {
+		return fmt.Errorf("invalid plugin local launch mode")
+	}
+
 	if c.PluginPackageCachePath == "" {
 		return fmt.Errorf("plugin package cache path is empty")
 	}
@@ -279,6 +439,21 @@ func (c *Config) GetLocalRuntimeMaxBufferSize() int {
 	return c.PluginRuntimeMaxBufferSize
 }
 
+type LocalLaunchMode string
+
+const (
+	LOCAL_LAUNCH_MODE_EAGER     LocalLaunchMode = "eager"
+	LOCAL_LAUNCH_MODE_ON_DEMAND LocalLaunchMode = "on_demand"
+)
+
+type EventBusType string
+
+const (
+	EVENT_BUS_TYPE_NONE   EventBusType = "none"
+	EVENT_BUS_TYPE_MEMORY EventBusType = "memory"
+	EVENT_BUS_TYPE_REDIS  EventBusType = "redis"
+)
+
 type PlatformType string
 
 const (
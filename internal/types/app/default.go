@@ -28,12 +28,66 @@ func (config *Config) SetDefault() {
 	setDefaultString(&config.PluginMediaCachePath, "assets")
 	setDefaultString(&config.PersistenceStoragePath, "persistence")
 	setDefaultInt(&config.PluginLocalLaunchingConcurrent, 2)
+	setDefaultInt(&config.PluginLocalAutoscaleMinReplicas, 1)
+	setDefaultInt(&config.PluginLocalAutoscaleMaxReplicas, 4)
+	setDefaultInt(&config.PluginLocalAutoscaleTargetInFlight, 8)
+	setDefaultInt(&config.PluginLocalAutoscaleScaleUpCooldown, 30)
+	setDefaultInt(&config.PluginLocalAutoscaleScaleDownCooldown, 300)
+	setDefaultInt(&config.PluginLocalRestartInitialBackoff, 5)
+	setDefaultInt(&config.PluginLocalRestartMaxBackoff, 300)
+	setDefaultInt(&config.PluginLocalRestartMaxRestarts, 10)
+	setDefaultInt(&config.PluginLocalRestartWindow, 600)
+	setDefaultInt(&config.PluginLocalLivenessProbeInterval, 30)
+	setDefaultInt(&config.PluginLocalLivenessProbeTimeout, 10)
+	setDefaultInt(&config.PluginLocalLivenessProbeFailureThreshold, 3)
+	setDefaultInt(&config.PluginLocalUpgradeDrainTimeout, 600)
+	setDefaultInt(&config.PluginLocalIdleTimeout, 900)
+	setDefaultInt(&config.PluginLocalOnDemandLaunchTimeout, 300)
+	setDefaultInt(&config.PluginDiskGCInterval, 600)
+	setDefaultInt(&config.PluginDiskGCGracePeriod, 86400)
+	setDefaultInt(&config.PluginLogBufferSize, 1000)
+	setDefaultString(&config.PluginLogPath, "plugin_logs")
+	setDefaultInt(&config.PluginLogMaxFileSize, 10)
+	setDefaultInt(&config.PluginLogMaxBackups, 5)
+	setDefaultInt(&config.PluginLogTailTimeout, 1800)
+	setDefaultInt(&config.PluginStderrLogMaxFileSize, 10)
+	setDefaultInt(&config.PluginStderrLogMaxBackups, 2)
+	setDefaultInt(&config.PluginStderrLogMaxInstances, 5)
+	setDefaultInt(&config.PluginStderrTailSize, 8192)
+	setDefaultString(&config.PluginLocalCgroupRoot, "/sys/fs/cgroup/dify-plugin-daemon")
+	setDefaultInt(&config.PluginLocalCgroupPidsMax, 512)
+	setDefaultString(&config.PluginSandboxBwrapPath, "bwrap")
+	setDefaultString(&config.PluginSandboxVerifiedProfile, "standard")
+	setDefaultString(&config.PluginSandboxUnverifiedProfile, "strict")
+	setDefaultInt(&config.PluginRuntimeMaxFrameSize, 128*1024*1024)
+	setDefaultInt(&config.EventBusRedisStreamMaxLen, 100000)
+	setDefaultInt(&config.EventBusRedisClaimIdleTimeout, 600)
+	setDefaultInt(&config.EventBusMemoryBufferSize, 1024)
+	setDefaultInt(&config.EventWebhookTimeout, 10)
+	setDefaultInt(&config.EventWebhookMaxAttempts, 8)
+	setDefaultInt(&config.EventWebhookInitialBackoff, 2)
+	setDefaultInt(&config.EventWebhookMaxBackoff, 60)
+	setDefaultInt(&config.EventWebhookConcurrency, 16)
 	setDefaultInt(&config.PersistenceStorageMaxSize, 100*1024*1024)
 	setDefaultString(&config.PluginPackageCachePath, "plugin_packages")
 	setDefaultString(&config.PythonInterpreterPath, "/usr/bin/python3")
 	setDefaultInt(&config.PythonEnvInitTimeout, 120)
+	setDefaultString(&config.PythonEnvCachePath, "python_envs")
+	setDefaultInt(&config.PythonEnvCacheGCGracePeriod, 600)
+	setDefaultString(&config.PythonInterpreterCachePath, "python_interpreters")
+	setDefaultString(&config.GoBinaryPath, "go")
+	setDefaultInt(&config.GoBuildTimeout, 600)
+	setDefaultString(&config.NodeBinaryPath, "node")
+	setDefaultString(&config.NpmBinaryPath, "npm")
+	setDefaultInt(&config.NodeEnvInitTimeout, 600)
 	setDefaultInt(&config.DifyInvocationWriteTimeout, 5000)
 	setDefaultInt(&config.DifyInvocationReadTimeout, 240000)
+	if config.PluginLocalLaunchMode == "" {
+		config.PluginLocalLaunchMode = LOCAL_LAUNCH_MODE_EAGER
+	}
+	if config.EventBusType == "" {
+		config.EventBusType = EVENT_BUS_TYPE_MEMORY
+	}
 	if config.DBType == DB_TYPE_POSTGRESQL {
 		setDefaultString(&config.DBDefaultDatabase, "postgres")
 	} else if config.DBType == DB_TYPE_MYSQL {
@@ -0,0 +1,13 @@
+package models
+
+// EventSubscription is an outbound webhook receiving lifecycle events
+// EventTypes matches events by exact type or by a prefix ending with `*`, e.g. `plugin.local_runtime.*`,
+// an empty list matches all events
+type EventSubscription struct {
+	Model
+	URL         string   `json:"url" gorm:"size:1023"`
+	Secret      string   `json:"-" gorm:"size:127"`
+	EventTypes  []string `json:"event_types" gorm:"serializer:json;type:text"`
+	Enabled     bool     `json:"enabled"`
+	Description string   `json:"description" gorm:"size:255"`
+}
@@ -13,3 +13,13 @@ type PluginInstallation struct {
 	Source                 string         `json:"source" gorm:"column:source;size:63"`
 	Meta                   map[string]any `json:"meta" gorm:"column:meta;serializer:json"`
 }
+
+// PluginEnvironment holds environment variables defined by operators for local plugin instances
+// variables with an empty PluginUniqueIdentifier apply to all versions of the plugin,
+// otherwise they only apply to the installed package identified by PluginUniqueIdentifier
+type PluginEnvironment struct {
+	Model
+	PluginID               string            `json:"plugin_id" gorm:"index;size:255"`
+	PluginUniqueIdentifier string            `json:"plugin_unique_identifier" gorm:"index;size:255"`
+	Variables              map[string]string `json:"variables" gorm:"column:variables;serializer:json;type:text"`
+}
@@ -0,0 +1,52 @@
+package models
+
+type PluginRuntimeAction string
+
+const (
+	PLUGIN_RUNTIME_ACTION_RESTART    PluginRuntimeAction = "restart"
+	PLUGIN_RUNTIME_ACTION_STOP       PluginRuntimeAction = "stop"
+	PLUGIN_RUNTIME_ACTION_START      PluginRuntimeAction = "start"
+	PLUGIN_RUNTIME_ACTION_SCALE      PluginRuntimeAction = "scale"
+	PLUGIN_RUNTIME_ACTION_QUARANTINE PluginRuntimeAction = "quarantine"
+	PLUGIN_RUNTIME_ACTION_RELEASE    PluginRuntimeAction = "release"
+)
+
+type PluginRuntimeAuditStatus string
+
+const (
+	PluginRuntimeAuditStatusRunning   PluginRuntimeAuditStatus = "running"
+	PluginRuntimeAuditStatusSucceeded PluginRuntimeAuditStatus = "succeeded"
+	PluginRuntimeAuditStatusFailed    PluginRuntimeAuditStatus = "failed"
+)
+
+// PluginRuntimeAudit records an operation applied to a plugin runtime by operators
+// an operation applied to the whole cluster has a record with an empty NodeID,
+// and a record for each node it's applied to, all of them share the same OperationID
+type PluginRuntimeAudit struct {
+	Model
+	OperationID            string                   `json:"operation_id" gorm:"index;size:36"`
+	Action                 PluginRuntimeAction      `json:"action" gorm:"size:31"`
+	PluginUniqueIdentifier string                   `json:"plugin_unique_identifier" gorm:"index;size:255"`
+	Scope                  string                   `json:"scope" gorm:"size:15"`
+	NodeID                 string                   `json:"node_id" gorm:"size:127"`
+	Replicas               int32                    `json:"replicas"`
+	Reason                 string                   `json:"reason" gorm:"type:text"`
+	Operator               string                   `json:"operator" gorm:"size:255"`
+	Status                 PluginRuntimeAuditStatus `json:"status" gorm:"size:15"`
+	Error                  string                   `json:"error" gorm:"type:text"`
+}
+
+// PluginQuarantine prevents a local plugin from being launched on all nodes until it's released
+type PluginQuarantine struct {
+	Model
+	PluginUniqueIdentifier string `json:"plugin_unique_identifier" gorm:"size:255;unique"`
+	Reason                 string `json:"reason" gorm:"type:text"`
+	Operator               string `json:"operator" gorm:"size:255"`
+}
+
+// PluginPin keeps all versions of a local plugin running on all nodes when plugins are launched on demand
+type PluginPin struct {
+	Model
+	PluginID string `json:"plugin_id" gorm:"size:255;unique"`
+	Operator string `json:"operator" gorm:"size:255"`
+}
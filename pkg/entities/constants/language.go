@@ -9,13 +9,14 @@ type Language string
 
 const (
 	Python Language = "python"
-	Go     Language = "go" // not supported yet
+	Go     Language = "go"
+	NodeJS Language = "nodejs"
 )
 
 func isAvailableLanguage(fl validator.FieldLevel) bool {
 	value := fl.Field().String()
 	switch value {
-	case string(Python):
+	case string(Python), string(Go), string(NodeJS):
 		return true
 	}
 	return false
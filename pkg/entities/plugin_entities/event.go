@@ -2,6 +2,8 @@ package plugin_entities
 
 import (
 	"encoding/json"
+	"math"
+	"time"
 
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/log"
 	"github.com/langgenius/dify-plugin-daemon/pkg/utils/parser"
@@ -22,7 +24,7 @@ func ParsePluginUniversalEvent(
 	sessionHandler func(sessionId string, data []byte),
 	heartbeatHandler func(),
 	errorHandler func(err string),
-	infoHandler func(message string),
+	logHandler func(sessionId string, event PluginLogEvent),
 ) {
 	// handle event
 	event, err := parser.UnmarshalJsonBytes[PluginUniversalEvent](data)
@@ -48,7 +50,7 @@ func ParsePluginUniversalEvent(
 				return
 			}
 
-			infoHandler(logEvent.Message)
+			logHandler(sessionId, logEvent)
 		}
 	case PLUGIN_EVENT_SESSION:
 		sessionHandler(sessionId, event.Data)
@@ -74,6 +76,16 @@ type PluginLogEvent struct {
 	Timestamp float64 `json:"timestamp"`
 }
 
+// Time returns when the log was emitted, `Timestamp` is seconds since epoch
+// falls back to `now` if the plugin didn't set it
+func (e *PluginLogEvent) Time(now time.Time) time.Time {
+	if e.Timestamp <= 0 {
+		return now
+	}
+	seconds, fraction := math.Modf(e.Timestamp)
+	return time.Unix(int64(seconds), int64(fraction*float64(time.Second)))
+}
+
 type SessionMessage struct {
 	Type SESSION_MESSAGE_TYPE `json:"type" validate:"required"`
 	Data json.RawMessage      `json:"data" validate:"required"`
@@ -135,6 +135,10 @@ func (r *PluginRuntime) SetRestarting() {
 	r.State.Status = PLUGIN_RUNTIME_STATUS_RESTARTING
 }
 
+func (r *PluginRuntime) SetCrashLooping() {
+	r.State.Status = PLUGIN_RUNTIME_STATUS_CRASH_LOOPING
+}
+
 func (r *PluginRuntime) SetPending() {
 	r.State.Status = PLUGIN_RUNTIME_STATUS_PENDING
 }
@@ -164,8 +168,27 @@ type PluginRuntimeState struct {
 	Verified    bool       `json:"verified"`
 	ScheduledAt *time.Time `json:"scheduled_at"`
 	Logs        []string   `json:"logs"`
+	// patches applied to the plugin sdk in the working path
+	Patches []PluginSdkPatchRecord `json:"patches"`
+}
+
+type PluginSdkPatchRecord struct {
+	Name       string    `json:"name"`
+	Target     string    `json:"target"`
+	Reason     string    `json:"reason"`
+	SdkVersion string    `json:"sdk_version"`
+	Status     string    `json:"status"`
+	PatchedAt  time.Time `json:"patched_at"`
 }
 
+const (
+	PLUGIN_SDK_PATCH_STATUS_APPLIED = "applied"
+	// the target is identical to the patch, nothing changed
+	PLUGIN_SDK_PATCH_STATUS_UP_TO_DATE = "up_to_date"
+	// under dry-run mode, the patch would be applied
+	PLUGIN_SDK_PATCH_STATUS_DRY_RUN = "dry_run"
+)
+
 func (s *PluginRuntimeState) Hash() (uint64, error) {
 	buf := bytes.Buffer{}
 	enc := gob.NewEncoder(&buf)
@@ -188,4 +211,6 @@ const (
 	PLUGIN_RUNTIME_STATUS_STOPPED    = "stopped"
 	PLUGIN_RUNTIME_STATUS_RESTARTING = "restarting"
 	PLUGIN_RUNTIME_STATUS_PENDING    = "pending"
+	// failed too many times in a short period, no more instances are started
+	PLUGIN_RUNTIME_STATUS_CRASH_LOOPING = "crash_looping"
 )
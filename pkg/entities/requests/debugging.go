@@ -3,3 +3,24 @@ package requests
 type RequestGetRemoteDebuggingKey struct {
 	TenantID string `uri:"tenant_id" validate:"required"`
 }
+
+type RequestCreateRemoteDebuggingKey struct {
+	TenantID string `uri:"tenant_id" validate:"required"`
+	UserID   string `json:"user_id" validate:"required,max=255"`
+	Label    string `json:"label" validate:"max=255"`
+	// in seconds, 2 hours by default
+	ExpiresIn int `json:"expires_in" validate:"omitempty,min=60,max=2592000"`
+}
+
+type RequestListRemoteDebuggingKeys struct {
+	TenantID string `uri:"tenant_id" validate:"required"`
+	// only keys created by the user are listed if set
+	UserID string `form:"user_id" validate:"max=255"`
+}
+
+type RequestRevokeRemoteDebuggingKey struct {
+	TenantID string `uri:"tenant_id" validate:"required"`
+	KeyID    string `uri:"key_id" validate:"required"`
+	// the key must be created by the user if set
+	UserID string `json:"user_id" validate:"max=255"`
+}
@@ -15,6 +15,9 @@ import (
 type Packager struct {
 	decoder  decoder.PluginDecoder
 	manifest string // manifest file path
+
+	// vendors wheels into the package if set
+	wheelhouse *WheelhouseOptions
 }
 
 func NewPackager(decoder decoder.PluginDecoder) *Packager {
@@ -30,6 +33,14 @@ func (p *Packager) Pack(maxSize int64) ([]byte, error) {
 		return nil, err
 	}
 
+	wheels := map[string][]byte{}
+	if p.wheelhouse != nil {
+		wheels, err = p.buildWheelhouse()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	zipBuffer := new(bytes.Buffer)
 	zipWriter := zip.NewWriter(zipBuffer)
 
@@ -37,12 +48,7 @@ func (p *Packager) Pack(maxSize int64) ([]byte, error) {
 
 	var files []FileInfoWithPath
 
-	err = p.decoder.Walk(func(filename, dir string) error {
-		fullPath := filepath.Join(dir, filename)
-		file, err := p.decoder.ReadFile(fullPath)
-		if err != nil {
-			return err
-		}
+	writeFile := func(fullPath string, file []byte) error {
 		fileSize := int64(len(file))
 		files = append(files, FileInfoWithPath{Path: fullPath, Size: fileSize})
 		totalSize += fileSize
@@ -78,12 +84,40 @@ func (p *Packager) Pack(maxSize int64) ([]byte, error) {
 		}
 
 		return nil
+	}
+
+	err = p.decoder.Walk(func(filename, dir string) error {
+		fullPath := filepath.Join(dir, filename)
+
+		// the vendored wheelhouse replaces the one in the plugin directory
+		if p.wheelhouse != nil && strings.HasPrefix(filepath.ToSlash(fullPath), WheelhouseDir+"/") {
+			return nil
+		}
+
+		file, err := p.decoder.ReadFile(fullPath)
+		if err != nil {
+			return err
+		}
+
+		return writeFile(fullPath, file)
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
+	// keep the order stable, it does not affect the checksum but makes packages reproducible
+	wheelPaths := make([]string, 0, len(wheels))
+	for wheelPath := range wheels {
+		wheelPaths = append(wheelPaths, wheelPath)
+	}
+	sort.Strings(wheelPaths)
+	for _, wheelPath := range wheelPaths {
+		if err := writeFile(wheelPath, wheels[wheelPath]); err != nil {
+			return nil, err
+		}
+	}
+
 	err = zipWriter.Close()
 	if err != nil {
 		return nil, err
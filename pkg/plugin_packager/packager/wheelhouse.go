@@ -0,0 +1,164 @@
+package packager
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/langgenius/dify-plugin-daemon/pkg/entities/constants"
+)
+
+const (
+	// wheels vendored into the package, laid out as `wheelhouse/<python version>/<arch>/*.whl`
+	// the local runtime installs from it without accessing any index if the python version matches
+	WheelhouseDir = "wheelhouse"
+
+	defaultWheelhousePythonVersion = "3.12"
+)
+
+// platform tags of wheels which are installable on each arch, plugins run on glibc based linux
+var wheelhousePlatforms = map[constants.Arch][]string{
+	constants.AMD64: {
+		"manylinux2014_x86_64",
+		"manylinux_2_17_x86_64",
+		"manylinux_2_28_x86_64",
+		"linux_x86_64",
+	},
+	constants.ARM64: {
+		"manylinux2014_aarch64",
+		"manylinux_2_17_aarch64",
+		"manylinux_2_28_aarch64",
+		"linux_aarch64",
+	},
+}
+
+type WheelhouseOptions struct {
+	// python interpreter with pip installed, `python3` by default
+	PythonPath string
+	// python version of the plugin runtime, `runner.version` of the manifest by default
+	PythonVersion string
+	// index to download wheels from, pip's default if empty
+	IndexUrl string
+	// extra arguments passed to `pip download`
+	ExtraArgs []string
+}
+
+// WithWheelhouse vendors wheels of the requirements for all declared archs into the package
+func (p *Packager) WithWheelhouse(options WheelhouseOptions) *Packager {
+	if options.PythonPath == "" {
+		options.PythonPath = "python3"
+	}
+	p.wheelhouse = &options
+	return p
+}
+
+// WheelhousePath returns the path of wheels built for the python version and the arch in the package
+// wheels are tagged by major.minor, patch versions share the same wheelhouse
+func WheelhousePath(pythonVersion string, arch string) string {
+	parts := strings.SplitN(strings.TrimSpace(pythonVersion), ".", 3)
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return path.Join(WheelhouseDir, strings.Join(parts, "."), arch)
+}
+
+// buildWheelhouse downloads wheels into a temporary directory
+// returns files to be packaged, keyed by their path in the package
+func (p *Packager) buildWheelhouse() (map[string][]byte, error) {
+	manifest, err := p.fetchManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Meta.Runner.Language != constants.Python {
+		return nil, fmt.Errorf("wheelhouse is only supported by python plugins")
+	}
+
+	requirements, err := p.decoder.ReadFile("requirements.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read requirements.txt: %w", err)
+	}
+
+	pythonVersion := p.wheelhouse.PythonVersion
+	if pythonVersion == "" {
+		pythonVersion = strings.TrimSpace(manifest.Meta.Runner.Version)
+	}
+	if pythonVersion == "" {
+		pythonVersion = defaultWheelhousePythonVersion
+	}
+
+	tempDir, err := os.MkdirTemp("", "dify-plugin-wheelhouse-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	requirementsPath := filepath.Join(tempDir, "requirements.txt")
+	if err := os.WriteFile(requirementsPath, requirements, 0644); err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	for _, arch := range manifest.Meta.Arch {
+		platforms, ok := wheelhousePlatforms[arch]
+		if !ok {
+			return nil, fmt.Errorf("unsupported arch: %s", arch)
+		}
+
+		dest := filepath.Join(tempDir, string(arch))
+		if err := p.downloadWheels(requirementsPath, dest, pythonVersion, platforms); err != nil {
+			return nil, fmt.Errorf("failed to download wheels for %s: %w", arch, err)
+		}
+
+		entries, err := os.ReadDir(dest)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".whl") {
+				continue
+			}
+			wheel, err := os.ReadFile(filepath.Join(dest, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			files[path.Join(WheelhousePath(pythonVersion, string(arch)), entry.Name())] = wheel
+		}
+	}
+
+	return files, nil
+}
+
+func (p *Packager) downloadWheels(requirementsPath string, dest string, pythonVersion string, platforms []string) error {
+	args := []string{
+		"-m", "pip", "download",
+		"--disable-pip-version-check",
+		"-r", requirementsPath,
+		"--dest", dest,
+		// the target platform differs from the current one, only wheels are usable
+		"--only-binary=:all:",
+		"--implementation", "cp",
+		"--python-version", pythonVersion,
+	}
+	for _, platform := range platforms {
+		args = append(args, "--platform", platform)
+	}
+	if p.wheelhouse.IndexUrl != "" {
+		args = append(args, "--index-url", p.wheelhouse.IndexUrl)
+	}
+	args = append(args, p.wheelhouse.ExtraArgs...)
+
+	cmd := exec.Command(p.wheelhouse.PythonPath, args...)
+	output := bytes.NewBuffer(nil)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w, output: %s", err, output.String())
+	}
+
+	return nil
+}
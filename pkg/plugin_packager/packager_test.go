@@ -1,10 +1,14 @@
 package plugin_packager
 
 import (
+	"archive/zip"
+	"bytes"
 	"crypto/rsa"
 	"embed"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -537,3 +541,182 @@ func TestVerifyPluginWithThirdPartyKeys(t *testing.T) {
 		})
 	}
 }
+
+// buildTestWheel builds a pure python wheel which is installable on any platform
+func buildTestWheel(t *testing.T, dir string) {
+	buffer := new(bytes.Buffer)
+	writer := zip.NewWriter(buffer)
+	for name, content := range map[string]string{
+		"neko_wheel/__init__.py":              "",
+		"neko_wheel-1.0.0.dist-info/METADATA": "Metadata-Version: 2.1\nName: neko-wheel\nVersion: 1.0.0\n",
+		"neko_wheel-1.0.0.dist-info/WHEEL":    "Wheel-Version: 1.0\nGenerator: test\nRoot-Is-Purelib: true\nTag: py3-none-any\n",
+		"neko_wheel-1.0.0.dist-info/RECORD":   "",
+	} {
+		file, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create wheel: %s", err.Error())
+		}
+		file.Write([]byte(content))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to create wheel: %s", err.Error())
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "neko_wheel-1.0.0-py3-none-any.whl"), buffer.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write wheel: %s", err.Error())
+	}
+}
+
+// newWheelhousePlugin writes a plugin requiring the test wheel, returns the plugin directory and the wheel directory
+func newWheelhousePlugin(t *testing.T, manifest []byte) (string, string) {
+	tempDir := t.TempDir()
+	for name, content := range map[string][]byte{
+		"manifest.yaml":    manifest,
+		"neko.yaml":        neko,
+		"_assets/test.svg": test_svg,
+		"requirements.txt": []byte("neko-wheel==1.0.0\n"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(tempDir, name)), 0755); err != nil {
+			t.Fatalf("failed to create directory: %s", err.Error())
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, name), content, 0644); err != nil {
+			t.Fatalf("failed to write %s: %s", name, err.Error())
+		}
+	}
+
+	// serve wheels from a local directory instead of an index
+	findLinks := t.TempDir()
+	buildTestWheel(t, findLinks)
+
+	return tempDir, findLinks
+}
+
+func TestPackWithWheelhouse(t *testing.T) {
+	if err := exec.Command("python3", "-m", "pip", "--version").Run(); err != nil {
+		t.Skip("pip is not available")
+	}
+
+	tempDir, findLinks := newWheelhousePlugin(t, manifest)
+
+	originDecoder, err := decoder.NewFSPluginDecoder(tempDir)
+	if err != nil {
+		t.Fatalf("failed to create decoder: %s", err.Error())
+	}
+
+	zipFile, err := packager.NewPackager(originDecoder).WithWheelhouse(packager.WheelhouseOptions{
+		ExtraArgs: []string{"--no-index", "--find-links", findLinks},
+	}).Pack(52428800)
+	if err != nil {
+		t.Fatalf("failed to pack: %s", err.Error())
+	}
+
+	privateKey := loadPrivateKeyFile(t, "test_key_pair_1.private.pem")
+	publicKey := loadPublicKeyFile(t, "test_key_pair_1.public.pem")
+
+	signed, err := withkey.SignPluginWithPrivateKey(zipFile, &decoder.Verification{
+		AuthorizedCategory: decoder.AUTHORIZED_CATEGORY_LANGGENIUS,
+	}, privateKey)
+	if err != nil {
+		t.Fatalf("failed to sign: %s", err.Error())
+	}
+
+	signedDecoder, err := decoder.NewZipPluginDecoder(signed)
+	if err != nil {
+		t.Fatalf("failed to create zip decoder: %s", err.Error())
+	}
+
+	// wheels are vendored for every declared arch
+	for _, arch := range []string{"amd64", "arm64"} {
+		if _, err := signedDecoder.ReadFile(fmt.Sprintf("wheelhouse/3.12/%s/neko_wheel-1.0.0-py3-none-any.whl", arch)); err != nil {
+			t.Errorf("wheel for %s is not vendored: %s", arch, err.Error())
+		}
+	}
+
+	if err := decoder.VerifyPluginWithPublicKeys(signedDecoder, []*rsa.PublicKey{publicKey}); err != nil {
+		t.Fatalf("failed to verify: %s", err.Error())
+	}
+
+	// replace the vendored wheel, the signature must not match anymore
+	reader, err := zip.NewReader(bytes.NewReader(signed), int64(len(signed)))
+	if err != nil {
+		t.Fatalf("failed to read package: %s", err.Error())
+	}
+	tampered := new(bytes.Buffer)
+	writer := zip.NewWriter(tampered)
+	for _, file := range reader.File {
+		content := []byte("tampered")
+		if !strings.HasPrefix(file.Name, "wheelhouse/") {
+			rc, err := file.Open()
+			if err != nil {
+				t.Fatalf("failed to read %s: %s", file.Name, err.Error())
+			}
+			content, err = io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				t.Fatalf("failed to read %s: %s", file.Name, err.Error())
+			}
+		}
+		w, err := writer.Create(file.Name)
+		if err != nil {
+			t.Fatalf("failed to write %s: %s", file.Name, err.Error())
+		}
+		w.Write(content)
+	}
+	writer.SetComment(reader.Comment)
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to write package: %s", err.Error())
+	}
+
+	tamperedDecoder, err := decoder.NewZipPluginDecoder(tampered.Bytes())
+	if err != nil {
+		t.Fatalf("failed to create zip decoder: %s", err.Error())
+	}
+	if err := decoder.VerifyPluginWithPublicKeys(tamperedDecoder, []*rsa.PublicKey{publicKey}); err == nil {
+		t.Errorf("should fail to verify a package with tampered wheels")
+	}
+}
+
+func TestPackWithWheelhouseForPythonVersion(t *testing.T) {
+	if err := exec.Command("python3", "-m", "pip", "--version").Run(); err != nil {
+		t.Skip("pip is not available")
+	}
+
+	tempDir, findLinks := newWheelhousePlugin(t, bytes.Replace(manifest, []byte(`version: "3.12"`), []byte(`version: "3.11"`), 1))
+
+	originDecoder, err := decoder.NewFSPluginDecoder(tempDir)
+	if err != nil {
+		t.Fatalf("failed to create decoder: %s", err.Error())
+	}
+
+	zipFile, err := packager.NewPackager(originDecoder).WithWheelhouse(packager.WheelhouseOptions{
+		ExtraArgs: []string{"--no-index", "--find-links", findLinks},
+	}).Pack(52428800)
+	if err != nil {
+		t.Fatalf("failed to pack: %s", err.Error())
+	}
+
+	zipDecoder, err := decoder.NewZipPluginDecoder(zipFile)
+	if err != nil {
+		t.Fatalf("failed to create zip decoder: %s", err.Error())
+	}
+
+	// wheels are built for the python version declared by the manifest
+	if _, err := zipDecoder.ReadFile("wheelhouse/3.11/amd64/neko_wheel-1.0.0-py3-none-any.whl"); err != nil {
+		t.Errorf("wheel for python 3.11 is not vendored: %s", err.Error())
+	}
+	if _, err := zipDecoder.ReadFile("wheelhouse/3.12/amd64/neko_wheel-1.0.0-py3-none-any.whl"); err == nil {
+		t.Errorf("wheel should not be vendored for python 3.12")
+	}
+}
+
+func TestWheelhousePath(t *testing.T) {
+	for version, expected := range map[string]string{
+		"3.11":   "wheelhouse/3.11/amd64",
+		"3.12.4": "wheelhouse/3.12/amd64",
+		" 3.13 ": "wheelhouse/3.13/amd64",
+	} {
+		if actual := packager.WheelhousePath(version, "amd64"); actual != expected {
+			t.Errorf("expected %s for %q, got %s", expected, version, actual)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StreamAdd appends a message to the stream, the stream is trimmed to about `maxLen` messages
+// `maxLen` of 0 means the stream is not trimmed
+func StreamAdd(key string, values map[string]any, maxLen int64, context ...redis.Cmdable) (string, error) {
+	if client == nil {
+		return "", ErrDBNotInit
+	}
+
+	return getCmdable(context...).XAdd(ctx, &redis.XAddArgs{
+		Stream: serialKey(key),
+		MaxLen: maxLen,
+		Approx: true,
+		Values: values,
+	}).Result()
+}
+
+// StreamCreateGroup creates a consumer group reading new messages of the stream
+// the stream is created if it does not exist, an existing group is not an error
+func StreamCreateGroup(key string, group string, context ...redis.Cmdable) error {
+	if client == nil {
+		return ErrDBNotInit
+	}
+
+	err := getCmdable(context...).XGroupCreateMkStream(ctx, serialKey(key), group, "$").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// StreamReadGroup reads new messages of the stream as a consumer of the group
+// it blocks until messages arrive or `block` is reached, no messages is not an error
+func StreamReadGroup(
+	key string,
+	group string,
+	consumer string,
+	count int64,
+	block time.Duration,
+	context ...redis.Cmdable,
+) ([]redis.XMessage, error) {
+	if client == nil {
+		return nil, ErrDBNotInit
+	}
+
+	streams, err := getCmdable(context...).XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{serialKey(key), ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []redis.XMessage{}
+	for _, stream := range streams {
+		messages = append(messages, stream.Messages...)
+	}
+	return messages, nil
+}
+
+// StreamAutoClaim takes over messages of the group which were not acknowledged for `minIdle`
+// e.g. messages read by a consumer which is gone
+func StreamAutoClaim(
+	key string,
+	group string,
+	consumer string,
+	minIdle time.Duration,
+	count int64,
+	context ...redis.Cmdable,
+) ([]redis.XMessage, error) {
+	if client == nil {
+		return nil, ErrDBNotInit
+	}
+
+	messages, _, err := getCmdable(context...).XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   serialKey(key),
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    count,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return messages, err
+}
+
+// StreamAck acknowledges messages of the group, they are not delivered again
+func StreamAck(key string, group string, ids []string, context ...redis.Cmdable) error {
+	if client == nil {
+		return ErrDBNotInit
+	}
+
+	return getCmdable(context...).XAck(ctx, serialKey(key), group, ids...).Err()
+}
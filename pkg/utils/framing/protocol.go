@@ -0,0 +1,167 @@
+package framing
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Protocol is how messages are delimited between the daemon and a plugin
+// every message is a json object on the daemon side, no matter how it's encoded on the wire
+type Protocol string
+
+const (
+	// newline-delimited json, the fallback every SDK speaks
+	PROTOCOL_JSON_LINES Protocol = "json_lines"
+	// 4 bytes big-endian payload length followed by a json payload
+	PROTOCOL_FRAMED_JSON Protocol = "framed_json"
+	// 4 bytes big-endian payload length followed by a msgpack payload
+	PROTOCOL_FRAMED_MSGPACK Protocol = "framed_msgpack"
+)
+
+const (
+	FRAME_HEADER_SIZE = 4
+)
+
+var (
+	ErrFrameTooLarge       = errors.New("frame exceeds the max frame size")
+	ErrUnsupportedProtocol = errors.New("unsupported protocol")
+)
+
+// FramedProtocols returns the framed protocols the daemon accepts, in order of preference
+// they are advertised to plugins, which pick one and negotiate it
+func FramedProtocols() []Protocol {
+	return []Protocol{PROTOCOL_FRAMED_MSGPACK, PROTOCOL_FRAMED_JSON}
+}
+
+// AdvertisedProtocols formats `FramedProtocols` as a comma-separated list
+func AdvertisedProtocols() string {
+	protocols := []string{}
+	for _, protocol := range FramedProtocols() {
+		protocols = append(protocols, string(protocol))
+	}
+	return strings.Join(protocols, ",")
+}
+
+func ParseProtocol(protocol string) (Protocol, error) {
+	switch Protocol(protocol) {
+	case PROTOCOL_JSON_LINES, PROTOCOL_FRAMED_JSON, PROTOCOL_FRAMED_MSGPACK:
+		return Protocol(protocol), nil
+	}
+	return "", errors.Join(ErrUnsupportedProtocol, fmt.Errorf("protocol: %s", protocol))
+}
+
+func (p Protocol) Framed() bool {
+	return p == PROTOCOL_FRAMED_JSON || p == PROTOCOL_FRAMED_MSGPACK
+}
+
+// negotiation is the first message a plugin writes to switch protocol
+// the message itself is always a json line, everything after it uses the requested protocol
+type negotiation struct {
+	Protocol string `json:"protocol"`
+}
+
+// NegotiationMessage returns the json line which asks the daemon to switch to the protocol
+func NegotiationMessage(protocol Protocol) []byte {
+	message, _ := json.Marshal(negotiation{Protocol: string(protocol)})
+	return append(message, '\n')
+}
+
+// parseNegotiation checks if the message is a negotiation message
+// false means it's a regular message, the plugin keeps speaking json lines
+func parseNegotiation(message []byte) (Protocol, bool, error) {
+	var request negotiation
+	if err := json.Unmarshal(message, &request); err != nil || request.Protocol == "" {
+		return "", false, nil
+	}
+
+	protocol, err := ParseProtocol(request.Protocol)
+	if err != nil {
+		return "", true, err
+	}
+	return protocol, true, nil
+}
+
+// Encode encodes a json message into bytes written to the peer
+func (p Protocol) Encode(message []byte) ([]byte, error) {
+	switch p {
+	case PROTOCOL_FRAMED_JSON:
+		return frame(message)
+	case PROTOCOL_FRAMED_MSGPACK:
+		payload, err := jsonToMsgpack(message)
+		if err != nil {
+			return nil, err
+		}
+		return frame(payload)
+	default:
+		return append(message, '\n'), nil
+	}
+}
+
+// decodePayload converts the payload of a frame back into json
+func (p Protocol) decodePayload(payload []byte) ([]byte, error) {
+	if p == PROTOCOL_FRAMED_MSGPACK {
+		return msgpackToJson(payload)
+	}
+	return payload, nil
+}
+
+func frame(payload []byte) ([]byte, error) {
+	if uint64(len(payload)) > math.MaxUint32 {
+		return nil, ErrFrameTooLarge
+	}
+
+	data := make([]byte, FRAME_HEADER_SIZE+len(payload))
+	binary.BigEndian.PutUint32(data, uint32(len(payload)))
+	copy(data[FRAME_HEADER_SIZE:], payload)
+	return data, nil
+}
+
+var (
+	mapType = reflect.TypeOf(map[string]any(nil))
+
+	// integers without fraction or exponent are kept as integers instead of float64
+	jsonHandle = &codec.JsonHandle{
+		BasicHandle: codec.BasicHandle{DecodeOptions: codec.DecodeOptions{MapType: mapType}},
+	}
+	msgpackHandle = &codec.MsgpackHandle{
+		BasicHandle: codec.BasicHandle{DecodeOptions: codec.DecodeOptions{MapType: mapType, RawToString: true}},
+		WriteExt:    true,
+	}
+)
+
+func jsonToMsgpack(message []byte) ([]byte, error) {
+	var value any
+	if err := codec.NewDecoderBytes(message, jsonHandle).Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var payload []byte
+	if err := codec.NewEncoderBytes(&payload, msgpackHandle).Encode(value); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// msgpackToJson converts a msgpack payload into json, binary values become base64 strings
+func msgpackToJson(payload []byte) ([]byte, error) {
+	var value any
+	if err := codec.NewDecoderBytes(payload, msgpackHandle).Decode(&value); err != nil {
+		return nil, err
+	}
+
+	buffer := bytes.Buffer{}
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
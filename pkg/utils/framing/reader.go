@@ -0,0 +1,200 @@
+package framing
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+type Options struct {
+	// initial size of the read buffer
+	BufferSize int
+	// max size of a json line, 0 means unlimited
+	MaxLineSize int
+	// max size of a frame payload, 0 means unlimited
+	MaxFrameSize int
+	// allows the peer to switch to a framed protocol with its first message
+	Negotiable bool
+}
+
+// negotiator holds the protocol in use, it switches at most once on the first non-empty message
+type negotiator struct {
+	options    Options
+	protocol   atomic.Value
+	negotiated bool
+}
+
+// Protocol returns the protocol in use, it's safe to be called concurrently with reads
+func (n *negotiator) Protocol() Protocol {
+	if protocol, ok := n.protocol.Load().(Protocol); ok {
+		return protocol
+	}
+	return PROTOCOL_JSON_LINES
+}
+
+// negotiate returns true if the line is consumed as a negotiation message
+func (n *negotiator) negotiate(line []byte) (bool, error) {
+	if n.negotiated || len(line) == 0 {
+		return false, nil
+	}
+	n.negotiated = true
+
+	if !n.options.Negotiable {
+		return false, nil
+	}
+
+	protocol, ok, err := parseNegotiation(line)
+	if !ok || err != nil {
+		return ok, err
+	}
+
+	n.protocol.Store(protocol)
+	return true, nil
+}
+
+func (n *negotiator) checkFrameSize(size uint32) error {
+	if n.options.MaxFrameSize > 0 && uint64(size) > uint64(n.options.MaxFrameSize) {
+		return errors.Join(ErrFrameTooLarge, fmt.Errorf("frame size %d, max %d", size, n.options.MaxFrameSize))
+	}
+	return nil
+}
+
+// Reader reads messages from a stream, e.g. stdout of a plugin
+// it starts with json lines and switches to the protocol the peer negotiated
+type Reader struct {
+	negotiator
+	reader *bufio.Reader
+}
+
+func NewReader(r io.Reader, options Options) *Reader {
+	return &Reader{
+		negotiator: negotiator{options: options},
+		reader:     bufio.NewReaderSize(r, max(options.BufferSize, 16)),
+	}
+}
+
+// Read returns the next message as json, empty lines are returned as empty messages
+func (r *Reader) Read() ([]byte, error) {
+	for {
+		if r.Protocol().Framed() {
+			return r.readFrame()
+		}
+
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		negotiated, err := r.negotiate(line)
+		if err != nil {
+			return nil, err
+		}
+		if !negotiated {
+			return line, nil
+		}
+	}
+}
+
+// readLine works like `bufio.ScanLines`, the last line without a newline is returned as well
+func (r *Reader) readLine() ([]byte, error) {
+	line := []byte{}
+	for {
+		chunk, err := r.reader.ReadSlice('\n')
+		if r.options.MaxLineSize > 0 && len(line)+len(chunk) > r.options.MaxLineSize {
+			return nil, bufio.ErrTooLong
+		}
+		line = append(line, chunk...)
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, err
+		}
+
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		return bytes.TrimSuffix(line, []byte("\r")), nil
+	}
+}
+
+func (r *Reader) readFrame() ([]byte, error) {
+	header := make([]byte, FRAME_HEADER_SIZE)
+	if _, err := io.ReadFull(r.reader, header); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header)
+	if err := r.checkFrameSize(size); err != nil {
+		return nil, err
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r.reader, payload); err != nil {
+		return nil, err
+	}
+
+	return r.Protocol().decodePayload(payload)
+}
+
+// Decoder decodes messages from chunks pushed to it, e.g. traffic of an event loop
+// it starts with json lines and switches to the protocol the peer negotiated
+type Decoder struct {
+	negotiator
+	buf bytes.Buffer
+}
+
+func NewDecoder(options Options) *Decoder {
+	return &Decoder{negotiator: negotiator{options: options}}
+}
+
+// Feed appends the data and returns all completed messages as json
+// incomplete data is kept until the next call, empty lines are returned as empty messages
+func (d *Decoder) Feed(data []byte) ([][]byte, error) {
+	d.buf.Write(data)
+
+	messages := [][]byte{}
+	for {
+		if d.Protocol().Framed() {
+			if d.buf.Len() < FRAME_HEADER_SIZE {
+				return messages, nil
+			}
+			size := binary.BigEndian.Uint32(d.buf.Bytes())
+			if err := d.checkFrameSize(size); err != nil {
+				return messages, err
+			}
+			if uint64(d.buf.Len()) < FRAME_HEADER_SIZE+uint64(size) {
+				return messages, nil
+			}
+
+			d.buf.Next(FRAME_HEADER_SIZE)
+			payload := bytes.Clone(d.buf.Next(int(size)))
+			message, err := d.Protocol().decodePayload(payload)
+			if err != nil {
+				return messages, err
+			}
+			messages = append(messages, message)
+			continue
+		}
+
+		end := bytes.IndexByte(d.buf.Bytes(), '\n')
+		if end < 0 {
+			if d.options.MaxLineSize > 0 && d.buf.Len() > d.options.MaxLineSize {
+				return messages, bufio.ErrTooLong
+			}
+			return messages, nil
+		}
+
+		line := bytes.Clone(d.buf.Next(end + 1)[:end])
+		negotiated, err := d.negotiate(line)
+		if err != nil {
+			return messages, err
+		}
+		if !negotiated {
+			messages = append(messages, line)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package framing
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReaderJsonLines(t *testing.T) {
+	reader := NewReader(strings.NewReader("{\"event\":\"heartbeat\"}\r\n\n{\"event\":\"log\"}"), Options{
+		BufferSize:  16,
+		MaxLineSize: 64,
+		Negotiable:  true,
+	})
+
+	for _, expected := range []string{`{"event":"heartbeat"}`, ``, `{"event":"log"}`} {
+		message, err := reader.Read()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(message))
+	}
+	assert.Equal(t, PROTOCOL_JSON_LINES, reader.Protocol())
+
+	// lines are still limited
+	reader = NewReader(strings.NewReader(strings.Repeat("a", 65)+"\n"), Options{BufferSize: 16, MaxLineSize: 64})
+	_, err := reader.Read()
+	assert.ErrorIs(t, err, bufio.ErrTooLong)
+}
+
+func TestReaderNegotiation(t *testing.T) {
+	large := `{"data":"` + strings.Repeat("a", 1024) + `","size":9007199254740993}`
+
+	for _, protocol := range FramedProtocols() {
+		stream := bytes.NewBuffer(NegotiationMessage(protocol))
+		for _, message := range []string{`{"event":"heartbeat"}`, large} {
+			frame, err := protocol.Encode([]byte(message))
+			if !assert.NoError(t, err) {
+				return
+			}
+			stream.Write(frame)
+		}
+
+		reader := NewReader(stream, Options{BufferSize: 16, MaxLineSize: 64, MaxFrameSize: 2048, Negotiable: true})
+		message, err := reader.Read()
+		assert.NoError(t, err)
+		assert.Equal(t, `{"event":"heartbeat"}`, string(message))
+		assert.Equal(t, protocol, reader.Protocol())
+
+		// frames are not limited by the line size, integers are kept as they are
+		message, err = reader.Read()
+		assert.NoError(t, err)
+		assert.JSONEq(t, large, string(message))
+		assert.Contains(t, string(message), "9007199254740993")
+	}
+}
+
+func TestReaderRejectsFrames(t *testing.T) {
+	frame, _ := PROTOCOL_FRAMED_JSON.Encode([]byte(`{"data":"` + strings.Repeat("a", 64) + `"}`))
+	stream := append(NegotiationMessage(PROTOCOL_FRAMED_JSON), frame...)
+	reader := NewReader(bytes.NewReader(stream), Options{MaxFrameSize: 64, Negotiable: true})
+	_, err := reader.Read()
+	assert.ErrorIs(t, err, ErrFrameTooLarge)
+
+	reader = NewReader(strings.NewReader(`{"protocol":"framed_xml"}`+"\n"), Options{Negotiable: true})
+	_, err = reader.Read()
+	assert.ErrorIs(t, err, ErrUnsupportedProtocol)
+
+	// a negotiation message is a regular message if negotiation is disabled
+	reader = NewReader(bytes.NewReader(stream), Options{})
+	message, err := reader.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"protocol":"framed_json"}`, string(message))
+	assert.Equal(t, PROTOCOL_JSON_LINES, reader.Protocol())
+}
+
+func TestDecoderFeed(t *testing.T) {
+	decoder := NewDecoder(Options{Negotiable: true})
+
+	// negotiation only happens on the first message
+	messages, err := decoder.Feed([]byte("{\"event\":\"heartbeat\"}\n{\"protocol\":\"framed_json\"}\n{\"ev"))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"event":"heartbeat"}`), []byte(`{"protocol":"framed_json"}`)}, messages)
+
+	messages, err = decoder.Feed([]byte("ent\":\"log\"}\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"event":"log"}`)}, messages)
+	assert.Equal(t, PROTOCOL_JSON_LINES, decoder.Protocol())
+}
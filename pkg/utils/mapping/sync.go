@@ -46,6 +46,18 @@ func (m *Map[K, V]) Delete(key K) {
 	m.store.Delete(key)
 }
 
+// CompareAndDelete deletes the entry for key if its value is equal to old
+func (m *Map[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted = m.store.CompareAndDelete(key, old)
+	if deleted {
+		atomic.AddInt32(&m.len, -1)
+	}
+	return
+}
+
 func (m *Map[K, V]) Range(f func(key K, value V) bool) {
 	m.store.Range(func(key, value interface{}) bool {
 		return f(key.(K), value.(V))
@@ -108,4 +108,23 @@ func TestEdgeCases(t *testing.T) {
 	if m.Len() != 0 {
 		t.Error("Clear failed to reset map")
 	}
-}
\ No newline at end of file
+}
+// TestCompareAndDelete validates deletion only happens on the expected value
+func TestCompareAndDelete(t *testing.T) {
+	t.Parallel()
+	m := Map[string, int]{}
+
+	m.Store("answer", 42)
+	if m.CompareAndDelete("answer", 100) {
+		t.Error("CompareAndDelete should not delete a different value")
+	}
+	if !m.CompareAndDelete("answer", 42) {
+		t.Error("CompareAndDelete failed to delete the expected value")
+	}
+	if _, ok := m.Load("answer"); ok || m.Len() != 0 {
+		t.Error("CompareAndDelete failed to remove item")
+	}
+	if m.CompareAndDelete("answer", 42) {
+		t.Error("CompareAndDelete should not delete a missing key")
+	}
+}